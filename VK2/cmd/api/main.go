@@ -1,17 +1,32 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 
 	"vk/internal/adapter/handler"
-	_ "vk/internal/adapter/repository"
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+	"vk/internal/infrastructure/breach"
+	"vk/internal/infrastructure/mail"
+	"vk/internal/infrastructure/notify"
 	"vk/internal/infrastructure/postgres"
+	"vk/internal/infrastructure/storage"
+	"vk/internal/infrastructure/util"
+	"vk/internal/infrastructure/worker"
 	"vk/internal/usecase"
 )
 
@@ -24,13 +39,104 @@ func main() {
 	// Получаем порт из переменных окружения, по умолчанию 8080
 	port := os.Getenv("PORT")
 
-	tokenSecretKey := os.Getenv("JWT_SECRET_KEY")
-	if tokenSecretKey == "" {
-		log.Fatal("Переменная окружения JWT_SECRET_KEY не установлена.")
+	tokenManager, err := loadTokenManager()
+	if err != nil {
+		log.Fatalf("Не удалось настроить ключи токенов: %v", err)
 	}
 
-	// Устанавливаем время жизни токена
-	tokenExpiration := 24 * time.Hour
+	// Устанавливаем время жизни токенов: access-токен короткоживущий,
+	// refresh-токен используется для его обновления без ввода пароля
+	tokenExpiration := 15 * time.Minute
+	refreshTokenExpiration := 30 * 24 * time.Hour
+
+	// Коды восстановления второго фактора хранятся в виде HMAC с этим ключом.
+	// Случайный ключ не подходит: после перезапуска коды перестали бы действовать
+	recoveryCodeKey := os.Getenv("TWO_FACTOR_RECOVERY_KEY")
+	if recoveryCodeKey == "" {
+		log.Fatal("Переменная окружения TWO_FACTOR_RECOVERY_KEY не установлена.")
+	}
+
+	blobStorage, err := loadBlobStorage()
+	if err != nil {
+		log.Fatalf("Не удалось настроить хранилище файлов: %v", err)
+	}
+	imageURLTTL, err := getEnvDuration("IMAGE_URL_TTL", "1h")
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Курсоры ленты подписываются, чтобы клиент не мог подменить значения в них
+	adCursorKey, err := getEnvSecret("AD_CURSOR_SIGNING_KEY", "подписи курсоров ленты")
+	if err != nil {
+		log.Fatal(err)
+	}
+	imageWorkerInterval, err := getEnvDuration("IMAGE_WORKER_INTERVAL", "2s")
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Загрузка файла до MaxImageSize на медленном канале не укладывается в таймауты сервера
+	uploadTimeout, err := getEnvDuration("UPLOAD_TIMEOUT", "2m")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Срок публикации объявлений и напоминание владельцу до его истечения
+	adTTL, err := getEnvDuration("AD_TTL", "720h")
+	if err != nil {
+		log.Fatal(err)
+	}
+	adExpiryReminder, err := getEnvDuration("AD_EXPIRY_REMINDER", "72h")
+	if err != nil {
+		log.Fatal(err)
+	}
+	adExpirationInterval, err := getEnvDuration("AD_EXPIRATION_INTERVAL", "1m")
+	if err != nil {
+		log.Fatal(err)
+	}
+	adSchedulerInterval, err := getEnvDuration("AD_SCHEDULER_INTERVAL", "5s")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	passwordManager, err := loadPasswordManager()
+	if err != nil {
+		log.Fatalf("Не удалось настроить хеширование паролей: %v", err)
+	}
+
+	passwordPolicy, err := loadPasswordPolicy()
+	if err != nil {
+		log.Fatalf("Не удалось настроить проверку паролей: %v", err)
+	}
+
+	mailer, err := loadMailer()
+	if err != nil {
+		log.Fatalf("Не удалось настроить отправку писем: %v", err)
+	}
+	passwordResetTTL, err := getEnvDuration("PASSWORD_RESET_TTL", "1h")
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Письмо со ссылкой для сброса не должно оставаться в очереди дольше, чем действует ссылка
+	if passwordResetTTL <= usecase.EmailOutboxRetention {
+		log.Fatalf("PASSWORD_RESET_TTL должен быть больше %s — наибольшего времени хранения письма в очереди.", usecase.EmailOutboxRetention)
+	}
+	emailVerificationTTL, err := getEnvDuration("EMAIL_VERIFICATION_TTL", "48h")
+	if err != nil {
+		log.Fatal(err)
+	}
+	emailResendInterval, err := getEnvDuration("EMAIL_VERIFICATION_RESEND_INTERVAL", "1m")
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Текст писем в очереди шифруется: в нем бывают токены сброса пароля и подтверждения почты.
+	// Случайный ключ не подходит: после перезапуска неотправленные письма нельзя было бы расшифровать
+	emailOutboxKey := os.Getenv("EMAIL_OUTBOX_KEY")
+	if emailOutboxKey == "" {
+		log.Fatal("Переменная окружения EMAIL_OUTBOX_KEY не установлена.")
+	}
+	emailOutboxBox, err := util.NewSecretBox([]byte(emailOutboxKey))
+	if err != nil {
+		log.Fatalf("Не удалось настроить шифрование писем: %v", err)
+	}
 
 	// Инициализация базы данных PostgreSQL
 	dbURL := os.Getenv("DATABASE_URL")
@@ -51,14 +157,55 @@ func main() {
 	// Инициализация репозиториев
 	userRepo := postgres.NewPGUserRepository(db)
 	adRepo := postgres.NewPGAdRepository(db)
+	refreshTokenRepo := postgres.NewPGRefreshTokenRepository(db)
+	sessionRepo := postgres.NewPGSessionRepository(db)
+	roleRepo := postgres.NewPGRoleRepository(db)
+	categoryRepo := postgres.NewPGCategoryRepository(db)
+	rateRepo := postgres.NewPGExchangeRateRepository(db)
+	adImageRepo := postgres.NewPGAdImageRepository(db)
+	profileRepo := postgres.NewPGProfileRepository(db)
+	passwordResetRepo := postgres.NewPGPasswordResetRepository(db)
+	emailVerificationRepo := postgres.NewPGEmailVerificationRepository(db)
+	twoFactorRepo := postgres.NewPGTwoFactorRepository(db)
+	loginChallengeRepo := postgres.NewPGLoginChallengeRepository(db)
+	loginThrottleRepo := postgres.NewPGLoginThrottleRepository(db)
+	emailOutboxRepo := postgres.NewPGEmailOutboxRepository(db)
 
 	// Инициализация Use Cases
-	authUseCase := usecase.NewAuthUseCase(userRepo, tokenSecretKey, tokenExpiration) // Передаем tokenSecretKey
-	adUseCase := usecase.NewAdUseCase(adRepo)
+	// Письма отправляются через очередь в БД, сценарии только ставят их в очередь
+	emailOutboxUseCase := usecase.NewEmailOutboxUseCase(emailOutboxRepo, mailer, emailOutboxBox)
+	loginThrottleUseCase := usecase.NewLoginThrottleUseCase(loginThrottleRepo)
+	emailUseCase := usecase.NewEmailUseCase(userRepo, emailVerificationRepo, emailOutboxUseCase, loginThrottleUseCase, passwordManager, emailVerificationTTL, emailResendInterval, os.Getenv("EMAIL_VERIFICATION_URL"))
+	twoFactorUseCase := usecase.NewTwoFactorUseCase(userRepo, twoFactorRepo, loginChallengeRepo, sessionRepo, loginThrottleUseCase, passwordManager, []byte(recoveryCodeKey), getEnvDefault("TOTP_ISSUER", "VK Marketplace"))
+	authUseCase := usecase.NewAuthUseCase(userRepo, refreshTokenRepo, sessionRepo, roleRepo, emailUseCase, twoFactorUseCase, loginThrottleUseCase, passwordPolicy, passwordManager, tokenManager, tokenExpiration, refreshTokenExpiration)
+	adUseCase := usecase.NewAdUseCase(adRepo, categoryRepo, rateRepo, adImageRepo, blobStorage, adCursorKey, imageURLTTL, adTTL)
+	categoryUseCase := usecase.NewCategoryUseCase(categoryRepo)
+	roleUseCase := usecase.NewRoleUseCase(userRepo, roleRepo, sessionRepo)
+	rateUseCase := usecase.NewExchangeRateUseCase(rateRepo)
+	profileUseCase := usecase.NewProfileUseCase(userRepo, adRepo, profileRepo, blobStorage, imageURLTTL)
+	imageProcessingUseCase := usecase.NewImageProcessingUseCase(adImageRepo, blobStorage)
+	adExpirationUseCase := usecase.NewAdExpirationUseCase(adRepo, notify.NewLogNotifier(), adExpiryReminder)
+	adSchedulerUseCase := usecase.NewAdSchedulerUseCase(adRepo)
+	passwordUseCase := usecase.NewPasswordUseCase(userRepo, sessionRepo, passwordResetRepo, emailOutboxUseCase, loginThrottleUseCase, passwordPolicy, passwordManager, passwordResetTTL, os.Getenv("PASSWORD_RESET_URL"))
+
+	// Курсы валют из локального файла, если он задан; дальше курсы меняются через API
+	if path := os.Getenv("EXCHANGE_RATES_FILE"); path != "" {
+		if err := loadExchangeRates(path, rateUseCase); err != nil {
+			log.Fatalf("Не удалось загрузить курсы валют: %v", err)
+		}
+		log.Printf("Курсы валют загружены из %s.", path)
+	}
 
 	// Инициализация HTTP-обработчиков
 	authHandler := handler.NewAuthHandler(authUseCase)
 	adHandler := handler.NewAdHandler(adUseCase)
+	adminHandler := handler.NewAdminHandler(roleUseCase)
+	categoryHandler := handler.NewCategoryHandler(categoryUseCase)
+	rateHandler := handler.NewExchangeRateHandler(rateUseCase)
+	profileHandler := handler.NewProfileHandler(profileUseCase)
+	passwordHandler := handler.NewPasswordHandler(passwordUseCase)
+	emailHandler := handler.NewEmailHandler(emailUseCase)
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorUseCase)
 
 	// Настройка маршрутизатора
 	router := http.NewServeMux()
@@ -67,24 +214,389 @@ func main() {
 		fmt.Fprintln(w, "OK")
 	})
 
+	// Открытые ключи для проверки токенов другими сервисами
+	router.HandleFunc("GET /.well-known/jwks.json", handler.JWKSHandler(tokenManager))
+
 	// Маршруты для аутентификации и регистрации
 	router.HandleFunc("POST /auth/register", authHandler.RegisterUser)
 	router.HandleFunc("POST /auth/login", authHandler.LoginUser)
+	router.HandleFunc("POST /auth/login/2fa", authHandler.LoginTwoFactor)
+	router.HandleFunc("POST /auth/refresh", authHandler.RefreshToken)
+	router.Handle("POST /auth/logout", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(authHandler.Logout)))
+	router.Handle("POST /auth/logout-all", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(authHandler.LogoutAll)))
+	router.Handle("GET /auth/sessions", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(authHandler.ListSessions)))
+	router.HandleFunc("POST /auth/password-reset", passwordHandler.RequestPasswordReset)
+	router.HandleFunc("POST /auth/password-reset/confirm", passwordHandler.ResetPassword)
+	router.HandleFunc("POST /auth/verify-email", emailHandler.VerifyEmail)
+	router.Handle("POST /auth/verify-email/resend", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(emailHandler.ResendVerification)))
 
 	// Маршруты для объявлений
-	router.Handle("POST /ads", handler.AuthMiddleware(tokenSecretKey, authUseCase, http.HandlerFunc(adHandler.CreateAd))) // Передаем tokenSecretKey
-	router.HandleFunc("GET /ads", adHandler.GetAdsFeed)                                                                   // Лента объявлений не требует авторизации, но может использовать userID из контекста
+	// Размещать объявления могут только пользователи с подтвержденной почтой
+	router.Handle("POST /ads", handler.AuthMiddleware(tokenManager, authUseCase, handler.RequireVerifiedEmail(emailUseCase, http.HandlerFunc(adHandler.CreateAd))))
+	// Эндпоинты чтения доступны без авторизации, но при наличии токена используют userID из контекста
+	router.Handle("GET /ads", handler.OptionalAuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(adHandler.GetAdsFeed)))
+	router.Handle("GET /ads/{id}", handler.OptionalAuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(adHandler.GetAd)))
+	router.Handle("PATCH /ads/{id}", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(adHandler.UpdateAd)))
+	router.Handle("DELETE /ads/{id}", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(adHandler.DeleteAd)))
+	router.Handle("POST /ads/{id}/renew", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(adHandler.RenewAd)))
+	router.Handle("PUT /ads/{id}/status", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(adHandler.ChangeAdStatus)))
+	router.Handle("POST /ads/{id}/images", handler.UploadDeadline(uploadTimeout, handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(adHandler.UploadAdImage))))
+	router.Handle("PUT /ads/{id}/images/order", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(adHandler.ReorderAdImages)))
+	router.Handle("DELETE /ads/{id}/images/{imageId}", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(adHandler.DeleteAdImage)))
+
+	// Профиль и объявления текущего пользователя, открытые профили продавцов
+	router.Handle("GET /me", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(profileHandler.GetMyProfile)))
+	router.Handle("PATCH /me", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(profileHandler.UpdateMyProfile)))
+	router.Handle("PUT /me/avatar", handler.UploadDeadline(uploadTimeout, handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(profileHandler.UploadAvatar))))
+	router.Handle("DELETE /me/avatar", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(profileHandler.DeleteAvatar)))
+	router.Handle("POST /me/password", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(passwordHandler.ChangePassword)))
+	router.Handle("PUT /me/email", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(emailHandler.ChangeEmail)))
+	router.Handle("POST /me/2fa/enroll", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(twoFactorHandler.Enroll)))
+	router.Handle("POST /me/2fa/confirm", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(twoFactorHandler.Confirm)))
+	router.Handle("POST /me/2fa/disable", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(twoFactorHandler.Disable)))
+	router.Handle("GET /me/ads", handler.AuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(adHandler.ListMyAds)))
+	router.Handle("GET /users/{id}", handler.OptionalAuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(profileHandler.GetPublicProfile)))
+	router.Handle("GET /users/{id}/ads", handler.OptionalAuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(adHandler.ListUserAds)))
+
+	// Файлы локального хранилища отдаются по подписанным ссылкам
+	if localStorage, ok := blobStorage.(*storage.LocalBlobStorage); ok {
+		router.HandleFunc("GET /files/{key...}", handler.FileHandler(localStorage))
+	}
+
+	// Маршруты для категорий
+	router.Handle("GET /categories", handler.OptionalAuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(categoryHandler.GetCategoryTree)))
+
+	// Курсы валют
+	router.Handle("GET /rates", handler.OptionalAuthMiddleware(tokenManager, authUseCase, http.HandlerFunc(rateHandler.ListRates)))
+
+	// Административные маршруты
+	router.Handle("PUT /admin/users/{id}/role", handler.AuthMiddleware(tokenManager, authUseCase, handler.RequirePermission(domain.PermissionManageRoles, http.HandlerFunc(adminHandler.SetUserRole))))
+	router.Handle("POST /admin/categories", handler.AuthMiddleware(tokenManager, authUseCase, handler.RequirePermission(domain.PermissionManageCategories, http.HandlerFunc(categoryHandler.CreateCategory))))
+	router.Handle("PATCH /admin/categories/{id}", handler.AuthMiddleware(tokenManager, authUseCase, handler.RequirePermission(domain.PermissionManageCategories, http.HandlerFunc(categoryHandler.UpdateCategory))))
+	router.Handle("DELETE /admin/categories/{id}", handler.AuthMiddleware(tokenManager, authUseCase, handler.RequirePermission(domain.PermissionManageCategories, http.HandlerFunc(categoryHandler.DeleteCategory))))
+	router.Handle("PUT /admin/rates/{currency}", handler.AuthMiddleware(tokenManager, authUseCase, handler.RequirePermission(domain.PermissionManageRates, http.HandlerFunc(rateHandler.SetRate))))
 
 	server := &http.Server{
-		Addr:         ":" + port,
-		Handler:      router,
-		ReadTimeout:  5 * time.Second,
+		Addr:              ":" + port,
+		Handler:           router,
+		ReadHeaderTimeout: 5 * time.Second,
+		// Таймауты рассчитаны на обычные запросы; маршруты загрузки файлов
+		// продлевают их до UPLOAD_TIMEOUT (handler.UploadDeadline)
+		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
 
-	log.Printf("Сервер слушает на порту %s...", port)
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	// Фоновые задачи и сервер останавливаются по SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Перед закрытием базы дожидаемся, пока фоновые задачи завершат текущую работу
+	var workers sync.WaitGroup
+	runWorker := func(name string, interval time.Duration, job worker.Job) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			worker.Run(ctx, name, interval, job)
+		}()
+	}
+
+	// Варианты изображений строятся в фоне: очередь хранится в БД
+	runWorker("image-variants", imageWorkerInterval, imageProcessingUseCase.ProcessNextImage)
+	// Истекшие объявления уходят в архив, владельцы получают напоминания заранее
+	runWorker("ad-expiration", adExpirationInterval, adExpirationUseCase.ArchiveExpiredAds)
+	runWorker("ad-expiry-reminders", adExpirationInterval, adExpirationUseCase.SendExpiryReminders)
+	// Отложенная публикация: расписание хранится в БД и переживает перезапуск
+	runWorker("ad-scheduler", adSchedulerInterval, adSchedulerUseCase.PublishScheduledAds)
+	// Счетчики попыток входа, по которым давно не было неудач, больше не нужны
+	runWorker("login-throttle-cleanup", 10*time.Minute, loginThrottleUseCase.DeleteStaleThrottles)
+	// Письма для сброса пароля отправляются в фоне, чтобы время ответа не выдавало зарегистрированные адреса
+	runWorker("password-reset-requests", time.Second, passwordUseCase.ProcessPasswordResetRequest)
+	runWorker("email-outbox", time.Second, emailOutboxUseCase.DeliverNextEmail)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Сервер слушает на порту %s...", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
 		log.Fatalf("Не удалось слушать на порту %s: %v\n", port, err)
+	case <-ctx.Done():
+	}
+
+	log.Println("Остановка сервера...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Ошибка при остановке сервера: %v", err)
+	}
+
+	log.Println("Ожидание завершения фоновых задач...")
+	workers.Wait()
+}
+
+// loadTokenManager настраивает ключи JWT из переменных окружения:
+//   - JWT_PRIVATE_KEY_FILE — PEM с RSA (RS256) или Ed25519 (EdDSA) ключом; если задан, используется для подписи;
+//   - JWT_SECRET_KEY — секрет HS256; используется для подписи, если асимметричный ключ не задан;
+//   - JWT_PREVIOUS_SECRET_KEYS — прежние секреты через запятую, принимаются только для проверки;
+//   - JWT_VERIFICATION_KEY_FILES — PEM-файлы прежних асимметричных ключей через запятую;
+//   - JWT_ISSUER, JWT_AUDIENCE — значения утверждений iss и aud.
+func loadTokenManager() (*util.TokenManager, error) {
+	var signingKey *util.SigningKey
+	var verificationKeys []*util.SigningKey
+
+	if path := os.Getenv("JWT_PRIVATE_KEY_FILE"); path != "" {
+		key, err := readKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if !key.CanSign() {
+			return nil, fmt.Errorf("%s не содержит приватный ключ", path)
+		}
+		signingKey = key
+	}
+
+	if secret := os.Getenv("JWT_SECRET_KEY"); secret != "" {
+		if signingKey == nil {
+			signingKey = util.NewHMACKey(secret)
+		} else {
+			// После перехода на асимметричную подпись старый секрет принимается до истечения токенов
+			verificationKeys = append(verificationKeys, util.NewHMACKey(secret))
+		}
+	}
+	if signingKey == nil {
+		return nil, fmt.Errorf("не задана ни переменная JWT_SECRET_KEY, ни JWT_PRIVATE_KEY_FILE")
+	}
+
+	for _, secret := range splitList(os.Getenv("JWT_PREVIOUS_SECRET_KEYS")) {
+		verificationKeys = append(verificationKeys, util.NewHMACKey(secret))
+	}
+	for _, path := range splitList(os.Getenv("JWT_VERIFICATION_KEY_FILES")) {
+		key, err := readKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+
+	issuer := getEnvDefault("JWT_ISSUER", "vk-marketplace")
+	audience := getEnvDefault("JWT_AUDIENCE", "vk-marketplace-api")
+	return util.NewTokenManager(signingKey, verificationKeys, issuer, audience)
+}
+
+// loadExchangeRates загружает курсы валют из JSON-файла вида {"USD": "92.5", "KZT": 0.19}:
+// сколько рублей стоит единица валюты.
+func loadExchangeRates(path string, rateUseCase *usecase.ExchangeRateUseCase) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать файл курсов %s: %w", path, err)
+	}
+	var decoded map[string]domain.Decimal
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return fmt.Errorf("файл курсов %s: %w", path, err)
+	}
+
+	rates := make(map[string]string, len(decoded))
+	for currency, rate := range decoded {
+		rates[currency] = string(rate)
+	}
+	return rateUseCase.SetRates(rates)
+}
+
+// loadBlobStorage настраивает хранилище изображений из переменных окружения:
+//   - STORAGE_BACKEND — local (по умолчанию) или s3;
+//   - STORAGE_LOCAL_DIR — каталог локального хранилища (по умолчанию ./uploads);
+//   - STORAGE_SIGNING_KEY — секрет подписи ссылок на файлы локального хранилища;
+//   - PUBLIC_BASE_URL — внешний адрес сервиса для ссылок на файлы;
+//   - S3_ENDPOINT, S3_PUBLIC_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY_ID,
+//     S3_SECRET_ACCESS_KEY — параметры S3-совместимого хранилища.
+func loadBlobStorage() (repository.BlobStorage, error) {
+	switch backend := getEnvDefault("STORAGE_BACKEND", "local"); backend {
+	case "local":
+		signingKey, err := getEnvSecret("STORAGE_SIGNING_KEY", "подписи ссылок на файлы")
+		if err != nil {
+			return nil, err
+		}
+		return storage.NewLocalBlobStorage(getEnvDefault("STORAGE_LOCAL_DIR", "./uploads"), os.Getenv("PUBLIC_BASE_URL"), signingKey)
+	case "s3":
+		return storage.NewS3BlobStorage(storage.S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			PublicEndpoint:  os.Getenv("S3_PUBLIC_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("неизвестное хранилище STORAGE_BACKEND=%q", backend)
+	}
+}
+
+// loadMailer настраивает отправку писем из переменных окружения:
+//   - MAIL_BACKEND — file (по умолчанию, письма сохраняются в MAIL_DIR) или smtp;
+//   - MAIL_FROM — адрес отправителя;
+//   - SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD — параметры SMTP-сервера.
+func loadMailer() (repository.Mailer, error) {
+	from := getEnvDefault("MAIL_FROM", "no-reply@vk-marketplace.local")
+	switch backend := getEnvDefault("MAIL_BACKEND", "file"); backend {
+	case "file":
+		return mail.NewFileMailer(getEnvDefault("MAIL_DIR", "./mail"), from)
+	case "smtp":
+		port, err := strconv.Atoi(getEnvDefault("SMTP_PORT", "587"))
+		if err != nil {
+			return nil, fmt.Errorf("некорректное значение SMTP_PORT: %q", os.Getenv("SMTP_PORT"))
+		}
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		})
+	default:
+		return nil, fmt.Errorf("неизвестный способ отправки писем MAIL_BACKEND=%q", backend)
+	}
+}
+
+// loadPasswordManager настраивает хеширование паролей из переменных окружения:
+//   - PASSWORD_HASH_ALGORITHM — argon2id (по умолчанию) или bcrypt;
+//   - ARGON2_MEMORY (КиБ), ARGON2_ITERATIONS, ARGON2_PARALLELISM — параметры Argon2id;
+//   - BCRYPT_COST — стоимость bcrypt.
+//
+// Хеши других алгоритмов и параметров по-прежнему принимаются и пересчитываются при входе.
+func loadPasswordManager() (*util.PasswordManager, error) {
+	var hasher util.PasswordHasher
+	switch algorithm := getEnvDefault("PASSWORD_HASH_ALGORITHM", "argon2id"); algorithm {
+	case "argon2id":
+		params := util.DefaultArgon2idParams
+		memory, err := getEnvInt("ARGON2_MEMORY", strconv.Itoa(int(params.Memory)))
+		if err != nil {
+			return nil, err
+		}
+		iterations, err := getEnvInt("ARGON2_ITERATIONS", strconv.Itoa(int(params.Iterations)))
+		if err != nil {
+			return nil, err
+		}
+		parallelism, err := getEnvInt("ARGON2_PARALLELISM", strconv.Itoa(int(params.Parallelism)))
+		if err != nil {
+			return nil, err
+		}
+		if parallelism > 255 {
+			return nil, fmt.Errorf("некорректное значение ARGON2_PARALLELISM: %d", parallelism)
+		}
+		params.Memory = uint32(memory)
+		params.Iterations = uint32(iterations)
+		params.Parallelism = uint8(parallelism)
+		if hasher, err = util.NewArgon2idHasher(params); err != nil {
+			return nil, err
+		}
+	case "bcrypt":
+		cost, err := getEnvInt("BCRYPT_COST", "10")
+		if err != nil {
+			return nil, err
+		}
+		if hasher, err = util.NewBcryptHasher(cost); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("неизвестный алгоритм PASSWORD_HASH_ALGORITHM=%q", algorithm)
+	}
+	return util.NewPasswordManager(hasher)
+}
+
+// loadPasswordPolicy настраивает проверку новых паролей из переменных окружения:
+//   - PASSWORD_MIN_STRENGTH — наименьшая оценка стойкости от 0 до 4 (по умолчанию 2);
+//   - PWNED_PASSWORDS_DIR — каталог базы паролей из утечек в формате Have I Been Pwned;
+//     если не задан, пароли проверяются по встроенному списку распространенных паролей
+//     (breach.NewCommonBreachedPasswordRepository).
+func loadPasswordPolicy() (*usecase.PasswordPolicy, error) {
+	minStrength, err := strconv.Atoi(getEnvDefault("PASSWORD_MIN_STRENGTH", "2"))
+	if err != nil || minStrength < 0 || minStrength > 4 {
+		return nil, fmt.Errorf("некорректное значение PASSWORD_MIN_STRENGTH: %q", os.Getenv("PASSWORD_MIN_STRENGTH"))
+	}
+
+	var breachedRepo repository.BreachedPasswordRepository
+	if dir := os.Getenv("PWNED_PASSWORDS_DIR"); dir != "" {
+		fileRepo, err := breach.NewFileBreachedPasswordRepository(dir)
+		if err != nil {
+			return nil, err
+		}
+		breachedRepo = fileRepo
+	} else {
+		// Без полной базы отсекаются хотя бы самые распространенные пароли
+		log.Println("ВНИМАНИЕ: PWNED_PASSWORDS_DIR не задан, пароли проверяются только по встроенному списку распространенных паролей, а не по базе утечек.")
+		breachedRepo = breach.NewCommonBreachedPasswordRepository()
+	}
+	return usecase.NewPasswordPolicy(breachedRepo, minStrength), nil
+}
+
+// readKeyFile читает ключ JWT из PEM-файла.
+func readKeyFile(path string) (*util.SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл ключа %s: %w", path, err)
+	}
+	key, err := util.ParseKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("файл ключа %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// splitList разбивает значение переменной окружения по запятым, отбрасывая пустые элементы.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvDuration возвращает положительную длительность из переменной окружения
+// или значение по умолчанию.
+func getEnvDuration(name, defaultValue string) (time.Duration, error) {
+	duration, err := time.ParseDuration(getEnvDefault(name, defaultValue))
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("некорректное значение %s: %q", name, os.Getenv(name))
+	}
+	return duration, nil
+}
+
+// getEnvInt возвращает положительное целое число из переменной окружения
+// или значение по умолчанию.
+func getEnvInt(name, defaultValue string) (int, error) {
+	value, err := strconv.Atoi(getEnvDefault(name, defaultValue))
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("некорректное значение %s: %q", name, os.Getenv(name))
+	}
+	return value, nil
+}
+
+// getEnvSecret возвращает секретный ключ из переменной окружения. Если
+// переменная не задана, используется случайный ключ: подписанные им значения
+// перестанут действовать после перезапуска.
+func getEnvSecret(name, purpose string) ([]byte, error) {
+	if value := os.Getenv(name); value != "" {
+		return []byte(value), nil
+	}
+	log.Printf("%s не задан, для %s используется случайный ключ.", name, purpose)
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// getEnvDefault возвращает значение переменной окружения или значение по умолчанию.
+func getEnvDefault(name, defaultValue string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
 	}
+	return defaultValue
 }
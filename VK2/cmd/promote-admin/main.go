@@ -0,0 +1,51 @@
+// Команда promote-admin назначает роль администратора существующему пользователю.
+// Используется для создания первого администратора, когда назначить роль через API еще некому.
+//
+// Использование:
+//
+//	promote-admin -login <логин>
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+
+	"vk/internal/infrastructure/postgres"
+	"vk/internal/usecase"
+)
+
+func main() {
+	login := flag.String("login", "", "логин пользователя, которому назначается роль администратора")
+	flag.Parse()
+
+	if *login == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Файл .env не найден, предполагается, что переменные окружения установлены.")
+	}
+
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		log.Fatal("Переменная окружения DATABASE_URL не установлена.")
+	}
+	db, err := postgres.NewPostgresDB(dbURL)
+	if err != nil {
+		log.Fatalf("Не удалось подключиться к базе данных: %v", err)
+	}
+	defer db.Close()
+
+	roleUseCase := usecase.NewRoleUseCase(postgres.NewPGUserRepository(db), postgres.NewPGRoleRepository(db), postgres.NewPGSessionRepository(db))
+
+	user, err := roleUseCase.PromoteToAdmin(*login)
+	if err != nil {
+		log.Fatalf("Не удалось назначить роль администратора: %v", err)
+	}
+
+	log.Printf("Пользователь %s (%s) теперь администратор. Роль вступит в силу при следующем входе.", user.Login, user.ID)
+}
@@ -7,4 +7,7 @@ require (
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.10.9
 	golang.org/x/crypto v0.40.0
+	golang.org/x/image v0.25.0
 )
+
+require golang.org/x/sys v0.34.0 // indirect
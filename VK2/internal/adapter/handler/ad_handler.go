@@ -3,10 +3,13 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
 
+	"vk/internal/domain"
 	"vk/internal/usecase"
 )
 
@@ -19,22 +22,59 @@ func NewAdHandler(adUseCase *usecase.AdUseCase) *AdHandler {
 	return &AdHandler{adUseCase: adUseCase}
 }
 
+// CreateAdRequest содержит поля нового объявления. Цена принимается JSON-числом
+// или строкой ("1500.50") и не округляется; валюта по умолчанию — RUB.
+// Изображение загружается отдельным запросом POST /ads/{id}/images.
 type CreateAdRequest struct {
-	Title       string  `json:"title"`
-	Description string  `json:"description"`
-	ImageURL    string  `json:"image_url"`
-	Price       float64 `json:"price"`
+	CategoryID  string         `json:"category_id"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Price       domain.Decimal `json:"price"`
+	Currency    string         `json:"currency"`
+	Status      string         `json:"status"` // draft, published (по умолчанию) или scheduled; при заданном publish_at по умолчанию scheduled
+	// Время отложенной публикации в формате RFC 3339; до него объявление не показывается в ленте
+	PublishAt *time.Time `json:"publish_at"`
 }
 
 type AdResponse struct {
-	ID          string    `json:"id"`
-	UserID      string    `json:"user_id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	ImageURL    string    `json:"image_url"`
-	Price       float64   `json:"price"`
-	CreatedAt   time.Time `json:"created_at"`
-	IsOwner     bool      `json:"is_owner,omitempty"` // Дополнительное поле для авторизованных пользователей
+	ID          string `json:"id"`
+	UserID      string `json:"user_id"`
+	CategoryID  string `json:"category_id,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Обложка: подписанная ссылка с ограниченным сроком действия. В ленте — миниатюра
+	// обложки, появляется после обработки изображения
+	ImageURL string `json:"image_url,omitempty"`
+	// Все изображения по порядку; только в ответах с одним объявлением, в ленте — лишь обложка
+	Images   []AdImageResponse `json:"images,omitempty"`
+	Price    domain.Amount     `json:"price"` // Число с двумя знаками после запятой
+	Currency string            `json:"currency"`
+	Status   string            `json:"status"`
+	// Цена в валюте, запрошенной параметром currency ленты
+	ConvertedPrice *domain.Money `json:"converted_price,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+	ExpiresAt      time.Time     `json:"expires_at"`             // После этого момента объявление уходит в архив
+	PublishAt      *time.Time    `json:"publish_at,omitempty"`   // Только у запланированных объявлений
+	PublishedAt    *time.Time    `json:"published_at,omitempty"` // Момент последней публикации; лента сортируется по нему
+	IsOwner        bool          `json:"is_owner,omitempty"`     // Дополнительное поле для авторизованных пользователей
+	// Фрагменты с подсвеченными совпадениями, только при поиске по q
+	Highlight *domain.AdHighlight `json:"highlight,omitempty"`
+}
+
+type AdImageResponse struct {
+	ID       string `json:"id"`
+	URL      string `json:"url"` // Оригинал
+	Position int    `json:"position"`
+	IsCover  bool   `json:"is_cover"`
+	Status   string `json:"status"` // Состояние построения вариантов: pending, processing, ready, failed
+	// Готовые варианты по имени: thumbnail, medium, large
+	Variants map[string]AdImageVariantResponse `json:"variants,omitempty"`
+}
+
+type AdImageVariantResponse struct {
+	URL    string `json:"url"` // JPEG
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
 }
 
 // CreateAd обрабатывает запрос на создание нового объявления.
@@ -51,7 +91,15 @@ func (h *AdHandler) CreateAd(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ad, err := h.adUseCase.CreateAd(userID, req.Title, req.Description, req.ImageURL, req.Price)
+	ad, err := h.adUseCase.CreateAd(userID, usecase.CreateAdParameters{
+		CategoryID:  req.CategoryID,
+		Title:       req.Title,
+		Description: req.Description,
+		Price:       string(req.Price),
+		Currency:    req.Currency,
+		Status:      req.Status,
+		PublishAt:   req.PublishAt,
+	})
 	if err != nil {
 		var validationErr *usecase.ValidationErr
 		if errors.As(err, &validationErr) {
@@ -62,27 +110,276 @@ func (h *AdHandler) CreateAd(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSONResponse(w, http.StatusCreated, AdResponse{
-		ID:          ad.ID,
-		UserID:      ad.UserID,
-		Title:       ad.Title,
-		Description: ad.Description,
-		ImageURL:    ad.ImageURL,
-		Price:       ad.Price,
-		CreatedAt:   ad.CreatedAt,
-		IsOwner:     true, // Создатель всегда является владельцем
+	// Создатель всегда является владельцем
+	writeJSONResponse(w, http.StatusCreated, newAdResponse(ad, userID))
+}
+
+// GetAd обрабатывает запрос на получение одного объявления.
+func (h *AdHandler) GetAd(w http.ResponseWriter, r *http.Request) {
+	ad, err := h.adUseCase.GetAd(actorFromContext(r.Context()), r.PathValue("id"))
+	if err != nil {
+		writeAdError(w, err, "Не удалось получить объявление")
+		return
+	}
+
+	currentUserID, _ := r.Context().Value(ContextKeyUserID).(string)
+	writeJSONResponse(w, http.StatusOK, newAdResponse(ad, currentUserID))
+}
+
+// UpdateAdRequest содержит поля для частичного обновления объявления.
+// Отсутствующие в запросе поля не изменяются.
+type UpdateAdRequest struct {
+	CategoryID  *string         `json:"category_id"`
+	Title       *string         `json:"title"`
+	Description *string         `json:"description"`
+	Price       *domain.Decimal `json:"price"`
+	Currency    *string         `json:"currency"`
+}
+
+// UpdateAd обрабатывает запрос на изменение объявления.
+func (h *AdHandler) UpdateAd(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(ContextKeyUserID).(string)
+	if !ok || userID == "" {
+		writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: "Не авторизован: ID пользователя не найден в контексте"})
+		return
+	}
+
+	var req UpdateAdRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Неверная полезная нагрузка запроса", Details: err.Error()})
+		return
+	}
+
+	ad, err := h.adUseCase.UpdateAd(actorFromContext(r.Context()), r.PathValue("id"), usecase.UpdateAdParameters{
+		CategoryID:  req.CategoryID,
+		Title:       req.Title,
+		Description: req.Description,
+		Price:       (*string)(req.Price),
+		Currency:    req.Currency,
 	})
+	if err != nil {
+		writeAdError(w, err, "Не удалось обновить объявление")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, newAdResponse(ad, userID))
+}
+
+// DeleteAd обрабатывает запрос на удаление объявления.
+func (h *AdHandler) DeleteAd(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(ContextKeyUserID).(string)
+	if !ok || userID == "" {
+		writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: "Не авторизован: ID пользователя не найден в контексте"})
+		return
+	}
+
+	if err := h.adUseCase.DeleteAd(actorFromContext(r.Context()), r.PathValue("id")); err != nil {
+		writeAdError(w, err, "Не удалось удалить объявление")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangeAdStatusRequest содержит новый статус объявления.
+type ChangeAdStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// ChangeAdStatus обрабатывает запрос на изменение статуса объявления.
+func (h *AdHandler) ChangeAdStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(ContextKeyUserID).(string)
+	if !ok || userID == "" {
+		writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: "Не авторизован: ID пользователя не найден в контексте"})
+		return
+	}
+
+	var req ChangeAdStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Неверная полезная нагрузка запроса", Details: err.Error()})
+		return
+	}
+
+	ad, err := h.adUseCase.ChangeAdStatus(actorFromContext(r.Context()), r.PathValue("id"), req.Status)
+	if err != nil {
+		writeAdError(w, err, "Не удалось изменить статус объявления")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, newAdResponse(ad, userID))
+}
+
+// RenewAd обрабатывает запрос на продление срока публикации объявления.
+func (h *AdHandler) RenewAd(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(ContextKeyUserID).(string)
+	if !ok || userID == "" {
+		writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: "Не авторизован: ID пользователя не найден в контексте"})
+		return
+	}
+
+	ad, err := h.adUseCase.RenewAd(actorFromContext(r.Context()), r.PathValue("id"))
+	if err != nil {
+		writeAdError(w, err, "Не удалось продлить объявление")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, newAdResponse(ad, userID))
+}
+
+// imageFormField — поле multipart-формы с файлом изображения.
+const imageFormField = "image"
+
+// UploadAdImage обрабатывает загрузку изображения объявления (multipart/form-data, поле image).
+// Изображение добавляется в конец списка.
+func (h *AdHandler) UploadAdImage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(ContextKeyUserID).(string)
+	if !ok || userID == "" {
+		writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: "Не авторизован: ID пользователя не найден в контексте"})
+		return
+	}
+
+	// Запас сверх размера файла — на заголовки и границы multipart
+	r.Body = http.MaxBytesReader(w, r.Body, usecase.MaxImageSize+64<<10)
+	data, err := readFormFile(r, imageFormField, usecase.MaxImageSize)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeAdError(w, usecase.ErrImageTooLarge, "")
+			return
+		}
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Неверная полезная нагрузка запроса", Details: err.Error()})
+		return
+	}
+
+	ad, err := h.adUseCase.UploadAdImage(actorFromContext(r.Context()), r.PathValue("id"), data)
+	if err != nil {
+		writeAdError(w, err, "Не удалось загрузить изображение")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, newAdResponse(ad, userID))
+}
+
+// ReorderAdImagesRequest задает новый порядок изображений и, при необходимости, обложку.
+type ReorderAdImagesRequest struct {
+	ImageIDs []string `json:"image_ids"`
+	CoverID  string   `json:"cover_id"` // Пустое значение — обложка не меняется
+}
+
+// ReorderAdImages обрабатывает запрос на изменение порядка изображений объявления.
+func (h *AdHandler) ReorderAdImages(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(ContextKeyUserID).(string)
+	if !ok || userID == "" {
+		writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: "Не авторизован: ID пользователя не найден в контексте"})
+		return
+	}
+
+	var req ReorderAdImagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Неверная полезная нагрузка запроса", Details: err.Error()})
+		return
+	}
+
+	ad, err := h.adUseCase.ReorderAdImages(actorFromContext(r.Context()), r.PathValue("id"), req.ImageIDs, req.CoverID)
+	if err != nil {
+		writeAdError(w, err, "Не удалось изменить порядок изображений")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, newAdResponse(ad, userID))
+}
+
+// DeleteAdImage обрабатывает запрос на удаление изображения объявления.
+func (h *AdHandler) DeleteAdImage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(ContextKeyUserID).(string)
+	if !ok || userID == "" {
+		writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: "Не авторизован: ID пользователя не найден в контексте"})
+		return
+	}
+
+	ad, err := h.adUseCase.DeleteAdImage(actorFromContext(r.Context()), r.PathValue("id"), r.PathValue("imageId"))
+	if err != nil {
+		writeAdError(w, err, "Не удалось удалить изображение")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, newAdResponse(ad, userID))
+}
+
+// readFormFile читает файл из поля field multipart-формы, не сохраняя его на диск.
+// Возвращает не больше maxSize+1 байт, чтобы превышение размера можно было обнаружить.
+func readFormFile(r *http.Request, field string, maxSize int64) ([]byte, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, fmt.Errorf("поле %q с файлом не найдено", field)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == field {
+			return io.ReadAll(io.LimitReader(part, maxSize+1))
+		}
+	}
 }
 
 type ListAdsResponse struct {
 	Ads        []AdResponse `json:"ads"`
 	TotalCount int          `json:"total_count"`
-	Page       int          `json:"page"`
+	Page       int          `json:"page,omitempty"` // Не заполняется при пагинации по курсору
 	Limit      int          `json:"limit"`
+	NextCursor string       `json:"next_cursor,omitempty"`
 }
 
 // GetAdsFeed обрабатывает запрос на получение ленты объявлений.
 func (h *AdHandler) GetAdsFeed(w http.ResponseWriter, r *http.Request) {
+	params := parseListAdsParameters(r)
+	result, err := h.adUseCase.ListAds(params)
+	if err != nil {
+		writeAdError(w, err, "Не удалось получить объявления")
+		return
+	}
+	writeAdsPage(w, r, params, result)
+}
+
+// ListUserAds обрабатывает запрос на получение опубликованных объявлений продавца.
+// Поддерживает те же параметры, что и лента.
+func (h *AdHandler) ListUserAds(w http.ResponseWriter, r *http.Request) {
+	params := parseListAdsParameters(r)
+	params.UserID = r.PathValue("id")
+	result, err := h.adUseCase.ListAds(params)
+	if err != nil {
+		writeAdError(w, err, "Не удалось получить объявления")
+		return
+	}
+	writeAdsPage(w, r, params, result)
+}
+
+// ListMyAds обрабатывает запрос на получение объявлений текущего пользователя
+// во всех статусах. Параметр status ограничивает выборку одним статусом.
+func (h *AdHandler) ListMyAds(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(ContextKeyUserID).(string)
+	if !ok || userID == "" {
+		writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: "Не авторизован: ID пользователя не найден в контексте"})
+		return
+	}
+
+	params := parseListAdsParameters(r)
+	result, err := h.adUseCase.ListOwnAds(actorFromContext(r.Context()), params, r.URL.Query().Get("status"))
+	if err != nil {
+		writeAdError(w, err, "Не удалось получить объявления")
+		return
+	}
+	writeAdsPage(w, r, params, result)
+}
+
+// parseListAdsParameters разбирает параметры пагинации, сортировки и фильтрации
+// списка объявлений. Некорректные числовые значения заменяются значениями по умолчанию.
+func parseListAdsParameters(r *http.Request) usecase.ListAdsParameters {
 	params := usecase.ListAdsParameters{}
 
 	pageStr := r.URL.Query().Get("page")
@@ -91,6 +388,10 @@ func (h *AdHandler) GetAdsFeed(w http.ResponseWriter, r *http.Request) {
 	sortOrder := r.URL.Query().Get("sort_order")
 	minPriceStr := r.URL.Query().Get("min_price")
 	maxPriceStr := r.URL.Query().Get("max_price")
+	params.Currency = r.URL.Query().Get("currency")
+	params.CategoryID = r.URL.Query().Get("category")
+	params.Query = r.URL.Query().Get("q")
+	params.Cursor = r.URL.Query().Get("cursor")
 
 	var err error
 	params.Page, err = strconv.Atoi(pageStr)
@@ -106,44 +407,102 @@ func (h *AdHandler) GetAdsFeed(w http.ResponseWriter, r *http.Request) {
 	params.SortBy = sortBy
 	params.SortOrder = sortOrder
 
-	params.MinPrice, err = strconv.ParseFloat(minPriceStr, 64)
+	params.MinPrice, err = domain.ParseAmount(minPriceStr)
 	if err != nil {
 		params.MinPrice = 0
 	}
 
-	params.MaxPrice, err = strconv.ParseFloat(maxPriceStr, 64)
+	params.MaxPrice, err = domain.ParseAmount(maxPriceStr)
 	if err != nil {
 		params.MaxPrice = 0
 	}
 
-	ads, totalCount, err := h.adUseCase.ListAds(params)
-	if err != nil {
-		writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: "Не удалось получить объявления", Details: err.Error()})
-		return
-	}
+	return params
+}
 
+// writeAdsPage отправляет страницу объявлений с учетом текущего пользователя.
+func writeAdsPage(w http.ResponseWriter, r *http.Request, params usecase.ListAdsParameters, result *usecase.ListAdsResult) {
 	// Получаем ID текущего пользователя из контекста (если авторизован)
 	currentUserID, _ := r.Context().Value(ContextKeyUserID).(string)
 
 	var adResponses []AdResponse
-	for _, ad := range ads {
-		isOwner := ad.UserID == currentUserID
-		adResponses = append(adResponses, AdResponse{
-			ID:          ad.ID,
-			UserID:      ad.UserID,
-			Title:       ad.Title,
-			Description: ad.Description,
-			ImageURL:    ad.ImageURL,
-			Price:       ad.Price,
-			CreatedAt:   ad.CreatedAt,
-			IsOwner:     isOwner,
-		})
+	for i := range result.Ads {
+		adResponses = append(adResponses, newAdResponse(&result.Ads[i], currentUserID))
 	}
 
-	writeJSONResponse(w, http.StatusOK, ListAdsResponse{
+	resp := ListAdsResponse{
 		Ads:        adResponses,
-		TotalCount: totalCount,
-		Page:       params.Page,
+		TotalCount: result.TotalCount,
 		Limit:      params.Limit,
-	})
+		NextCursor: result.NextCursor,
+	}
+	if params.Cursor == "" {
+		resp.Page = params.Page
+	}
+	writeJSONResponse(w, http.StatusOK, resp)
+}
+
+// newAdResponse преобразует объявление в ответ API с учетом текущего пользователя.
+func newAdResponse(ad *domain.Ad, currentUserID string) AdResponse {
+	var images []AdImageResponse
+	for _, image := range ad.Images {
+		var variants map[string]AdImageVariantResponse
+		if len(image.Variants) > 0 {
+			variants = make(map[string]AdImageVariantResponse, len(image.Variants))
+			for _, variant := range image.Variants {
+				variants[variant.Name] = AdImageVariantResponse{URL: variant.URL, Width: variant.Width, Height: variant.Height}
+			}
+		}
+		images = append(images, AdImageResponse{
+			ID:       image.ID,
+			URL:      image.URL,
+			Position: image.Position,
+			IsCover:  image.IsCover,
+			Status:   image.Status,
+			Variants: variants,
+		})
+	}
+
+	return AdResponse{
+		ID:             ad.ID,
+		UserID:         ad.UserID,
+		CategoryID:     ad.CategoryID,
+		Title:          ad.Title,
+		Description:    ad.Description,
+		ImageURL:       ad.ImageURL,
+		Images:         images,
+		Price:          ad.Price.Amount,
+		Currency:       ad.Price.Currency,
+		Status:         ad.Status,
+		ConvertedPrice: ad.ConvertedPrice,
+		CreatedAt:      ad.CreatedAt,
+		ExpiresAt:      ad.ExpiresAt,
+		PublishAt:      ad.PublishAt,
+		PublishedAt:    ad.PublishedAt,
+		IsOwner:        currentUserID != "" && ad.UserID == currentUserID,
+		Highlight:      ad.Highlight,
+	}
+}
+
+// writeAdError преобразует ошибку сценария работы с объявлениями в HTTP-ответ.
+func writeAdError(w http.ResponseWriter, err error, message string) {
+	var validationErr *usecase.ValidationErr
+	switch {
+	case errors.As(err, &validationErr):
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Ошибка валидации", Details: err.Error()})
+	case errors.Is(err, usecase.ErrAdNotFound):
+		writeJSONResponse(w, http.StatusNotFound, ErrorResponse{Message: "Объявление не найдено"})
+	case errors.Is(err, usecase.ErrImageNotFound):
+		writeJSONResponse(w, http.StatusNotFound, ErrorResponse{Message: "Изображение не найдено"})
+	case errors.Is(err, usecase.ErrImageTooLarge):
+		writeJSONResponse(w, http.StatusRequestEntityTooLarge, ErrorResponse{Message: "Слишком большой файл", Details: err.Error()})
+	case errors.Is(err, usecase.ErrUnsupportedImage):
+		writeJSONResponse(w, http.StatusUnsupportedMediaType, ErrorResponse{Message: "Неподдерживаемый формат изображения", Details: err.Error()})
+	case errors.Is(err, usecase.ErrInvalidStatusTransition):
+		writeJSONResponse(w, http.StatusConflict, ErrorResponse{Message: "Недопустимое изменение статуса объявления", Details: err.Error()})
+	case errors.Is(err, usecase.ErrForbidden):
+		writeJSONResponse(w, http.StatusForbidden, ErrorResponse{Message: "Доступ запрещен: вы не являетесь владельцем объявления"})
+	default:
+		writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: message, Details: err.Error()})
+	}
 }
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"vk/internal/usecase"
+)
+
+// AdminHandler обрабатывает административные HTTP-запросы.
+type AdminHandler struct {
+	roleUseCase *usecase.RoleUseCase
+}
+
+func NewAdminHandler(roleUseCase *usecase.RoleUseCase) *AdminHandler {
+	return &AdminHandler{roleUseCase: roleUseCase}
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type UserRoleResponse struct {
+	ID    string `json:"id"`
+	Login string `json:"login"`
+	Role  string `json:"role"`
+}
+
+// SetUserRole обрабатывает запрос на назначение роли пользователю.
+func (h *AdminHandler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	var req SetRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Неверная полезная нагрузка запроса", Details: err.Error()})
+		return
+	}
+
+	user, err := h.roleUseCase.SetUserRole(r.PathValue("id"), req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrUserNotFound):
+			writeJSONResponse(w, http.StatusNotFound, ErrorResponse{Message: err.Error()})
+		case errors.Is(err, usecase.ErrUnknownRole):
+			writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Ошибка валидации", Details: err.Error()})
+		default:
+			writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: "Не удалось изменить роль", Details: err.Error()})
+		}
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, UserRoleResponse{
+		ID:    user.ID,
+		Login: user.Login,
+		Role:  user.Role,
+	})
+}
@@ -3,7 +3,10 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"vk/internal/usecase"
@@ -21,11 +24,13 @@ func NewAuthHandler(authUseCase *usecase.AuthUseCase) *AuthHandler {
 type RegisterRequest struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
+	Email    string `json:"email"`
 }
 
 type RegisterResponse struct {
 	ID        string `json:"id"`
 	Login     string `json:"login"`
+	Email     string `json:"email,omitempty"`
 	CreatedAt string `json:"created_at"`
 }
 
@@ -43,6 +48,12 @@ func writeJSONResponse(w http.ResponseWriter, statuCode int, data interface{}) {
 	}
 }
 
+// writeRateLimitResponse отправляет ответ 429 с заголовком Retry-After в секундах.
+func writeRateLimitResponse(w http.ResponseWriter, err *usecase.RateLimitErr) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	writeJSONResponse(w, http.StatusTooManyRequests, ErrorResponse{Message: err.Error()})
+}
+
 // RegisterUser обрабатывает запрос на регистрацию нового пользователя.
 func (h *AuthHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 	var req RegisterRequest
@@ -51,14 +62,14 @@ func (h *AuthHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.authUseCase.RegisterUser(req.Login, req.Password)
+	user, err := h.authUseCase.RegisterUser(req.Login, req.Password, req.Email)
 	if err != nil {
 		var validationErr *usecase.ValidationErr
 		if errors.As(err, &validationErr) {
 			writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Validation error", Details: err.Error()})
 			return
 		}
-		if errors.Is(err, usecase.ErrUserAlreadyExists) {
+		if errors.Is(err, usecase.ErrUserAlreadyExists) || errors.Is(err, usecase.ErrEmailAlreadyExists) {
 			writeJSONResponse(w, http.StatusConflict, ErrorResponse{Message: err.Error()})
 			return
 		}
@@ -69,6 +80,7 @@ func (h *AuthHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 	writeJSONResponse(w, http.StatusCreated, RegisterResponse{
 		ID:        user.ID,
 		Login:     user.Login,
+		Email:     user.Email,
 		CreatedAt: user.CreatedAt.Format(time.RFC3339),
 	})
 }
@@ -80,7 +92,17 @@ type LoginRequest struct {
 
 // LoginResponse представляет структуру ответа на вход.
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // Время жизни access-токена в секундах
+}
+
+// TwoFactorChallengeResponse возвращается вместо токенов, если у пользователя
+// подключен второй фактор: вход нужно завершить через /auth/login/2fa.
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int64  `json:"expires_in"` // Время на ввод кода в секундах
 }
 
 // LoginUser обрабатывает запрос на вход пользователя.
@@ -91,15 +113,180 @@ func (h *AuthHandler) LoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.authUseCase.AuthenticateUser(req.Login, req.Password)
+	result, err := h.authUseCase.AuthenticateUser(req.Login, req.Password, clientInfo(r))
 	if err != nil {
 		if errors.Is(err, usecase.ErrInvalidCredentials) {
 			writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: err.Error()})
 			return
 		}
+		var rateLimitErr *usecase.RateLimitErr
+		if errors.As(err, &rateLimitErr) {
+			writeRateLimitResponse(w, rateLimitErr)
+			return
+		}
 		writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: "Failed to authenticate user", Details: err.Error()})
 		return
 	}
 
-	writeJSONResponse(w, http.StatusOK, LoginResponse{Token: token})
+	if result.Challenge != nil {
+		writeJSONResponse(w, http.StatusOK, TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    result.Challenge.Token,
+			ExpiresIn:         int64(result.Challenge.ExpiresIn.Seconds()),
+		})
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, newLoginResponse(result.Tokens))
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"` // Код из приложения или код восстановления
+}
+
+// LoginTwoFactor обрабатывает второй шаг входа: проверку кода второго фактора.
+func (h *AuthHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload", Details: err.Error()})
+		return
+	}
+	if req.ChallengeToken == "" || req.Code == "" {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Validation error", Details: "challenge_token and code are required"})
+		return
+	}
+
+	tokens, err := h.authUseCase.CompleteTwoFactorLogin(req.ChallengeToken, req.Code, clientInfo(r))
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidTwoFactorCode) || errors.Is(err, usecase.ErrInvalidLoginChallenge) {
+			writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: err.Error()})
+			return
+		}
+		var rateLimitErr *usecase.RateLimitErr
+		if errors.As(err, &rateLimitErr) {
+			writeRateLimitResponse(w, rateLimitErr)
+			return
+		}
+		writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: "Failed to authenticate user", Details: err.Error()})
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, newLoginResponse(tokens))
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken обрабатывает запрос на обновление пары токенов по refresh-токену.
+func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload", Details: err.Error()})
+		return
+	}
+	if req.RefreshToken == "" {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Validation error", Details: "refresh_token is required"})
+		return
+	}
+
+	tokens, err := h.authUseCase.RefreshTokens(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidRefreshToken) || errors.Is(err, usecase.ErrRefreshTokenReused) {
+			writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: err.Error()})
+			return
+		}
+		writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: "Failed to refresh token", Details: err.Error()})
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, newLoginResponse(tokens))
+}
+
+// newLoginResponse формирует ответ с парой токенов.
+func newLoginResponse(tokens *usecase.AuthTokens) LoginResponse {
+	return LoginResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    int64(tokens.AccessExpiresIn.Seconds()),
+	}
+}
+
+// Logout обрабатывает запрос на завершение текущей сессии.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(ContextKeyUserID).(string)
+	sessionID, _ := r.Context().Value(ContextKeySessionID).(string)
+
+	if err := h.authUseCase.Logout(userID, sessionID); err != nil {
+		if errors.Is(err, usecase.ErrSessionRevoked) {
+			writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: err.Error()})
+			return
+		}
+		writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: "Failed to log out", Details: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll обрабатывает запрос на завершение всех сессий пользователя.
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(ContextKeyUserID).(string)
+
+	if err := h.authUseCase.LogoutAll(userID); err != nil {
+		writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: "Failed to log out from all sessions", Details: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SessionResponse описывает активную сессию пользователя.
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// ListSessions обрабатывает запрос на получение списка активных сессий.
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(ContextKeyUserID).(string)
+	sessionID, _ := r.Context().Value(ContextKeySessionID).(string)
+
+	sessions, err := h.authUseCase.ListSessions(userID)
+	if err != nil {
+		writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: "Failed to list sessions", Details: err.Error()})
+		return
+	}
+
+	resp := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == sessionID,
+		})
+	}
+
+	writeJSONResponse(w, http.StatusOK, resp)
+}
+
+// clientInfo извлекает из запроса данные об устройстве клиента.
+func clientInfo(r *http.Request) usecase.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return usecase.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IP:        ip,
+	}
 }
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"vk/internal/usecase"
+)
+
+// CategoryHandler обрабатывает HTTP-запросы, связанные с категориями.
+type CategoryHandler struct {
+	categoryUseCase *usecase.CategoryUseCase
+}
+
+func NewCategoryHandler(categoryUseCase *usecase.CategoryUseCase) *CategoryHandler {
+	return &CategoryHandler{categoryUseCase: categoryUseCase}
+}
+
+type CategoryRequest struct {
+	Name     *string `json:"name"`
+	ParentID *string `json:"parent_id"` // null или отсутствие поля — корневая категория
+}
+
+type CategoryResponse struct {
+	ID        string             `json:"id"`
+	ParentID  *string            `json:"parent_id"`
+	Name      string             `json:"name"`
+	CreatedAt time.Time          `json:"created_at"`
+	Children  []CategoryResponse `json:"children,omitempty"`
+}
+
+// GetCategoryTree обрабатывает запрос на получение дерева категорий.
+func (h *CategoryHandler) GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.categoryUseCase.GetCategoryTree()
+	if err != nil {
+		writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: "Не удалось получить категории", Details: err.Error()})
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, newCategoryTreeResponse(tree))
+}
+
+// CreateCategory обрабатывает запрос на создание категории.
+func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var req CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Неверная полезная нагрузка запроса", Details: err.Error()})
+		return
+	}
+
+	name := ""
+	if req.Name != nil {
+		name = *req.Name
+	}
+	parentID := req.ParentID
+	if parentID != nil && *parentID == "" {
+		parentID = nil
+	}
+
+	category, err := h.categoryUseCase.CreateCategory(name, parentID)
+	if err != nil {
+		writeCategoryError(w, err, "Не удалось создать категорию")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, CategoryResponse{
+		ID:        category.ID,
+		ParentID:  category.ParentID,
+		Name:      category.Name,
+		CreatedAt: category.CreatedAt,
+	})
+}
+
+// UpdateCategory обрабатывает запрос на изменение категории.
+func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	// parent_id: null и отсутствие поля различаются, поэтому сначала разбираем сырой JSON
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Неверная полезная нагрузка запроса", Details: err.Error()})
+		return
+	}
+
+	params := usecase.UpdateCategoryParameters{}
+	if value, ok := raw["name"]; ok {
+		if err := json.Unmarshal(value, &params.Name); err != nil {
+			writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Неверная полезная нагрузка запроса", Details: err.Error()})
+			return
+		}
+	}
+	if value, ok := raw["parent_id"]; ok {
+		parentID := ""
+		if string(value) != "null" {
+			if err := json.Unmarshal(value, &parentID); err != nil {
+				writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Неверная полезная нагрузка запроса", Details: err.Error()})
+				return
+			}
+		}
+		params.ParentID = &parentID
+	}
+
+	category, err := h.categoryUseCase.UpdateCategory(r.PathValue("id"), params)
+	if err != nil {
+		writeCategoryError(w, err, "Не удалось обновить категорию")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, CategoryResponse{
+		ID:        category.ID,
+		ParentID:  category.ParentID,
+		Name:      category.Name,
+		CreatedAt: category.CreatedAt,
+	})
+}
+
+// DeleteCategory обрабатывает запрос на удаление категории.
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	if err := h.categoryUseCase.DeleteCategory(r.PathValue("id")); err != nil {
+		writeCategoryError(w, err, "Не удалось удалить категорию")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newCategoryTreeResponse рекурсивно преобразует дерево категорий в ответ API.
+func newCategoryTreeResponse(nodes []*usecase.CategoryNode) []CategoryResponse {
+	resp := make([]CategoryResponse, 0, len(nodes))
+	for _, node := range nodes {
+		resp = append(resp, CategoryResponse{
+			ID:        node.ID,
+			ParentID:  node.ParentID,
+			Name:      node.Name,
+			CreatedAt: node.CreatedAt,
+			Children:  newCategoryTreeResponse(node.Children),
+		})
+	}
+	return resp
+}
+
+// writeCategoryError преобразует ошибку сценария работы с категориями в HTTP-ответ.
+func writeCategoryError(w http.ResponseWriter, err error, message string) {
+	var validationErr *usecase.ValidationErr
+	switch {
+	case errors.As(err, &validationErr):
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Ошибка валидации", Details: err.Error()})
+	case errors.Is(err, usecase.ErrCategoryNotFound):
+		writeJSONResponse(w, http.StatusNotFound, ErrorResponse{Message: err.Error()})
+	case errors.Is(err, usecase.ErrCategoryInUse):
+		writeJSONResponse(w, http.StatusConflict, ErrorResponse{Message: err.Error()})
+	default:
+		writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: message, Details: err.Error()})
+	}
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"vk/internal/usecase"
+)
+
+// EmailHandler обрабатывает HTTP-запросы смены и подтверждения почты.
+type EmailHandler struct {
+	emailUseCase *usecase.EmailUseCase
+}
+
+func NewEmailHandler(emailUseCase *usecase.EmailUseCase) *EmailHandler {
+	return &EmailHandler{emailUseCase: emailUseCase}
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type ChangeEmailResponse struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// VerifyEmail обрабатывает запрос на подтверждение почты по токену из письма.
+func (h *EmailHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload", Details: err.Error()})
+		return
+	}
+	if req.Token == "" {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Validation error", Details: "token is required"})
+		return
+	}
+
+	if err := h.emailUseCase.VerifyEmail(req.Token); err != nil {
+		writeEmailError(w, err, "Failed to verify email")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResendVerification обрабатывает запрос на повторную отправку письма для подтверждения почты.
+func (h *EmailHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(ContextKeyUserID).(string)
+
+	if err := h.emailUseCase.ResendVerification(userID); err != nil {
+		writeEmailError(w, err, "Failed to send verification email")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ChangeEmail обрабатывает запрос на смену почты текущего пользователя.
+func (h *EmailHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(ContextKeyUserID).(string)
+
+	var req ChangeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload", Details: err.Error()})
+		return
+	}
+
+	user, err := h.emailUseCase.ChangeEmail(userID, req.Password, req.Email, clientInfo(r).IP)
+	if err != nil {
+		writeEmailError(w, err, "Failed to change email")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, ChangeEmailResponse{
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified(),
+	})
+}
+
+// writeEmailError отправляет ответ с HTTP-статусом, соответствующим ошибке работы с почтой.
+func writeEmailError(w http.ResponseWriter, err error, message string) {
+	var validationErr *usecase.ValidationErr
+	var rateLimitErr *usecase.RateLimitErr
+	switch {
+	case errors.As(err, &validationErr):
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Validation error", Details: err.Error()})
+	case errors.As(err, &rateLimitErr):
+		writeRateLimitResponse(w, rateLimitErr)
+	case errors.Is(err, usecase.ErrInvalidEmailVerificationToken):
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+	case errors.Is(err, usecase.ErrWrongPassword):
+		writeJSONResponse(w, http.StatusForbidden, ErrorResponse{Message: err.Error()})
+	case errors.Is(err, usecase.ErrEmailAlreadyVerified), errors.Is(err, usecase.ErrEmailAlreadyExists):
+		writeJSONResponse(w, http.StatusConflict, ErrorResponse{Message: err.Error()})
+	case errors.Is(err, usecase.ErrUserNotFound):
+		writeJSONResponse(w, http.StatusNotFound, ErrorResponse{Message: err.Error()})
+	default:
+		writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: message, Details: err.Error()})
+	}
+}
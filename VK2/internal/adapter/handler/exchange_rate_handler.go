@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"vk/internal/domain"
+	"vk/internal/usecase"
+)
+
+// ExchangeRateHandler обрабатывает HTTP-запросы, связанные с курсами валют.
+type ExchangeRateHandler struct {
+	rateUseCase *usecase.ExchangeRateUseCase
+}
+
+func NewExchangeRateHandler(rateUseCase *usecase.ExchangeRateUseCase) *ExchangeRateHandler {
+	return &ExchangeRateHandler{rateUseCase: rateUseCase}
+}
+
+// SetRateRequest содержит новый курс: JSON-число или строка ("92.5").
+type SetRateRequest struct {
+	Rate domain.Decimal `json:"rate"`
+}
+
+type ExchangeRatesResponse struct {
+	BaseCurrency string                `json:"base_currency"`
+	Rates        []domain.ExchangeRate `json:"rates"`
+}
+
+// ListRates обрабатывает запрос на получение курсов валют.
+func (h *ExchangeRateHandler) ListRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.rateUseCase.ListRates()
+	if err != nil {
+		writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: "Не удалось получить курсы валют", Details: err.Error()})
+		return
+	}
+	if rates == nil {
+		rates = []domain.ExchangeRate{}
+	}
+
+	writeJSONResponse(w, http.StatusOK, ExchangeRatesResponse{BaseCurrency: domain.BaseCurrency, Rates: rates})
+}
+
+// SetRate обрабатывает запрос на изменение курса валюты.
+func (h *ExchangeRateHandler) SetRate(w http.ResponseWriter, r *http.Request) {
+	var req SetRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Неверная полезная нагрузка запроса", Details: err.Error()})
+		return
+	}
+
+	rate, err := h.rateUseCase.SetRate(r.PathValue("currency"), string(req.Rate))
+	if err != nil {
+		var validationErr *usecase.ValidationErr
+		if errors.As(err, &validationErr) {
+			writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Ошибка валидации", Details: err.Error()})
+			return
+		}
+		writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: "Не удалось изменить курс валюты", Details: err.Error()})
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, rate)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"vk/internal/infrastructure/storage"
+)
+
+// FileHandler отдает файлы локального хранилища по подписанным ссылкам.
+// Для S3 ссылки ведут напрямую в хранилище, и этот обработчик не нужен.
+func FileHandler(blobStorage *storage.LocalBlobStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.PathValue("key")
+		expires := r.URL.Query().Get("expires")
+		err := blobStorage.VerifySignedURL(key, expires, r.URL.Query().Get("signature"), time.Now())
+		if errors.Is(err, storage.ErrSignatureExpired) {
+			writeJSONResponse(w, http.StatusForbidden, ErrorResponse{Message: "Срок действия ссылки истек"})
+			return
+		}
+		if err != nil {
+			writeJSONResponse(w, http.StatusForbidden, ErrorResponse{Message: "Неверная ссылка на файл"})
+			return
+		}
+
+		data, err := blobStorage.Get(key)
+		if err != nil {
+			writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: "Не удалось прочитать файл", Details: err.Error()})
+			return
+		}
+		if data == nil {
+			writeJSONResponse(w, http.StatusNotFound, ErrorResponse{Message: "Файл не найден"})
+			return
+		}
+
+		// Файл по ключу не меняется, поэтому его можно кэшировать до истечения ссылки
+		expiresAt, _ := strconv.ParseInt(expires, 10, 64)
+		maxAge := max(expiresAt-time.Now().Unix(), 0)
+		w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(maxAge, 10))
+		w.Header().Set("Content-Type", http.DetectContentType(data))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+	}
+}
@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"net/http"
+
+	"vk/internal/infrastructure/util"
+)
+
+// JWKSHandler отдает открытые ключи проверки токенов в формате JWK Set (RFC 7517).
+func JWKSHandler(tokenManager *util.TokenManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Набор ключей меняется только при ротации, клиенты могут кэшировать его
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		writeJSONResponse(w, http.StatusOK, tokenManager.JWKS())
+	}
+}
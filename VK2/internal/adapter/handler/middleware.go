@@ -2,8 +2,10 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"vk/internal/infrastructure/util"
 	"vk/internal/usecase"
@@ -11,34 +13,144 @@ import (
 
 type ContextKey string
 
-const ContextKeyUserID ContextKey = "userID"
+const (
+	ContextKeyUserID      ContextKey = "userID"
+	ContextKeySessionID   ContextKey = "sessionID"
+	ContextKeyRole        ContextKey = "role"
+	ContextKeyPermissions ContextKey = "permissions"
+)
 
 // AuthMiddleware проверяет наличие и валидность авторизационного токена.
-func AuthMiddleware(tokenSecretKey string, authUseCase *usecase.AuthUseCase, next http.Handler) http.Handler {
+func AuthMiddleware(tokenManager *util.TokenManager, authUseCase *usecase.AuthUseCase, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: "Не авторизован: отсутствует заголовок Authorization"})
+		claims, status, errResp := authenticateRequest(r, tokenManager, authUseCase)
+		if errResp != nil {
+			writeJSONResponse(w, status, *errResp)
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: "Не авторизован: неверный формат заголовка Authorization"})
+		// Добавляем userID и ID сессии в контекст запроса
+		next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), claims)))
+	})
+}
+
+// OptionalAuthMiddleware добавляет userID в контекст, если передан токен.
+// Запрос без заголовка Authorization обрабатывается анонимно, а неверный или
+// истекший токен отклоняется так же, как в AuthMiddleware.
+func OptionalAuthMiddleware(tokenManager *util.TokenManager, authUseCase *usecase.AuthUseCase, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		tokenString := parts[1]
+		claims, status, errResp := authenticateRequest(r, tokenManager, authUseCase)
+		if errResp != nil {
+			writeJSONResponse(w, status, *errResp)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), claims)))
+	})
+}
+
+// RequirePermission пропускает запрос, только если у пользователя есть разрешение.
+// Должен применяться внутри AuthMiddleware, который кладет разрешения в контекст.
+func RequirePermission(permission string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !actorFromContext(r.Context()).Can(permission) {
+			writeJSONResponse(w, http.StatusForbidden, ErrorResponse{Message: "Доступ запрещен: недостаточно прав", Details: "требуется разрешение " + permission})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
 
-		// Парсим и валидируем кастомный токен
-		userID, err := util.ParseToken(tokenString, tokenSecretKey)
-		if err != nil {
-			writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: "Не авторизован: неверный или истекший токен", Details: err.Error()})
+// RequireVerifiedEmail пропускает запрос, только если почта пользователя подтверждена.
+// Должен применяться внутри AuthMiddleware.
+func RequireVerifiedEmail(emailUseCase *usecase.EmailUseCase, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(ContextKeyUserID).(string)
+		if err := emailUseCase.RequireVerifiedEmail(userID); err != nil {
+			if errors.Is(err, usecase.ErrEmailNotVerified) || errors.Is(err, usecase.ErrUserNotFound) {
+				writeJSONResponse(w, http.StatusForbidden, ErrorResponse{Message: "Доступ запрещен: почта не подтверждена", Details: "подтвердите почту по ссылке из письма"})
+				return
+			}
+			writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: "Не удалось проверить почту", Details: err.Error()})
 			return
 		}
+		next.ServeHTTP(w, r)
+	})
+}
 
-		// Добавляем userID в контекст запроса
-		ctx := context.WithValue(r.Context(), ContextKeyUserID, userID)
-		next.ServeHTTP(w, r.WithContext(ctx))
+// UploadDeadline продлевает сроки чтения запроса и записи ответа до timeout для
+// маршрутов загрузки файлов. Таймауты сервера рассчитаны на обычные запросы:
+// файл на медленном канале за это время не успевает дойти, а декодирование
+// большого изображения и запись в хранилище — завершиться.
+func UploadDeadline(timeout time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline := time.Now().Add(timeout)
+		controller := http.NewResponseController(w)
+		if err := controller.SetReadDeadline(deadline); err != nil {
+			writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: "Не удалось продлить срок чтения запроса", Details: err.Error()})
+			return
+		}
+		if err := controller.SetWriteDeadline(deadline); err != nil {
+			writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: "Не удалось продлить срок записи ответа", Details: err.Error()})
+			return
+		}
+		next.ServeHTTP(w, r)
 	})
 }
+
+// authenticateRequest извлекает и валидирует bearer-токен из заголовка Authorization
+// и проверяет, что сессия токена не отозвана. При ошибке возвращает HTTP-статус и описание.
+func authenticateRequest(r *http.Request, tokenManager *util.TokenManager, authUseCase *usecase.AuthUseCase) (*util.TokenClaims, int, *ErrorResponse) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return nil, http.StatusUnauthorized, &ErrorResponse{Message: "Не авторизован: отсутствует заголовок Authorization"}
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		return nil, http.StatusUnauthorized, &ErrorResponse{Message: "Не авторизован: неверный формат заголовка Authorization"}
+	}
+
+	tokenString := parts[1]
+
+	// Проверяем подпись и утверждения JWT
+	claims, err := tokenManager.ParseToken(tokenString)
+	if err != nil {
+		return nil, http.StatusUnauthorized, &ErrorResponse{Message: "Не авторизован: неверный или истекший токен", Details: err.Error()}
+	}
+
+	// Токен может быть отозван до истечения срока вместе с сессией
+	if err := authUseCase.ValidateSession(claims.UserID, claims.SessionID); err != nil {
+		if errors.Is(err, usecase.ErrSessionRevoked) {
+			return nil, http.StatusUnauthorized, &ErrorResponse{Message: "Не авторизован: сессия завершена", Details: err.Error()}
+		}
+		return nil, http.StatusInternalServerError, &ErrorResponse{Message: "Не удалось проверить сессию", Details: err.Error()}
+	}
+
+	return claims, http.StatusOK, nil
+}
+
+// withClaims добавляет данные токена в контекст запроса.
+func withClaims(ctx context.Context, claims *util.TokenClaims) context.Context {
+	ctx = context.WithValue(ctx, ContextKeyUserID, claims.UserID)
+	ctx = context.WithValue(ctx, ContextKeySessionID, claims.SessionID)
+	ctx = context.WithValue(ctx, ContextKeyRole, claims.Role)
+	return context.WithValue(ctx, ContextKeyPermissions, claims.Permissions)
+}
+
+// actorFromContext возвращает пользователя запроса и его разрешения.
+func actorFromContext(ctx context.Context) usecase.Actor {
+	userID, _ := ctx.Value(ContextKeyUserID).(string)
+	role, _ := ctx.Value(ContextKeyRole).(string)
+	permissions, _ := ctx.Value(ContextKeyPermissions).([]string)
+	return usecase.Actor{
+		UserID:      userID,
+		Role:        role,
+		Permissions: permissions,
+	}
+}
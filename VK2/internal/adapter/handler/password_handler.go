@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"vk/internal/usecase"
+)
+
+// PasswordHandler обрабатывает HTTP-запросы смены и восстановления пароля.
+type PasswordHandler struct {
+	passwordUseCase *usecase.PasswordUseCase
+}
+
+func NewPasswordHandler(passwordUseCase *usecase.PasswordUseCase) *PasswordHandler {
+	return &PasswordHandler{passwordUseCase: passwordUseCase}
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// ChangePassword обрабатывает запрос на смену пароля текущего пользователя.
+// Остальные сессии пользователя завершаются.
+func (h *PasswordHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(ContextKeyUserID).(string)
+	sessionID, _ := r.Context().Value(ContextKeySessionID).(string)
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload", Details: err.Error()})
+		return
+	}
+
+	if err := h.passwordUseCase.ChangePassword(userID, sessionID, req.CurrentPassword, req.NewPassword, clientInfo(r).IP); err != nil {
+		writePasswordError(w, err, "Failed to change password")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RequestPasswordReset обрабатывает запрос на отправку ссылки для сброса пароля.
+// Ответ не зависит от того, зарегистрирована ли почта: письмо отправляется в фоне.
+func (h *PasswordHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload", Details: err.Error()})
+		return
+	}
+
+	if err := h.passwordUseCase.RequestPasswordReset(req.Email, clientInfo(r).IP); err != nil {
+		writePasswordError(w, err, "Failed to request password reset")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPassword обрабатывает запрос на установку нового пароля по токену из письма.
+func (h *PasswordHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req PasswordResetConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload", Details: err.Error()})
+		return
+	}
+	if req.Token == "" {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Validation error", Details: "token is required"})
+		return
+	}
+
+	if err := h.passwordUseCase.ResetPassword(req.Token, req.NewPassword); err != nil {
+		writePasswordError(w, err, "Failed to reset password")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writePasswordError отправляет ответ с HTTP-статусом, соответствующим ошибке смены пароля.
+func writePasswordError(w http.ResponseWriter, err error, message string) {
+	var validationErr *usecase.ValidationErr
+	var rateLimitErr *usecase.RateLimitErr
+	switch {
+	case errors.As(err, &validationErr):
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Validation error", Details: err.Error()})
+	case errors.As(err, &rateLimitErr):
+		writeRateLimitResponse(w, rateLimitErr)
+	case errors.Is(err, usecase.ErrInvalidPasswordResetToken):
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+	case errors.Is(err, usecase.ErrWrongPassword):
+		writeJSONResponse(w, http.StatusForbidden, ErrorResponse{Message: err.Error()})
+	case errors.Is(err, usecase.ErrUserNotFound):
+		writeJSONResponse(w, http.StatusNotFound, ErrorResponse{Message: err.Error()})
+	default:
+		writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: message, Details: err.Error()})
+	}
+}
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"vk/internal/domain"
+	"vk/internal/usecase"
+)
+
+// ProfileHandler обрабатывает HTTP-запросы профилей пользователей.
+type ProfileHandler struct {
+	profileUseCase *usecase.ProfileUseCase
+}
+
+func NewProfileHandler(profileUseCase *usecase.ProfileUseCase) *ProfileHandler {
+	return &ProfileHandler{profileUseCase: profileUseCase}
+}
+
+// ProfileResponse — профиль текущего пользователя со всеми полями и настройками видимости.
+type ProfileResponse struct {
+	ID            string    `json:"id"`
+	Login         string    `json:"login"`
+	Email         string    `json:"email,omitempty"`
+	EmailVerified bool      `json:"email_verified"`
+	Role          string    `json:"role"`
+	CreatedAt     time.Time `json:"created_at"`
+	DisplayName   string    `json:"display_name"`
+	AvatarURL     string    `json:"avatar_url,omitempty"`
+	City          string    `json:"city"`
+	Phone         string    `json:"phone"`
+	Bio           string    `json:"bio"`
+	CityPublic    bool      `json:"city_public"`  // Показывать город в открытом профиле
+	PhonePublic   bool      `json:"phone_public"` // Показывать телефон в открытом профиле
+}
+
+// PublicProfileResponse — открытый профиль продавца. Скрытые пользователем поля отсутствуют.
+type PublicProfileResponse struct {
+	ID          string    `json:"id"`
+	Login       string    `json:"login"`
+	DisplayName string    `json:"display_name,omitempty"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+	Bio         string    `json:"bio,omitempty"`
+	City        string    `json:"city,omitempty"`
+	Phone       string    `json:"phone,omitempty"`
+	MemberSince time.Time `json:"member_since"`
+	AdCount     int       `json:"ad_count"` // Опубликованные объявления
+	Rating      *float64  `json:"rating"`   // null, пока у продавца нет отзывов
+}
+
+// UpdateProfileRequest содержит поля для частичного обновления профиля.
+// Отсутствующие в запросе поля не изменяются, пустая строка очищает поле.
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name"`
+	City        *string `json:"city"`
+	Phone       *string `json:"phone"`
+	Bio         *string `json:"bio"`
+	CityPublic  *bool   `json:"city_public"`
+	PhonePublic *bool   `json:"phone_public"`
+}
+
+// GetMyProfile обрабатывает запрос на получение профиля текущего пользователя.
+func (h *ProfileHandler) GetMyProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(ContextKeyUserID).(string)
+	if !ok || userID == "" {
+		writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: "Не авторизован: ID пользователя не найден в контексте"})
+		return
+	}
+
+	user, profile, err := h.profileUseCase.GetProfile(userID)
+	if err != nil {
+		writeProfileError(w, err, "Не удалось получить профиль")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, newProfileResponse(user, profile))
+}
+
+// UpdateMyProfile обрабатывает запрос на изменение профиля текущего пользователя.
+func (h *ProfileHandler) UpdateMyProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(ContextKeyUserID).(string)
+	if !ok || userID == "" {
+		writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: "Не авторизован: ID пользователя не найден в контексте"})
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Неверная полезная нагрузка запроса", Details: err.Error()})
+		return
+	}
+
+	user, profile, err := h.profileUseCase.UpdateProfile(userID, usecase.UpdateProfileParameters{
+		DisplayName: req.DisplayName,
+		City:        req.City,
+		Phone:       req.Phone,
+		Bio:         req.Bio,
+		CityPublic:  req.CityPublic,
+		PhonePublic: req.PhonePublic,
+	})
+	if err != nil {
+		writeProfileError(w, err, "Не удалось обновить профиль")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, newProfileResponse(user, profile))
+}
+
+// avatarFormField — поле multipart-формы с файлом аватара.
+const avatarFormField = "avatar"
+
+// UploadAvatar обрабатывает загрузку аватара (multipart/form-data, поле avatar).
+func (h *ProfileHandler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(ContextKeyUserID).(string)
+	if !ok || userID == "" {
+		writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: "Не авторизован: ID пользователя не найден в контексте"})
+		return
+	}
+
+	// Запас сверх размера файла — на заголовки и границы multipart
+	r.Body = http.MaxBytesReader(w, r.Body, usecase.MaxImageSize+64<<10)
+	data, err := readFormFile(r, avatarFormField, usecase.MaxImageSize)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeProfileError(w, usecase.ErrImageTooLarge, "")
+			return
+		}
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Неверная полезная нагрузка запроса", Details: err.Error()})
+		return
+	}
+
+	user, profile, err := h.profileUseCase.UploadAvatar(userID, data)
+	if err != nil {
+		writeProfileError(w, err, "Не удалось загрузить аватар")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, newProfileResponse(user, profile))
+}
+
+// DeleteAvatar обрабатывает запрос на удаление аватара.
+func (h *ProfileHandler) DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(ContextKeyUserID).(string)
+	if !ok || userID == "" {
+		writeJSONResponse(w, http.StatusUnauthorized, ErrorResponse{Message: "Не авторизован: ID пользователя не найден в контексте"})
+		return
+	}
+
+	user, profile, err := h.profileUseCase.DeleteAvatar(userID)
+	if err != nil {
+		writeProfileError(w, err, "Не удалось удалить аватар")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, newProfileResponse(user, profile))
+}
+
+// GetPublicProfile обрабатывает запрос на получение открытого профиля продавца.
+func (h *ProfileHandler) GetPublicProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.profileUseCase.GetPublicProfile(r.PathValue("id"))
+	if err != nil {
+		writeProfileError(w, err, "Не удалось получить профиль")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, PublicProfileResponse{
+		ID:          profile.UserID,
+		Login:       profile.Login,
+		DisplayName: profile.DisplayName,
+		AvatarURL:   profile.AvatarURL,
+		Bio:         profile.Bio,
+		City:        profile.City,
+		Phone:       profile.Phone,
+		MemberSince: profile.MemberSince,
+		AdCount:     profile.AdCount,
+		Rating:      profile.Rating,
+	})
+}
+
+// newProfileResponse преобразует пользователя и его профиль в ответ API.
+func newProfileResponse(user *domain.User, profile *domain.UserProfile) ProfileResponse {
+	return ProfileResponse{
+		ID:            user.ID,
+		Login:         user.Login,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified(),
+		Role:          user.Role,
+		CreatedAt:     user.CreatedAt,
+		DisplayName:   profile.DisplayName,
+		AvatarURL:     profile.AvatarURL,
+		City:          profile.City,
+		Phone:         profile.Phone,
+		Bio:           profile.Bio,
+		CityPublic:    profile.CityPublic,
+		PhonePublic:   profile.PhonePublic,
+	}
+}
+
+// writeProfileError отправляет ответ с HTTP-статусом, соответствующим ошибке профиля.
+func writeProfileError(w http.ResponseWriter, err error, message string) {
+	var validationErr *usecase.ValidationErr
+	switch {
+	case errors.As(err, &validationErr):
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Ошибка валидации", Details: err.Error()})
+	case errors.Is(err, usecase.ErrUserNotFound):
+		writeJSONResponse(w, http.StatusNotFound, ErrorResponse{Message: err.Error()})
+	case errors.Is(err, usecase.ErrImageTooLarge):
+		writeJSONResponse(w, http.StatusRequestEntityTooLarge, ErrorResponse{Message: "Слишком большой файл", Details: err.Error()})
+	case errors.Is(err, usecase.ErrUnsupportedImage):
+		writeJSONResponse(w, http.StatusUnsupportedMediaType, ErrorResponse{Message: "Неподдерживаемый формат изображения", Details: err.Error()})
+	default:
+		writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: message, Details: err.Error()})
+	}
+}
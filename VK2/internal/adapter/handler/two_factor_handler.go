@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"vk/internal/usecase"
+)
+
+// TwoFactorHandler обрабатывает HTTP-запросы подключения и отключения второго фактора.
+type TwoFactorHandler struct {
+	twoFactorUseCase *usecase.TwoFactorUseCase
+}
+
+func NewTwoFactorHandler(twoFactorUseCase *usecase.TwoFactorUseCase) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorUseCase: twoFactorUseCase}
+}
+
+// TwoFactorEnrollResponse содержит секрет для приложения-аутентификатора.
+type TwoFactorEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth://, обычно показывается QR-кодом
+}
+
+type TwoFactorEnrollRequest struct {
+	Password string `json:"password"`
+}
+
+type TwoFactorConfirmRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// TwoFactorConfirmResponse содержит коды восстановления. Они показываются один раз.
+type TwoFactorConfirmResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"` // Код из приложения или код восстановления
+}
+
+// Enroll обрабатывает запрос на начало подключения второго фактора.
+func (h *TwoFactorHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(ContextKeyUserID).(string)
+
+	var req TwoFactorEnrollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload", Details: err.Error()})
+		return
+	}
+
+	enrollment, err := h.twoFactorUseCase.Enroll(userID, req.Password, clientInfo(r).IP)
+	if err != nil {
+		writeTwoFactorError(w, err, "Failed to start two-factor enrollment")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, TwoFactorEnrollResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	})
+}
+
+// Confirm обрабатывает запрос на подтверждение подключения кодом из приложения.
+func (h *TwoFactorHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(ContextKeyUserID).(string)
+	sessionID, _ := r.Context().Value(ContextKeySessionID).(string)
+
+	var req TwoFactorConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload", Details: err.Error()})
+		return
+	}
+
+	codes, err := h.twoFactorUseCase.Confirm(userID, sessionID, req.Password, req.Code, clientInfo(r).IP)
+	if err != nil {
+		writeTwoFactorError(w, err, "Failed to confirm two-factor enrollment")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, TwoFactorConfirmResponse{RecoveryCodes: codes})
+}
+
+// Disable обрабатывает запрос на отключение второго фактора.
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(ContextKeyUserID).(string)
+
+	var req TwoFactorDisableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: "Invalid request payload", Details: err.Error()})
+		return
+	}
+
+	if err := h.twoFactorUseCase.Disable(userID, req.Password, req.Code, clientInfo(r).IP); err != nil {
+		writeTwoFactorError(w, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeTwoFactorError отправляет ответ с HTTP-статусом, соответствующим ошибке второго фактора.
+func writeTwoFactorError(w http.ResponseWriter, err error, message string) {
+	var rateLimitErr *usecase.RateLimitErr
+	switch {
+	case errors.As(err, &rateLimitErr):
+		writeRateLimitResponse(w, rateLimitErr)
+	case errors.Is(err, usecase.ErrInvalidTwoFactorCode):
+		writeJSONResponse(w, http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+	case errors.Is(err, usecase.ErrWrongPassword):
+		writeJSONResponse(w, http.StatusForbidden, ErrorResponse{Message: err.Error()})
+	case errors.Is(err, usecase.ErrTwoFactorAlreadyEnabled), errors.Is(err, usecase.ErrTwoFactorNotEnabled), errors.Is(err, usecase.ErrTwoFactorNotEnrolled):
+		writeJSONResponse(w, http.StatusConflict, ErrorResponse{Message: err.Error()})
+	case errors.Is(err, usecase.ErrUserNotFound):
+		writeJSONResponse(w, http.StatusNotFound, ErrorResponse{Message: err.Error()})
+	default:
+		writeJSONResponse(w, http.StatusInternalServerError, ErrorResponse{Message: message, Details: err.Error()})
+	}
+}
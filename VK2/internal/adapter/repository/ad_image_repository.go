@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"time"
+
+	"vk/internal/domain"
+)
+
+// AdImageRepository определяет интерфейс для взаимодействия с хранилищем изображений объявлений.
+type AdImageRepository interface {
+	// AddImage добавляет изображение в конец списка объявления и заполняет его
+	// Position и IsCover: первое изображение объявления становится обложкой.
+	// Возвращает false, если у объявления уже maxImages изображений.
+	AddImage(image *domain.AdImage, maxImages int) (bool, error)
+	// ListImages возвращает изображения объявления в порядке Position.
+	ListImages(adID string) ([]domain.AdImage, error)
+	// ListCoverImages возвращает обложки перечисленных объявлений.
+	ListCoverImages(adIDs []string) ([]domain.AdImage, error)
+	// DeleteImage удаляет изображение. Если оно было обложкой, обложкой
+	// становится первое из оставшихся.
+	DeleteImage(adID, imageID string) error
+	// ReorderImages задает порядок изображений объявления и обложку.
+	// imageIDs должен содержать все изображения объявления.
+	ReorderImages(adID string, imageIDs []string, coverID string) error
+
+	// ListImageVariants возвращает готовые варианты перечисленных изображений.
+	ListImageVariants(imageIDs []string) ([]domain.AdImageVariant, error)
+	// ClaimNextImage забирает в обработку самое старое изображение, ожидающее
+	// обработки или зависшее в ней дольше staleBefore, и увеличивает счетчик попыток.
+	// Возвращает nil, если очередь пуста.
+	ClaimNextImage(now, staleBefore time.Time, maxAttempts int) (*domain.AdImage, error)
+	// FailStaleImages отмечает необработанными изображения, зависшие в обработке
+	// дольше staleBefore на последней попытке (например, сервис упал во время нее).
+	// Возвращает число таких изображений.
+	FailStaleImages(staleBefore time.Time, maxAttempts int) (int, error)
+	// SaveImageVariants сохраняет варианты изображения и отмечает его обработанным.
+	SaveImageVariants(imageID string, variants []domain.AdImageVariant) error
+	// SetImageStatus меняет статус обработки изображения.
+	SetImageStatus(imageID, status string) error
+}
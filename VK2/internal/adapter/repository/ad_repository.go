@@ -1,6 +1,45 @@
 package repository
 
-import "vk/internal/domain"
+import (
+	"time"
+
+	"vk/internal/domain"
+)
+
+// AdFilter описывает условия отбора объявлений. Нулевые значения полей не ограничивают выборку.
+type AdFilter struct {
+	MinPrice      domain.Amount
+	MaxPrice      domain.Amount
+	PriceCurrency string   // Валюта MinPrice и MaxPrice; цены объявлений пересчитываются по курсам
+	CategoryID    string   // Включая все подкатегории
+	Query         string   // Полнотекстовый поиск по заголовку и описанию
+	Statuses      []string // Допустимые статусы объявлений
+	UserID        string   // Автор объявлений
+}
+
+// Поля сортировки объявлений.
+const (
+	AdSortPublishedAt = "published_at" // По моменту публикации, до нее — по моменту создания
+	AdSortCreatedAt   = "created_at"
+	AdSortPrice       = "price"     // По цене, пересчитанной в базовую валюту
+	AdSortRelevance   = "relevance" // Только вместе с AdFilter.Query
+)
+
+// AdCursor указывает на последнее объявление страницы при keyset-пагинации:
+// значение поля сортировки и ID как устойчивый дополнительный ключ.
+type AdCursor struct {
+	SortValue string
+	ID        string
+}
+
+// AdPageRequest описывает сортировку и границы страницы объявлений.
+type AdPageRequest struct {
+	SortBy   string // Одно из AdSort*
+	SortDesc bool
+	Limit    int
+	Offset   int       // Используется, только если After не задан
+	After    *AdCursor // Keyset-пагинация: объявления строго после курсора
+}
 
 // AdRepository определяет интерфейс для взаимодействия с хранилищем объявлений.
 type AdRepository interface {
@@ -8,8 +47,31 @@ type AdRepository interface {
 	CreateAd(ad *domain.Ad) error
 	// GetAdByID находит объявление по ID.
 	GetAdByID(id string) (*domain.Ad, error)
-	// ListAds возвращает список объявлений с учетом пагинации, сортировки и фильтрации.
-	ListAds(offset, limit int, sortBy, sortOrder string, minPrice, maxPrice float64) ([]domain.Ad, error)
+	// UpdateAd сохраняет изменения существующего объявления.
+	UpdateAd(ad *domain.Ad) error
+	// UpdateAdStatus переводит объявление из статуса from в статус to и задает срок
+	// публикации; при изменении срока напоминание о нем отправляется заново, время
+	// отложенной публикации сбрасывается. Если publishedAt не nil, объявление
+	// считается опубликованным заново в этот момент. Возвращает false, если статус
+	// объявления уже не равен from (например, изменен параллельным запросом).
+	UpdateAdStatus(id, from, to string, expiresAt time.Time, publishedAt *time.Time) (bool, error)
+	// PublishScheduledAds публикует не больше limit запланированных объявлений,
+	// время публикации которых наступило к now, с моментом публикации now.
+	// Возвращает их число.
+	PublishScheduledAds(now time.Time, limit int) (int, error)
+	// ArchiveExpiredAds переносит в архив не больше limit опубликованных
+	// и зарезервированных объявлений, срок которых истек к now. Возвращает их число.
+	ArchiveExpiredAds(now time.Time, limit int) (int, error)
+	// ClaimExpiringAds отмечает отправку напоминания для не больше limit активных
+	// объявлений, срок которых истекает до before, и возвращает их. Напоминание
+	// по каждому объявлению выдается один раз за срок публикации.
+	ClaimExpiringAds(before, now time.Time, limit int) ([]domain.Ad, error)
+	// DeleteAd удаляет объявление по ID.
+	DeleteAd(id string) error
+	// ListAds возвращает список объявлений с учетом пагинации, сортировки и фильтрации,
+	// а также курсор следующей страницы (nil, если страница последняя).
+	// При поиске по Query объявления содержат фрагменты с подсветкой совпадений.
+	ListAds(filter AdFilter, page AdPageRequest) ([]domain.Ad, *AdCursor, error)
 	// CountAds возвращает общее количество объявлений с учетом фильтрации.
-	CountAds(minPrice, maxPrice float64) (int, error)
+	CountAds(filter AdFilter) (int, error)
 }
@@ -0,0 +1,16 @@
+package repository
+
+import "time"
+
+// BlobStorage определяет интерфейс хранилища файлов (изображений объявлений).
+// Ключ — относительный путь вида "ads/<id>/<имя>", разделитель — "/".
+type BlobStorage interface {
+	// Put сохраняет файл под ключом key, перезаписывая существующий.
+	Put(key string, data []byte, contentType string) error
+	// Get возвращает содержимое файла или nil, если файла нет.
+	Get(key string) ([]byte, error)
+	// Delete удаляет файл. Отсутствие файла ошибкой не считается.
+	Delete(key string) error
+	// SignedURL возвращает ссылку на файл, действующую в течение ttl.
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
@@ -0,0 +1,11 @@
+package repository
+
+// BreachedPasswordRepository определяет интерфейс для базы паролей из утечек в
+// формате Have I Been Pwned: SHA-1 хеши паролей сгруппированы по первым 5
+// hex-символам. Хранилище получает только префикс хеша (k-анонимность), поэтому
+// его можно заменить внешним сервисом, не раскрывая ему пароли.
+type BreachedPasswordRepository interface {
+	// GetBreachedHashSuffixes возвращает для префикса хеша (5 hex-символов в верхнем
+	// регистре) оставшиеся 35 символов хешей с числом утечек, в которых встречался пароль.
+	GetBreachedHashSuffixes(prefix string) (map[string]int, error)
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"errors"
+
+	"vk/internal/domain"
+)
+
+// ErrCategoryCycle и ErrCategoryNameTaken возвращаются при сохранении категории,
+// если перенос создал бы цикл в дереве или название уже занято соседней категорией.
+// ErrCategoryInUse возвращается при удалении категории, на которую ссылаются
+// подкатегории или объявления.
+var (
+	ErrCategoryCycle     = errors.New("category cannot be moved under itself or its subcategory")
+	ErrCategoryNameTaken = errors.New("category name is already taken at this level")
+	ErrCategoryInUse     = errors.New("category has subcategories or ads")
+)
+
+// CategoryRepository определяет интерфейс для взаимодействия с хранилищем категорий.
+type CategoryRepository interface {
+	// CreateCategory сохраняет новую категорию. Возвращает ErrCategoryNameTaken,
+	// если название уже занято.
+	CreateCategory(category *domain.Category) error
+	// GetCategoryByID находит категорию по ID.
+	GetCategoryByID(id string) (*domain.Category, error)
+	// ListCategories возвращает все категории.
+	ListCategories() ([]domain.Category, error)
+	// UpdateCategory сохраняет изменения категории. Проверка на цикл и сохранение
+	// выполняются атомарно: возвращает ErrCategoryCycle, если новый родитель — сама
+	// категория или ее подкатегория, и ErrCategoryNameTaken, если название занято.
+	UpdateCategory(category *domain.Category) error
+	// DeleteCategory удаляет категорию. Возвращает ErrCategoryInUse, если на нее
+	// ссылаются подкатегории или объявления.
+	DeleteCategory(id string) error
+	// IsCategoryInUse проверяет, есть ли у категории подкатегории или объявления.
+	IsCategoryInUse(id string) (bool, error)
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"time"
+
+	"vk/internal/domain"
+)
+
+// EmailOutboxRepository определяет интерфейс для взаимодействия с очередью исходящих писем.
+type EmailOutboxRepository interface {
+	// EnqueueEmail ставит письмо в очередь на отправку.
+	EnqueueEmail(email *domain.OutboxEmail) error
+	// ClaimNextEmail забирает письмо, время отправки которого наступило к now:
+	// увеличивает число попыток и откладывает следующую попытку до retryAt, чтобы
+	// письмо не отправили параллельно. Возвращает nil, если таких писем нет.
+	ClaimNextEmail(now, retryAt time.Time) (*domain.OutboxEmail, error)
+	// RescheduleEmail откладывает следующую попытку отправки письма до nextAttemptAt.
+	RescheduleEmail(id string, nextAttemptAt time.Time) error
+	// DeleteEmail удаляет письмо из очереди.
+	DeleteEmail(id string) error
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"time"
+
+	"vk/internal/domain"
+)
+
+// EmailVerificationRepository определяет интерфейс для взаимодействия с хранилищем токенов подтверждения почты.
+type EmailVerificationRepository interface {
+	// CreateEmailVerificationToken сохраняет новый токен подтверждения почты.
+	CreateEmailVerificationToken(token *domain.EmailVerificationToken) error
+	// GetEmailVerificationTokenByHash находит токен по хешу его значения.
+	GetEmailVerificationTokenByHash(tokenHash string) (*domain.EmailVerificationToken, error)
+	// MarkEmailVerificationTokenUsed помечает токен использованным. Возвращает false,
+	// если токен уже был использован ранее.
+	MarkEmailVerificationTokenUsed(id string, usedAt time.Time) (bool, error)
+	// LastEmailVerificationSentAt возвращает время выдачи последнего токена
+	// пользователя или nil, если токенов не было.
+	LastEmailVerificationSentAt(userID string) (*time.Time, error)
+}
@@ -0,0 +1,13 @@
+package repository
+
+import "vk/internal/domain"
+
+// ExchangeRateRepository определяет интерфейс для взаимодействия с хранилищем курсов валют.
+type ExchangeRateRepository interface {
+	// ListRates возвращает все известные курсы, отсортированные по коду валюты.
+	ListRates() ([]domain.ExchangeRate, error)
+	// GetRate возвращает курс валюты или nil, если курс не задан.
+	GetRate(currency string) (*domain.ExchangeRate, error)
+	// SetRate создает или обновляет курс валюты.
+	SetRate(rate *domain.ExchangeRate) error
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"time"
+
+	"vk/internal/domain"
+)
+
+// LoginThrottleRepository определяет интерфейс для взаимодействия с хранилищем
+// счетчиков неудачных попыток входа.
+type LoginThrottleRepository interface {
+	// ClaimLoginAttempt заранее учитывает попытку входа как неудачную. Возвращает
+	// false и текущее состояние счетчика, если вход по ключу заблокирован.
+	ClaimLoginAttempt(key string, policy domain.LoginThrottlePolicy, now time.Time) (*domain.LoginThrottle, bool, error)
+	// ReleaseLoginAttempt отменяет учет попытки, которая оказалась успешной, и
+	// снимает блокировку по ключу.
+	ReleaseLoginAttempt(key string) error
+	// ResetLoginThrottle сбрасывает счетчик и блокировку по ключу.
+	ResetLoginThrottle(key string) error
+	// DeleteStaleLoginThrottles удаляет не больше limit счетчиков без неудачных
+	// попыток и блокировок после before. Возвращает количество удаленных.
+	DeleteStaleLoginThrottles(before time.Time, limit int) (int, error)
+}
@@ -0,0 +1,9 @@
+package repository
+
+import "vk/internal/domain"
+
+// Mailer определяет интерфейс отправки писем пользователям.
+type Mailer interface {
+	// Send отправляет письмо.
+	Send(msg domain.EmailMessage) error
+}
@@ -0,0 +1,9 @@
+package repository
+
+import "vk/internal/domain"
+
+// Notifier определяет интерфейс отправки уведомлений пользователям.
+type Notifier interface {
+	// NotifyAdExpiring сообщает владельцу объявления, что срок публикации скоро истечет.
+	NotifyAdExpiring(ad *domain.Ad) error
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"time"
+
+	"vk/internal/domain"
+)
+
+// PasswordResetRepository определяет интерфейс для взаимодействия с хранилищем токенов сброса пароля.
+type PasswordResetRepository interface {
+	// CreatePasswordResetToken сохраняет новый токен сброса пароля.
+	CreatePasswordResetToken(token *domain.PasswordResetToken) error
+	// GetPasswordResetTokenByHash находит токен по хешу его значения.
+	GetPasswordResetTokenByHash(tokenHash string) (*domain.PasswordResetToken, error)
+	// ResetPasswordWithToken в одной транзакции помечает использованным токен
+	// tokenID, сохраняет новый хеш пароля пользователя и аннулирует остальные его
+	// токены. Возвращает false и ничего не меняет, если токен уже был использован.
+	ResetPasswordWithToken(tokenID, userID, passwordHash string, usedAt time.Time) (bool, error)
+	// UpdatePasswordAndInvalidateTokens сохраняет новый хеш пароля пользователя и
+	// в той же транзакции помечает использованными все его неиспользованные токены.
+	UpdatePasswordAndInvalidateTokens(userID, passwordHash string, usedAt time.Time) error
+	// EnqueuePasswordResetRequest ставит запрос на сброс пароля в очередь.
+	EnqueuePasswordResetRequest(request *domain.PasswordResetRequest) error
+	// TakePasswordResetRequest извлекает из очереди самый старый запрос.
+	// Возвращает nil, если очередь пуста.
+	TakePasswordResetRequest() (*domain.PasswordResetRequest, error)
+}
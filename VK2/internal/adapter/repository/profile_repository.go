@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"time"
+
+	"vk/internal/domain"
+)
+
+// ProfileUpdate содержит изменяемые поля профиля. Nil-поля не изменяются.
+type ProfileUpdate struct {
+	DisplayName *string
+	City        *string
+	Phone       *string
+	Bio         *string
+	CityPublic  *bool
+	PhonePublic *bool
+}
+
+// ProfileRepository определяет интерфейс для взаимодействия с хранилищем профилей пользователей.
+// Профиль изменяется по отдельным полям, а не перезаписывается целиком, чтобы
+// параллельные запросы (например, правка профиля во время загрузки аватара) не
+// затирали изменения друг друга.
+type ProfileRepository interface {
+	// GetProfile возвращает профиль пользователя или nil, если профиль еще не заполнялся.
+	GetProfile(userID string) (*domain.UserProfile, error)
+	// UpdateProfile изменяет заданные поля профиля, при необходимости создавая его;
+	// остальные поля, включая аватар, не меняются. Возвращает профиль после изменения.
+	UpdateProfile(userID string, update ProfileUpdate, updatedAt time.Time) (*domain.UserProfile, error)
+	// ReplaceAvatarKey заменяет ключ аватара (пустой ключ удаляет аватар), при
+	// необходимости создавая профиль. Возвращает профиль после изменения и прежний
+	// ключ. Параллельные замены выполняются по очереди, поэтому каждый прежний
+	// ключ возвращается ровно один раз.
+	ReplaceAvatarKey(userID, avatarKey string, updatedAt time.Time) (*domain.UserProfile, string, error)
+}
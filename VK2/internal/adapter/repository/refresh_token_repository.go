@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"time"
+
+	"vk/internal/domain"
+)
+
+// RefreshTokenRepository определяет интерфейс для взаимодействия с хранилищем refresh-токенов.
+type RefreshTokenRepository interface {
+	// CreateRefreshToken сохраняет новый refresh-токен.
+	CreateRefreshToken(token *domain.RefreshToken) error
+	// GetRefreshTokenByHash находит refresh-токен по хешу его значения.
+	GetRefreshTokenByHash(tokenHash string) (*domain.RefreshToken, error)
+	// MarkRefreshTokenRotated помечает токен использованным. Возвращает false,
+	// если токен уже был использован или отозван ранее.
+	MarkRefreshTokenRotated(id string, rotatedAt time.Time) (bool, error)
+}
@@ -0,0 +1,9 @@
+package repository
+
+// RoleRepository определяет интерфейс для взаимодействия с хранилищем ролей и разрешений.
+type RoleRepository interface {
+	// RoleExists проверяет, существует ли роль.
+	RoleExists(role string) (bool, error)
+	// GetRolePermissions возвращает разрешения роли.
+	GetRolePermissions(role string) ([]string, error)
+}
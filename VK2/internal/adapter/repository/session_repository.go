@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"time"
+
+	"vk/internal/domain"
+)
+
+// SessionRepository определяет интерфейс для взаимодействия с хранилищем сессий.
+type SessionRepository interface {
+	// CreateSession сохраняет новую сессию.
+	CreateSession(session *domain.Session) error
+	// GetSessionByID находит сессию по ID.
+	GetSessionByID(id string) (*domain.Session, error)
+	// ListActiveSessions возвращает неотозванные и неистекшие сессии пользователя.
+	ListActiveSessions(userID string, now time.Time) ([]domain.Session, error)
+	// TouchSession обновляет время последней активности сессии.
+	TouchSession(id string, lastSeenAt time.Time) error
+	// ExtendSession продлевает срок действия сессии.
+	ExtendSession(id string, expiresAt time.Time) error
+	// RevokeSession отзывает сессию и ее refresh-токены.
+	RevokeSession(id string, revokedAt time.Time) error
+	// RevokeUserSessions отзывает все сессии пользователя и их refresh-токены.
+	RevokeUserSessions(userID string, revokedAt time.Time) error
+	// RevokeOtherUserSessions отзывает все сессии пользователя, кроме keepSessionID,
+	// и их refresh-токены.
+	RevokeOtherUserSessions(userID, keepSessionID string, revokedAt time.Time) error
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"time"
+
+	"vk/internal/domain"
+)
+
+// TwoFactorRepository определяет интерфейс для взаимодействия с хранилищем
+// секретов TOTP и кодов восстановления.
+type TwoFactorRepository interface {
+	// GetTOTPSecret находит секрет TOTP пользователя.
+	GetTOTPSecret(userID string) (*domain.TOTPSecret, error)
+	// SavePendingTOTPSecret сохраняет новый неподтвержденный секрет. Возвращает
+	// false, если у пользователя уже подключен второй фактор.
+	SavePendingTOTPSecret(secret *domain.TOTPSecret) (bool, error)
+	// EnableTOTP подтверждает подключение второго фактора и заменяет коды
+	// восстановления пользователя. Возвращает false, если подключение уже подтверждено.
+	EnableTOTP(userID string, step int64, enabledAt time.Time, recoveryCodeHashes []string) (bool, error)
+	// UseTOTPStep запоминает интервал принятого кода. Возвращает false, если код
+	// этого или более позднего интервала уже был принят.
+	UseTOTPStep(userID string, step int64) (bool, error)
+	// UseRecoveryCode помечает код восстановления использованным. Возвращает false,
+	// если такого неиспользованного кода нет.
+	UseRecoveryCode(userID, codeHash string, usedAt time.Time) (bool, error)
+	// DeleteTOTP отключает второй фактор и удаляет коды восстановления.
+	DeleteTOTP(userID string) error
+}
+
+// LoginChallengeRepository определяет интерфейс для взаимодействия с хранилищем незавершенных входов.
+type LoginChallengeRepository interface {
+	// CreateLoginChallenge сохраняет новый незавершенный вход.
+	CreateLoginChallenge(challenge *domain.LoginChallenge) error
+	// GetLoginChallengeByHash находит незавершенный вход по хешу токена.
+	GetLoginChallengeByHash(tokenHash string) (*domain.LoginChallenge, error)
+	// ClaimLoginChallengeAttempt учитывает попытку ввода кода. Возвращает false,
+	// если вход уже завершен или попытки исчерпаны.
+	ClaimLoginChallengeAttempt(id string, maxAttempts int) (bool, error)
+	// MarkLoginChallengeUsed отмечает вход завершенным. Возвращает false, если
+	// он уже был завершен.
+	MarkLoginChallengeUsed(id string, usedAt time.Time) (bool, error)
+}
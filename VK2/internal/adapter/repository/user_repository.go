@@ -1,13 +1,41 @@
 package repository
 
-import "vk/internal/domain"
+import (
+	"errors"
+	"time"
+
+	"vk/internal/domain"
+)
+
+// ErrLoginTaken и ErrEmailTaken возвращаются при сохранении пользователя, если
+// логин или почта уже заняты другим пользователем.
+var (
+	ErrLoginTaken = errors.New("login is already taken")
+	ErrEmailTaken = errors.New("email is already taken")
+)
 
 // UserRepository определяет интерфейс для взаимодействия с хранилищем пользователей.
 type UserRepository interface {
-	// CreateUser сохраняет нового пользователя в хранилище.
+	// CreateUser сохраняет нового пользователя в хранилище. Возвращает ErrLoginTaken
+	// или ErrEmailTaken, если логин или почта уже заняты.
 	CreateUser(user *domain.User) error
 	// GetUserByLogin находит пользователя по логину.
 	GetUserByLogin(login string) (*domain.User, error)
+	// GetUserByEmail находит пользователя по почте без учета регистра.
+	GetUserByEmail(email string) (*domain.User, error)
 	// GetUserByID находит пользователя по ID.
 	GetUserByID(id string) (*domain.User, error)
+	// UpdateUserRole изменяет роль пользователя.
+	UpdateUserRole(id, role string) error
+	// UpdatePassword заменяет хеш пароля пользователя.
+	UpdatePassword(id, passwordHash string) error
+	// ReplacePasswordHash заменяет хеш пароля, пересчитанный с новыми параметрами.
+	// Возвращает false, если хеш успели изменить.
+	ReplacePasswordHash(id, oldHash, newHash string) (bool, error)
+	// UpdateEmail меняет почту пользователя и снимает отметку о ее подтверждении.
+	// Возвращает ErrEmailTaken, если почта уже занята.
+	UpdateEmail(id, email string) error
+	// MarkEmailVerified отмечает почту подтвержденной. Возвращает false, если
+	// у пользователя уже другая почта.
+	MarkEmailVerified(id, email string, verifiedAt time.Time) (bool, error)
 }
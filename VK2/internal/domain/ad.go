@@ -5,21 +5,47 @@ import "time"
 type Ad struct {
 	ID          string    `json:"id"`
 	UserID      string    `json:"user_id"`
+	CategoryID  string    `json:"category_id"`
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
-	ImageURL    string    `json:"image_url"`
-	Price       float64   `json:"price"`
+	Price       Money     `json:"price"`
+	Status      string    `json:"status"` // Одно из AdStatus*
 	CreatedAt   time.Time `json:"created_at"`
+	// ExpiresAt — момент, после которого опубликованное объявление переносится в архив.
+	ExpiresAt time.Time `json:"expires_at"`
+	// PublishAt — момент отложенной публикации; задан только в статусе AdStatusScheduled.
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	// PublishedAt — момент последней публикации: из черновика, по расписанию или
+	// из архива. Nil, если объявление еще не публиковалось.
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+
+	// ImageURL — подписанная ссылка на обложку, выдается вместе с объявлением.
+	ImageURL string `json:"image_url,omitempty"`
+	// Images — все изображения объявления по порядку; загружаются только для одного объявления.
+	Images []AdImage `json:"images,omitempty"`
+	// ConvertedPrice — цена в валюте отображения, если она запрошена в ленте.
+	ConvertedPrice *Money `json:"converted_price,omitempty"`
+	// Highlight заполняется только в результатах полнотекстового поиска.
+	Highlight *AdHighlight `json:"highlight,omitempty"`
+}
+
+// AdHighlight содержит фрагменты заголовка и описания, в которых совпадения
+// с поисковым запросом выделены тегами <mark>. Остальной текст экранирован.
+type AdHighlight struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
 }
 
-func NewAd(id, userID, title, description, imageURL string, price float64, createdAt time.Time) *Ad {
+func NewAd(id, userID, categoryID, title, description string, price Money, status string, createdAt, expiresAt time.Time) *Ad {
 	return &Ad{
 		ID:          id,
 		UserID:      userID,
+		CategoryID:  categoryID,
 		Title:       title,
 		Description: description,
-		ImageURL:    imageURL,
 		Price:       price,
+		Status:      status,
 		CreatedAt:   createdAt,
+		ExpiresAt:   expiresAt,
 	}
 }
@@ -0,0 +1,75 @@
+package domain
+
+import "time"
+
+// Состояния обработки изображения: после загрузки фоновый обработчик
+// строит уменьшенные копии (варианты) изображения.
+const (
+	ImageStatusPending    = "pending"
+	ImageStatusProcessing = "processing"
+	ImageStatusReady      = "ready"
+	ImageStatusFailed     = "failed"
+)
+
+// Варианты изображения.
+const (
+	ImageVariantThumbnail = "thumbnail"
+	ImageVariantMedium    = "medium"
+	ImageVariantLarge     = "large"
+)
+
+// ImageVariantSpec задает вариант изображения: наибольшую сторону в пикселях.
+type ImageVariantSpec struct {
+	Name    string
+	MaxSide int
+}
+
+// ImageVariantSpecs — варианты, которые строятся для каждого изображения.
+var ImageVariantSpecs = []ImageVariantSpec{
+	{Name: ImageVariantThumbnail, MaxSide: 320},
+	{Name: ImageVariantMedium, MaxSide: 800},
+	{Name: ImageVariantLarge, MaxSide: 1600},
+}
+
+// AdImage — изображение объявления. Изображения упорядочены по Position;
+// обложка (IsCover) показывается в ленте объявлений.
+type AdImage struct {
+	ID          string
+	AdID        string
+	StorageKey  string // Ключ файла в хранилище; пуст у внешних изображений
+	ExternalURL string // Ссылка на внешний файл у изображений, перенесенных из первой версии объявлений
+	ContentType string
+	Position    int
+	IsCover     bool
+	Status      string // Одно из ImageStatus*
+	Attempts    int    // Число попыток обработки
+	CreatedAt   time.Time
+
+	// Variants — готовые варианты изображения.
+	Variants []AdImageVariant
+	// URL — подписанная ссылка на файл, выдается вместе с объявлением.
+	URL string
+}
+
+// Variant возвращает вариант изображения по имени или nil, если он еще не готов.
+func (i *AdImage) Variant(name string) *AdImageVariant {
+	for j := range i.Variants {
+		if i.Variants[j].Name == name {
+			return &i.Variants[j]
+		}
+	}
+	return nil
+}
+
+// AdImageVariant — уменьшенная копия изображения объявления.
+type AdImageVariant struct {
+	ImageID     string
+	Name        string // Одно из ImageVariant*
+	StorageKey  string // Вариант в JPEG, который поддерживают все клиенты
+	ContentType string
+	Width       int
+	Height      int
+
+	// URL — подписанная ссылка на файл, выдается вместе с объявлением.
+	URL string
+}
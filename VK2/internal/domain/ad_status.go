@@ -0,0 +1,45 @@
+package domain
+
+// Статусы объявления. В ленте показываются только опубликованные объявления;
+// черновики, запланированные и архивные объявления видят лишь владелец и модераторы.
+const (
+	AdStatusDraft     = "draft"
+	AdStatusScheduled = "scheduled" // Будет опубликовано в момент Ad.PublishAt
+	AdStatusPublished = "published"
+	AdStatusReserved  = "reserved" // Покупатель найден, сделка еще не завершена
+	AdStatusSold      = "sold"
+	AdStatusArchived  = "archived"
+)
+
+// adStatusTransitions — допустимые переходы между статусами объявления.
+var adStatusTransitions = map[string][]string{
+	AdStatusDraft:     {AdStatusPublished, AdStatusArchived},
+	AdStatusScheduled: {AdStatusDraft, AdStatusPublished, AdStatusArchived},
+	AdStatusPublished: {AdStatusDraft, AdStatusReserved, AdStatusSold, AdStatusArchived},
+	AdStatusReserved:  {AdStatusPublished, AdStatusSold, AdStatusArchived},
+	AdStatusSold:      {AdStatusArchived},
+	AdStatusArchived:  {AdStatusDraft, AdStatusPublished},
+}
+
+// IsValidAdStatus проверяет, что статус объявления известен.
+func IsValidAdStatus(status string) bool {
+	_, ok := adStatusTransitions[status]
+	return ok
+}
+
+// CanTransitionAdStatus проверяет, что объявление можно перевести из статуса from в статус to.
+func CanTransitionAdStatus(from, to string) bool {
+	for _, next := range adStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPubliclyVisibleAdStatus сообщает, доступно ли объявление по ссылке любому
+// пользователю. Зарезервированные и проданные объявления не показываются в ленте,
+// но открываются по ссылке, чтобы покупатели видели, что товар уже не продается.
+func IsPubliclyVisibleAdStatus(status string) bool {
+	return status == AdStatusPublished || status == AdStatusReserved || status == AdStatusSold
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// Category описывает категорию объявлений. Категории образуют дерево:
+// у корневых категорий ParentID равен nil.
+type Category struct {
+	ID        string    `json:"id"`
+	ParentID  *string   `json:"parent_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
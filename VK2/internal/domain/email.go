@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// EmailMessage — письмо пользователю в виде простого текста.
+type EmailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// OutboxEmail — письмо в очереди на отправку.
+type OutboxEmail struct {
+	ID            string
+	Message       EmailMessage
+	Attempts      int       // Сколько раз письмо уже пытались отправить
+	NextAttemptAt time.Time // Раньше этого момента письмо не отправляется
+	CreatedAt     time.Time
+}
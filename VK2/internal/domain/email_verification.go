@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// EmailVerificationToken описывает выданный токен подтверждения почты. Как и
+// токен сброса пароля, хранится только хеш значения из письма.
+type EmailVerificationToken struct {
+	ID        string
+	UserID    string
+	Email     string // Адрес, на который отправлено письмо
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
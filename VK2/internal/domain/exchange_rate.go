@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// RateScale — точность курса обмена: шесть знаков после запятой, как в колонке NUMERIC(12, 6).
+const RateScale = 1_000_000
+
+// MaxRate — наибольший курс, который вмещает колонка NUMERIC(12, 6).
+const MaxRate Rate = 999_999_999_999
+
+var (
+	ErrInvalidRate   = errors.New("курс должен быть положительным десятичным числом")
+	ErrRatePrecision = errors.New("курс может содержать не более шести знаков после запятой")
+	ErrRateRange     = fmt.Errorf("курс должен быть не больше %s", MaxRate)
+)
+
+// Rate — курс обмена в миллионных долях: сколько единиц базовой валюты стоит
+// одна единица другой валюты.
+type Rate int64
+
+// ParseRate разбирает десятичную запись курса без округления.
+func ParseRate(s string) (Rate, error) {
+	v, err := parseScaled(s, RateScale, int64(MaxRate))
+	switch {
+	case err == errTooPrecise:
+		return 0, ErrRatePrecision
+	case err == errOutOfRange:
+		return 0, ErrRateRange
+	case err != nil || v <= 0:
+		return 0, ErrInvalidRate
+	}
+	return Rate(v), nil
+}
+
+// String возвращает курс с шестью знаками после запятой, например "92.500000".
+func (r Rate) String() string {
+	return formatScaled(int64(r), RateScale, 6)
+}
+
+// MarshalJSON кодирует курс десятичной строкой, чтобы клиенты не округляли его до float64.
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + r.String() + `"`), nil
+}
+
+// Scan реализует sql.Scanner для колонок NUMERIC.
+func (r *Rate) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return fmt.Errorf("не удалось прочитать курс из %T", src)
+	}
+	parsed, err := ParseRate(s)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать курс %q: %w", s, err)
+	}
+	*r = parsed
+	return nil
+}
+
+// Value реализует driver.Valuer: курс передается в БД десятичной строкой.
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// ExchangeRate — курс валюты к базовой валюте BaseCurrency.
+type ExchangeRate struct {
+	Currency  string    `json:"currency"`
+	Rate      Rate      `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ConvertAmount пересчитывает сумму из валюты с курсом from в валюту с курсом to
+// с округлением до копейки (половина округляется от нуля). Возвращает false,
+// если результат не помещается в Amount.
+func ConvertAmount(amount Amount, from, to Rate) (Amount, bool) {
+	if to <= 0 {
+		return 0, false
+	}
+	num := new(big.Int).Mul(big.NewInt(int64(amount)), big.NewInt(int64(from)))
+	den := big.NewInt(int64(to))
+
+	// Округление: (2·num + den) / (2·den) для положительных, симметрично для отрицательных
+	num.Mul(num, big.NewInt(2))
+	den.Mul(den, big.NewInt(2))
+	if num.Sign() < 0 {
+		num.Sub(num, big.NewInt(int64(to)))
+	} else {
+		num.Add(num, big.NewInt(int64(to)))
+	}
+	result := num.Quo(num, den)
+	if !result.IsInt64() {
+		return 0, false
+	}
+	return Amount(result.Int64()), true
+}
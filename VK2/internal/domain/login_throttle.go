@@ -0,0 +1,52 @@
+package domain
+
+import "time"
+
+// LoginThrottlePolicy задает, сколько неудачных попыток входа допускается и на
+// сколько после них блокируется вход.
+type LoginThrottlePolicy struct {
+	FreeAttempts int           // Неудачных попыток до первой блокировки
+	BaseLockout  time.Duration // Первая блокировка; каждая следующая вдвое дольше
+	MaxLockout   time.Duration // Наибольшая длительность блокировки
+	ResetAfter   time.Duration // Через сколько после последней неудачи счетчик начинается заново
+}
+
+// Lockout возвращает длительность блокировки после failures неудачных попыток подряд.
+func (p LoginThrottlePolicy) Lockout(failures int) time.Duration {
+	if failures < p.FreeAttempts {
+		return 0
+	}
+	lockout := p.BaseLockout
+	for i := p.FreeAttempts; i < failures && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+	return min(lockout, p.MaxLockout)
+}
+
+// LoginThrottle — счетчик неудачных попыток входа для логина или IP-адреса.
+type LoginThrottle struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+// Locked сообщает, заблокированы ли попытки входа в момент now.
+func (t *LoginThrottle) Locked(now time.Time) bool {
+	return t.LockedUntil != nil && t.LockedUntil.After(now)
+}
+
+// RecordFailure учитывает неудачную попытку и, если попыток слишком много,
+// блокирует вход по правилам policy.
+func (t *LoginThrottle) RecordFailure(policy LoginThrottlePolicy, now time.Time) {
+	if now.Sub(t.LastFailureAt) > policy.ResetAfter {
+		t.Failures = 0
+	}
+	t.Failures++
+	t.LastFailureAt = now
+	t.LockedUntil = nil
+	if lockout := policy.Lockout(t.Failures); lockout > 0 {
+		lockedUntil := now.Add(lockout)
+		t.LockedUntil = &lockedUntil
+	}
+}
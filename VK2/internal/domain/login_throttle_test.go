@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+var testPolicy = LoginThrottlePolicy{
+	FreeAttempts: 5,
+	BaseLockout:  30 * time.Second,
+	MaxLockout:   15 * time.Minute,
+	ResetAfter:   time.Hour,
+}
+
+func TestLoginThrottlePolicyLockout(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, 30 * time.Second},
+		{6, time.Minute},
+		{7, 2 * time.Minute},
+		{9, 8 * time.Minute},
+		{10, 15 * time.Minute},
+		{100, 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := testPolicy.Lockout(tt.failures); got != tt.want {
+			t.Errorf("Lockout(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginThrottleRecordFailure(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	throttle := &LoginThrottle{Key: "k"}
+
+	for i := 1; i < testPolicy.FreeAttempts; i++ {
+		throttle.RecordFailure(testPolicy, now)
+		if throttle.Locked(now) {
+			t.Fatalf("locked after %d failures, want unlocked", i)
+		}
+	}
+	throttle.RecordFailure(testPolicy, now)
+	if !throttle.Locked(now) {
+		t.Fatalf("not locked after %d failures", testPolicy.FreeAttempts)
+	}
+	if got := throttle.LockedUntil.Sub(now); got != testPolicy.BaseLockout {
+		t.Errorf("lockout = %s, want %s", got, testPolicy.BaseLockout)
+	}
+	if throttle.Locked(now.Add(testPolicy.BaseLockout)) {
+		t.Error("still locked when the lockout has ended")
+	}
+
+	// Следующая неудача после блокировки удваивает ее
+	later := now.Add(testPolicy.BaseLockout)
+	throttle.RecordFailure(testPolicy, later)
+	if got := throttle.LockedUntil.Sub(later); got != 2*testPolicy.BaseLockout {
+		t.Errorf("second lockout = %s, want %s", got, 2*testPolicy.BaseLockout)
+	}
+
+	// После ResetAfter без неудач счетчик начинается заново
+	idle := later.Add(testPolicy.ResetAfter + time.Second)
+	throttle.RecordFailure(testPolicy, idle)
+	if throttle.Failures != 1 || throttle.Locked(idle) {
+		t.Errorf("after idle period: failures = %d, locked = %v, want 1, false", throttle.Failures, throttle.Locked(idle))
+	}
+}
+
+func TestLoginThrottleLockedNil(t *testing.T) {
+	throttle := &LoginThrottle{}
+	if throttle.Locked(time.Now()) {
+		t.Error("Locked() = true for a throttle without lockout")
+	}
+}
@@ -0,0 +1,193 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// Валюты объявлений (ISO 4217).
+const (
+	CurrencyRUB = "RUB"
+	CurrencyUSD = "USD"
+	CurrencyKZT = "KZT"
+
+	DefaultCurrency = CurrencyRUB
+	// BaseCurrency — валюта, к которой указываются курсы обмена. Ее курс всегда равен 1.
+	BaseCurrency = CurrencyRUB
+)
+
+// SupportedCurrencies — валюты, в которых можно указывать цену объявления.
+var SupportedCurrencies = []string{CurrencyRUB, CurrencyUSD, CurrencyKZT}
+
+// IsSupportedCurrency сообщает, можно ли указывать цену объявления в валюте code.
+func IsSupportedCurrency(code string) bool {
+	for _, currency := range SupportedCurrencies {
+		if code == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// AmountScale — число минимальных единиц в единице валюты (два знака после запятой).
+const AmountScale = 100
+
+// MaxAmount — наибольшая сумма, которую вмещает колонка NUMERIC(10, 2): 99 999 999.99.
+const MaxAmount Amount = 99_999_999_99
+
+var (
+	ErrInvalidAmount   = errors.New("сумма должна быть десятичным числом")
+	ErrAmountPrecision = errors.New("сумма может содержать не более двух знаков после запятой")
+	ErrAmountRange     = fmt.Errorf("сумма должна быть не больше %s", MaxAmount)
+)
+
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d{1,3})?$`)
+
+// Amount — точная денежная сумма в минимальных единицах валюты (копейках, центах).
+// В отличие от float64 не теряет точность при вычислениях и сериализации.
+type Amount int64
+
+// ParseAmount разбирает десятичную запись суммы ("150", "150.5", "1.5e2") без округления.
+// Значения с точностью больше копейки или вне диапазона NUMERIC(10, 2) отклоняются.
+func ParseAmount(s string) (Amount, error) {
+	v, err := parseScaled(s, AmountScale, int64(MaxAmount))
+	switch err {
+	case errNotDecimal:
+		return 0, ErrInvalidAmount
+	case errTooPrecise:
+		return 0, ErrAmountPrecision
+	case errOutOfRange:
+		return 0, ErrAmountRange
+	}
+	return Amount(v), nil
+}
+
+// String возвращает сумму с двумя знаками после запятой, например "150.00".
+func (a Amount) String() string {
+	return formatScaled(int64(a), AmountScale, 2)
+}
+
+// MarshalJSON кодирует сумму JSON-числом с двумя знаками после запятой (150.00).
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON принимает сумму как JSON-число или строку.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var d Decimal
+	if err := d.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	parsed, err := ParseAmount(string(d))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Scan реализует sql.Scanner для колонок NUMERIC.
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return a.scanString(string(v))
+	case string:
+		return a.scanString(v)
+	case int64:
+		*a = Amount(v * AmountScale)
+		return nil
+	}
+	return fmt.Errorf("не удалось прочитать сумму из %T", src)
+}
+
+func (a *Amount) scanString(s string) error {
+	parsed, err := ParseAmount(s)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать сумму %q: %w", s, err)
+	}
+	*a = parsed
+	return nil
+}
+
+// Value реализует driver.Valuer: сумма передается в БД десятичной строкой без потери точности.
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Ошибки разбора десятичного числа с фиксированной точностью.
+var (
+	errNotDecimal = errors.New("not a decimal number")
+	errTooPrecise = errors.New("too many fractional digits")
+	errOutOfRange = errors.New("value out of range")
+)
+
+// parseScaled разбирает десятичную запись числа и возвращает его, умноженное на
+// scale. Значение должно получиться целым и не превышать max по модулю.
+func parseScaled(s string, scale, max int64) (int64, error) {
+	s = strings.TrimSpace(s)
+	// Только десятичная запись: big.Rat принимает также дроби и шестнадцатеричные
+	// числа, а неограниченный порядок позволил бы создать огромное число
+	if !decimalPattern.MatchString(s) {
+		return 0, errNotDecimal
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, errNotDecimal
+	}
+
+	r.Mul(r, big.NewRat(scale, 1))
+	if !r.IsInt() {
+		return 0, errTooPrecise
+	}
+
+	v := r.Num()
+	if v.CmpAbs(big.NewInt(max)) > 0 {
+		return 0, errOutOfRange
+	}
+	return v.Int64(), nil
+}
+
+// formatScaled форматирует число v/scale с digits знаками после запятой.
+func formatScaled(v, scale int64, digits int) string {
+	sign := ""
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, v/scale, digits, v%scale)
+}
+
+// Money — сумма в конкретной валюте.
+type Money struct {
+	Amount   Amount `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// Decimal — десятичное число из JSON, переданное числом или строкой, в исходной
+// записи. Позволяет проверить точность значения до его преобразования.
+type Decimal string
+
+// UnmarshalJSON принимает JSON-число или строку.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*d = Decimal(s)
+		return nil
+	}
+
+	// json.Number сохраняет исходную запись числа без преобразования во float64
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return ErrInvalidAmount
+	}
+	*d = Decimal(n)
+	return nil
+}
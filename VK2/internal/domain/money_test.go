@@ -0,0 +1,209 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Amount
+		wantErr error
+	}{
+		{"150", 150_00, nil},
+		{"150.5", 150_50, nil},
+		{"150.50", 150_50, nil},
+		{"0.01", 1, nil},
+		{".5", 50, nil},
+		{"5.", 5_00, nil},
+		{"+5", 5_00, nil},
+		{" 5 ", 5_00, nil},
+		{"1.5e2", 150_00, nil},
+		{"1.50e1", 15_00, nil},
+		{"1E-2", 1, nil},
+		{"-1.50", -1_50, nil},
+		{"99999999.99", MaxAmount, nil},
+		{"-99999999.99", -MaxAmount, nil},
+		// Примеры из задачи: раньше округлялись или переполняли колонку
+		{"0.001", 0, ErrAmountPrecision},
+		{"1e12", 0, ErrAmountRange},
+		{"99999999.991", 0, ErrAmountPrecision},
+		{"100000000", 0, ErrAmountRange},
+		{"100000000.00", 0, ErrAmountRange},
+		{"-100000000", 0, ErrAmountRange},
+		{"1e999", 0, ErrAmountRange},
+		{"1e1000", 0, ErrInvalidAmount},
+		{"1.5e-3", 0, ErrAmountPrecision},
+		{"", 0, ErrInvalidAmount},
+		{".", 0, ErrInvalidAmount},
+		{"0x10", 0, ErrInvalidAmount},
+		{"1/2", 0, ErrInvalidAmount},
+		{"1.5.5", 0, ErrInvalidAmount},
+		{"1,5", 0, ErrInvalidAmount},
+		{"NaN", 0, ErrInvalidAmount},
+		{"Inf", 0, ErrInvalidAmount},
+		{"e5", 0, ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseAmount(tt.input)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("ParseAmount(%q) = %d, %v, want %d, %v", tt.input, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAmountString(t *testing.T) {
+	tests := []struct {
+		amount Amount
+		want   string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{150_50, "150.50"},
+		{-5, "-0.05"},
+		{-1_50, "-1.50"},
+		{MaxAmount, "99999999.99"},
+	}
+	for _, tt := range tests {
+		if got := tt.amount.String(); got != tt.want {
+			t.Errorf("Amount(%d).String() = %q, want %q", int64(tt.amount), got, tt.want)
+		}
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Amount
+		wantErr bool
+	}{
+		{`150.5`, 150_50, false},
+		{`"150.5"`, 150_50, false},
+		{`1.5e2`, 150_00, false},
+		{`"1.5e2"`, 150_00, false},
+		{`0.001`, 0, true},
+		{`"0.001"`, 0, true},
+		{`1e12`, 0, true},
+		{`"abc"`, 0, true},
+		{`"0x10"`, 0, true},
+		{`true`, 0, true},
+		{`{}`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var got Amount
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("Unmarshal(%s) = %d, %v, want %d, error %v", tt.input, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMoneyJSONRoundTrip(t *testing.T) {
+	// Цена, переданная строкой и числом, дает одно и то же значение и
+	// кодируется числом с двумя знаками после запятой
+	for _, input := range []string{
+		`{"amount":"99999999.99","currency":"RUB"}`,
+		`{"amount":99999999.99,"currency":"RUB"}`,
+	} {
+		var money Money
+		if err := json.Unmarshal([]byte(input), &money); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", input, err)
+		}
+		if money.Amount != MaxAmount || money.Currency != CurrencyRUB {
+			t.Errorf("Unmarshal(%s) = %+v, want %d RUB", input, money, int64(MaxAmount))
+		}
+
+		data, err := json.Marshal(money)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if want := `{"amount":99999999.99,"currency":"RUB"}`; string(data) != want {
+			t.Errorf("Marshal() = %s, want %s", data, want)
+		}
+
+		var decoded Money
+		if err := json.Unmarshal(data, &decoded); err != nil || decoded != money {
+			t.Errorf("round trip = %+v, %v, want %+v", decoded, err, money)
+		}
+	}
+}
+
+func TestAmountScan(t *testing.T) {
+	var amount Amount
+	if err := amount.Scan([]byte("150.50")); err != nil || amount != 150_50 {
+		t.Errorf("Scan([]byte) = %d, %v, want 15050", amount, err)
+	}
+	if err := amount.Scan(int64(7)); err != nil || amount != 7_00 {
+		t.Errorf("Scan(int64) = %d, %v, want 700", amount, err)
+	}
+	if err := amount.Scan(1.5); err == nil {
+		t.Error("Scan(float64) error = nil, want error")
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Rate
+		wantErr error
+	}{
+		{"1", 1_000_000, nil},
+		{"92.5", 92_500_000, nil},
+		{"0.000001", 1, nil},
+		{"1.5e-6", 0, ErrRatePrecision},
+		{"999999.999999", MaxRate, nil},
+		{"1000000", 0, ErrRateRange},
+		{"0.0000001", 0, ErrRatePrecision},
+		{"0", 0, ErrInvalidRate},
+		{"-1", 0, ErrInvalidRate},
+		{"abc", 0, ErrInvalidRate},
+		{"1/3", 0, ErrInvalidRate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRate(tt.input)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("ParseRate(%q) = %d, %v, want %d, %v", tt.input, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRateJSON(t *testing.T) {
+	data, err := json.Marshal(Rate(92_500_000))
+	if err != nil || string(data) != `"92.500000"` {
+		t.Errorf("Marshal() = %s, %v, want \"92.500000\"", data, err)
+	}
+}
+
+func TestConvertAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Amount
+		from, to Rate
+		want     Amount
+		wantOK   bool
+	}{
+		{"same rate", 150_00, 1_000_000, 1_000_000, 150_00, true},
+		{"to base currency", 10_00, 92_500_000, 1_000_000, 925_00, true},
+		{"from base currency", 925_00, 1_000_000, 92_500_000, 10_00, true},
+		{"rounds half away from zero", 1, 1_000_000, 2_000_000, 1, true},
+		{"negative rounds half away from zero", -1, 1_000_000, 2_000_000, -1, true},
+		{"zero rate", 150_00, 1_000_000, 0, 0, false},
+		{"overflow", Amount(1 << 62), MaxRate, 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ConvertAmount(tt.amount, tt.from, tt.to)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ConvertAmount() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
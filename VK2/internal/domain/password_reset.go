@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// PasswordResetToken описывает выданный токен сброса пароля. Само значение
+// отправляется пользователю письмом, в хранилище попадает только его хеш.
+// Токен одноразовый: после использования заполняется UsedAt.
+type PasswordResetToken struct {
+	ID        string
+	UserID    string
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
+
+// PasswordResetRequest — запрос на сброс пароля, ожидающий обработки в фоне.
+type PasswordResetRequest struct {
+	ID        string
+	Email     string
+	CreatedAt time.Time
+}
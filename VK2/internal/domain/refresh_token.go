@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// RefreshToken описывает сохраненный refresh-токен. Токены, выданные в рамках
+// одного входа, образуют семейство: при каждом обновлении старый токен
+// помечается использованным, а новый получает тот же FamilyID.
+type RefreshToken struct {
+	ID        string
+	UserID    string
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+}
@@ -0,0 +1,16 @@
+package domain
+
+// Встроенные роли пользователей. Набор ролей и их разрешений хранится в БД.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Разрешения, проверяемые приложением.
+const (
+	PermissionModerateAds      = "ads:moderate"
+	PermissionManageRoles      = "users:manage_roles"
+	PermissionManageCategories = "categories:manage"
+	PermissionManageRates      = "rates:manage"
+)
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// Session описывает сессию пользователя, открытую при входе. ID сессии
+// встраивается в access-токен и совпадает с FamilyID ее refresh-токенов.
+type Session struct {
+	ID         string
+	UserID     string
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+}
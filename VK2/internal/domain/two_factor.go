@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// TOTPSecret описывает подключенный или подключаемый второй фактор (TOTP).
+type TOTPSecret struct {
+	UserID       string
+	Secret       string     // base32
+	EnabledAt    *time.Time // nil, пока пользователь не подтвердил подключение кодом
+	LastUsedStep int64      // Интервал последнего принятого кода
+	CreatedAt    time.Time
+}
+
+// Enabled сообщает, подтверждено ли подключение второго фактора.
+func (s *TOTPSecret) Enabled() bool {
+	return s != nil && s.EnabledAt != nil
+}
+
+// LoginChallenge — незавершенный вход: пароль проверен, ожидается код второго
+// фактора. Клиент получает токен, в хранилище попадает только его хеш.
+type LoginChallenge struct {
+	ID        string
+	UserID    string
+	TokenHash string
+	Attempts  int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
@@ -3,10 +3,18 @@ package domain
 import "time"
 
 type User struct {
-	ID           string    `json:"id"`
-	Login        string    `json:"login"`
-	PasswordHash string    `json:"-"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID              string     `json:"id"`
+	Login           string     `json:"login"`
+	Email           string     `json:"email,omitempty"`             // Пустая, если пользователь не указал почту
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"` // nil, пока почта не подтверждена
+	PasswordHash    string     `json:"-"`
+	Role            string     `json:"role"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// EmailVerified сообщает, указана ли у пользователя почта и подтверждена ли она.
+func (u *User) EmailVerified() bool {
+	return u.Email != "" && u.EmailVerifiedAt != nil
 }
 
 func NewUser(id, login, passwordHash string, createdAt time.Time) *User {
@@ -14,6 +22,7 @@ func NewUser(id, login, passwordHash string, createdAt time.Time) *User {
 		ID:           id,
 		Login:        login,
 		PasswordHash: passwordHash,
+		Role:         RoleUser,
 		CreatedAt:    createdAt,
 	}
 }
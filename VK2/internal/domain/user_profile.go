@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// UserProfile — сведения, которые пользователь указывает о себе. Отображаемое имя,
+// аватар и описание видны всем; город и телефон — только если пользователь их открыл.
+type UserProfile struct {
+	UserID      string
+	DisplayName string
+	AvatarKey   string // Ключ файла аватара в хранилище; пустой, если аватар не загружен
+	City        string
+	Phone       string // В формате E.164: +79991234567
+	Bio         string
+	CityPublic  bool
+	PhonePublic bool
+	UpdatedAt   time.Time
+
+	// AvatarURL — подписанная ссылка на аватар, выдается вместе с профилем.
+	AvatarURL string
+}
+
+// NewUserProfile возвращает пустой профиль с видимостью полей по умолчанию:
+// город открыт, телефон скрыт.
+func NewUserProfile(userID string) *UserProfile {
+	return &UserProfile{
+		UserID:     userID,
+		CityPublic: true,
+	}
+}
@@ -0,0 +1,66 @@
+package breach
+
+import (
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"strings"
+
+	"vk/internal/adapter/repository"
+)
+
+// commonPasswords — распространенные пароли, которые проходят требования к
+// составу: частые слова и имена с заглавной буквы и типичными окончаниями
+// (1!, 123!, 2024! и т. п.). По одному паролю в строке.
+//
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// MemoryBreachedPasswordRepository хранит базу паролей из утечек в памяти.
+// Подходит для небольших списков; полную базу Have I Been Pwned читает
+// FileBreachedPasswordRepository.
+type MemoryBreachedPasswordRepository struct {
+	suffixes map[string]map[string]int // Префикс хеша → суффикс → число утечек
+}
+
+var _ repository.BreachedPasswordRepository = (*MemoryBreachedPasswordRepository)(nil)
+
+// NewMemoryBreachedPasswordRepository создает базу из списка паролей. Каждый
+// пароль считается встретившимся в одной утечке.
+func NewMemoryBreachedPasswordRepository(passwords []string) *MemoryBreachedPasswordRepository {
+	r := &MemoryBreachedPasswordRepository{suffixes: make(map[string]map[string]int)}
+	for _, password := range passwords {
+		sum := sha1.Sum([]byte(password))
+		hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+		if r.suffixes[hash[:5]] == nil {
+			r.suffixes[hash[:5]] = make(map[string]int)
+		}
+		r.suffixes[hash[:5]][hash[5:]]++
+	}
+	return r
+}
+
+// NewCommonBreachedPasswordRepository создает базу из встроенного списка
+// распространенных паролей. Используется, если полная база не задана: список
+// намного меньше базы утечек и отсекает только самые очевидные пароли.
+func NewCommonBreachedPasswordRepository() *MemoryBreachedPasswordRepository {
+	var passwords []string
+	for _, line := range strings.Split(commonPasswords, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			passwords = append(passwords, line)
+		}
+	}
+	return NewMemoryBreachedPasswordRepository(passwords)
+}
+
+// GetBreachedHashSuffixes возвращает суффиксы хешей паролей с префиксом prefix.
+func (r *MemoryBreachedPasswordRepository) GetBreachedHashSuffixes(prefix string) (map[string]int, error) {
+	if err := validatePrefix(prefix); err != nil {
+		return nil, err
+	}
+	suffixes := make(map[string]int, len(r.suffixes[prefix]))
+	for suffix, count := range r.suffixes[prefix] {
+		suffixes[suffix] = count
+	}
+	return suffixes, nil
+}
@@ -0,0 +1,52 @@
+package breach
+
+import "testing"
+
+func TestCommonBreachedPasswordRepository(t *testing.T) {
+	repo := NewCommonBreachedPasswordRepository()
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"Qwerty123!", true},
+		{"Summer2024!", true},
+		{"Admin123!", true},
+		{"P@ssw0rd", true},
+		{"Xk9#vLq2!mWz", false},
+	}
+
+	for _, tt := range tests {
+		prefix, suffix := hashParts(tt.password)
+		suffixes, err := repo.GetBreachedHashSuffixes(prefix)
+		if err != nil {
+			t.Fatalf("GetBreachedHashSuffixes(%s) error = %v", prefix, err)
+		}
+		if got := suffixes[suffix] > 0; got != tt.want {
+			t.Errorf("%q listed = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryBreachedPasswordRepository(t *testing.T) {
+	repo := NewMemoryBreachedPasswordRepository([]string{"Secret123!", "Secret123!"})
+	prefix, suffix := hashParts("Secret123!")
+
+	suffixes, err := repo.GetBreachedHashSuffixes(prefix)
+	if err != nil {
+		t.Fatalf("GetBreachedHashSuffixes() error = %v", err)
+	}
+	if suffixes[suffix] != 2 {
+		t.Errorf("suffixes[%s] = %d, want 2", suffix, suffixes[suffix])
+	}
+
+	// Возвращается копия: изменения не попадают в базу
+	suffixes[suffix] = 0
+	if again, _ := repo.GetBreachedHashSuffixes(prefix); again[suffix] != 2 {
+		t.Errorf("suffixes after caller modification = %d, want 2", again[suffix])
+	}
+
+	if _, err := repo.GetBreachedHashSuffixes("abcde"); err == nil {
+		t.Error("GetBreachedHashSuffixes(lowercase) error = nil, want error")
+	}
+}
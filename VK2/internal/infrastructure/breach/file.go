@@ -0,0 +1,83 @@
+// Package breach содержит базы паролей из утечек.
+package breach
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"vk/internal/adapter/repository"
+)
+
+// FileBreachedPasswordRepository читает базу паролей из утечек из каталога
+// файлов по префиксам, как их сохраняет загрузчик Have I Been Pwned
+// (PwnedPasswordsDownloader): файл 21BD1.txt содержит строки вида
+// 0018A45C4D1DEF81644B54AB7F969B88D65:3 — суффикс хеша и число утечек.
+// Каталог может содержать только часть префиксов: отсутствующий файл означает,
+// что паролей с таким префиксом в базе нет.
+type FileBreachedPasswordRepository struct {
+	dir string
+}
+
+var _ repository.BreachedPasswordRepository = (*FileBreachedPasswordRepository)(nil)
+
+// NewFileBreachedPasswordRepository создает базу паролей из утечек в каталоге dir.
+func NewFileBreachedPasswordRepository(dir string) (*FileBreachedPasswordRepository, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть каталог базы паролей %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s не является каталогом", dir)
+	}
+	return &FileBreachedPasswordRepository{dir: dir}, nil
+}
+
+// GetBreachedHashSuffixes читает файл префикса. Строки с нулевым числом утечек
+// (заполнение, которое добавляет API Have I Been Pwned) пропускаются.
+func (r *FileBreachedPasswordRepository) GetBreachedHashSuffixes(prefix string) (map[string]int, error) {
+	if err := validatePrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filepath.Join(r.dir, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]int{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached passwords file: %w", err)
+	}
+	defer file.Close()
+
+	suffixes := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		suffix, countValue, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok {
+			continue
+		}
+		count, err := strconv.Atoi(countValue)
+		if err != nil || count <= 0 {
+			continue
+		}
+		suffixes[strings.ToUpper(suffix)] = count
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached passwords file: %w", err)
+	}
+	return suffixes, nil
+}
+
+// validatePrefix проверяет, что префикс — 5 hex-символов в верхнем регистре.
+// Префикс становится частью пути к файлу, поэтому другие символы недопустимы.
+func validatePrefix(prefix string) error {
+	if len(prefix) != 5 || strings.ContainsFunc(prefix, func(c rune) bool { return !strings.ContainsRune("0123456789ABCDEF", c) }) {
+		return fmt.Errorf("некорректный префикс хеша %q", prefix)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package breach
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// hashParts возвращает префикс и суффикс SHA-1 хеша пароля в верхнем регистре.
+func hashParts(password string) (string, string) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	return hash[:5], hash[5:]
+}
+
+func newTestFileRepository(t *testing.T, files map[string]string) *FileBreachedPasswordRepository {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", name, err)
+		}
+	}
+	repo, err := NewFileBreachedPasswordRepository(dir)
+	if err != nil {
+		t.Fatalf("NewFileBreachedPasswordRepository() error = %v", err)
+	}
+	return repo
+}
+
+func TestFileBreachedPasswordRepositoryParsesPrefixFile(t *testing.T) {
+	prefix, suffix := hashParts("P@ssw0rd")
+	content := strings.Join([]string{
+		suffix + ":52579",
+		strings.ToLower("0018A45C4D1DEF81644B54AB7F969B88D65") + ":3", // Суффикс в нижнем регистре
+		"00D4F6E8FA6EECAD2A3AA415EEC418D38EC:2\r",                      // Окончание строки CRLF
+		"011053FD0102E94D6AE2F8B83D76FAF94F6:0",                        // Заполнение
+		"012A7CA357541F0AC487871FEEC1891C49C:-1",
+		"0136E006E24E7D152139815FB0FC6A50B15",
+		"01A85766CD276B17DE6DA022AA3CADAC3CE:abc",
+		"",
+	}, "\n")
+	repo := newTestFileRepository(t, map[string]string{prefix + ".txt": content})
+
+	suffixes, err := repo.GetBreachedHashSuffixes(prefix)
+	if err != nil {
+		t.Fatalf("GetBreachedHashSuffixes() error = %v", err)
+	}
+	want := map[string]int{
+		suffix:                                52579,
+		"0018A45C4D1DEF81644B54AB7F969B88D65": 3,
+		"00D4F6E8FA6EECAD2A3AA415EEC418D38EC": 2,
+	}
+	if len(suffixes) != len(want) {
+		t.Errorf("GetBreachedHashSuffixes() = %v, want %v", suffixes, want)
+	}
+	for s, count := range want {
+		if suffixes[s] != count {
+			t.Errorf("suffixes[%s] = %d, want %d", s, suffixes[s], count)
+		}
+	}
+}
+
+func TestFileBreachedPasswordRepositoryMissingPrefixFile(t *testing.T) {
+	repo := newTestFileRepository(t, map[string]string{"00000.txt": "0018A45C4D1DEF81644B54AB7F969B88D65:3\n"})
+
+	suffixes, err := repo.GetBreachedHashSuffixes("FFFFF")
+	if err != nil {
+		t.Fatalf("GetBreachedHashSuffixes() error = %v", err)
+	}
+	if len(suffixes) != 0 {
+		t.Errorf("GetBreachedHashSuffixes() = %v, want empty", suffixes)
+	}
+}
+
+func TestFileBreachedPasswordRepositoryInvalidPrefix(t *testing.T) {
+	repo := newTestFileRepository(t, nil)
+
+	for _, prefix := range []string{"", "1234", "123456", "abcde", "0000G", "../00", "00/00"} {
+		if _, err := repo.GetBreachedHashSuffixes(prefix); err == nil {
+			t.Errorf("GetBreachedHashSuffixes(%q) error = nil, want error", prefix)
+		}
+	}
+}
+
+func TestNewFileBreachedPasswordRepositoryRequiresDirectory(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "00000.txt")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	for _, path := range []string{filepath.Join(dir, "missing"), file} {
+		if _, err := NewFileBreachedPasswordRepository(path); err == nil {
+			t.Errorf("NewFileBreachedPasswordRepository(%s) error = nil, want error", path)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+// Package imaging проверяет загружаемые изображения, удаляет из них метаданные
+// и строит уменьшенные копии.
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Регистрация декодеров для image.DecodeConfig
+	"image/png"
+	"net/http"
+
+	_ "golang.org/x/image/webp"
+)
+
+// Поддерживаемые форматы изображений.
+const (
+	ContentTypeJPEG = "image/jpeg"
+	ContentTypePNG  = "image/png"
+	ContentTypeWebP = "image/webp"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("only JPEG, PNG and WebP images are supported")
+	ErrMalformedImage    = errors.New("image file is corrupted")
+)
+
+// DetectContentType определяет формат изображения по содержимому файла, а не по
+// имени или заголовку Content-Type, которые задает клиент.
+func DetectContentType(data []byte) (string, error) {
+	switch contentType := http.DetectContentType(data); contentType {
+	case ContentTypeJPEG, ContentTypePNG, ContentTypeWebP:
+		return contentType, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Extension возвращает расширение файла для формата изображения.
+func Extension(contentType string) string {
+	switch contentType {
+	case ContentTypeJPEG:
+		return ".jpg"
+	case ContentTypePNG:
+		return ".png"
+	case ContentTypeWebP:
+		return ".webp"
+	}
+	return ""
+}
+
+// DecodeConfig читает размеры изображения из заголовка, не декодируя пиксели.
+func DecodeConfig(data []byte) (image.Config, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return image.Config{}, fmt.Errorf("%w: %v", ErrMalformedImage, err)
+	}
+	return config, nil
+}
+
+// originalJPEGQuality — качество JPEG при перекодировании загруженного
+// оригинала: выше, чем у уменьшенных копий, чтобы не терять детали.
+const originalJPEGQuality = 92
+
+// StripMetadata удаляет из изображения метаданные (EXIF с координатами съемки
+// и моделью камеры, XMP, IPTC, текстовые комментарии) и возвращает результат
+// вместе с его типом. Обычно пиксели не перекодируются; если же в EXIF задана
+// ориентация, изображение поворачивается и кодируется заново, иначе после
+// удаления EXIF оно отображалось бы повернутым.
+func StripMetadata(data []byte, contentType string) ([]byte, string, error) {
+	if orientation := Orientation(data); orientation != 1 {
+		return reencodeOriented(data, contentType, orientation)
+	}
+
+	var stripped []byte
+	var err error
+	switch contentType {
+	case ContentTypeJPEG:
+		stripped, err = stripJPEG(data)
+	case ContentTypePNG:
+		stripped, err = stripPNG(data)
+	case ContentTypeWebP:
+		stripped, err = stripWebP(data)
+	default:
+		return nil, "", ErrUnsupportedFormat
+	}
+	return stripped, contentType, err
+}
+
+// reencodeOriented применяет ориентацию из EXIF к пикселям и кодирует изображение
+// заново. Кодировщики не записывают метаданные, поэтому результат их не содержит;
+// цветовой профиль при этом тоже теряется. JPEG и PNG сохраняют формат, а WebP
+// кодируется в JPEG: кодировщика WebP в стандартной библиотеке нет.
+func reencodeOriented(data []byte, contentType string, orientation int) ([]byte, string, error) {
+	img, err := Decode(data)
+	if err != nil {
+		return nil, "", err
+	}
+	img = ApplyOrientation(img, orientation)
+
+	switch contentType {
+	case ContentTypeJPEG, ContentTypeWebP:
+		encoded, err := encodeJPEG(img, originalJPEGQuality)
+		return encoded, ContentTypeJPEG, err
+	case ContentTypePNG:
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("failed to encode png: %w", err)
+		}
+		return buf.Bytes(), ContentTypePNG, nil
+	}
+	return nil, "", ErrUnsupportedFormat
+}
+
+// stripJPEG удаляет сегменты APP1 (EXIF, XMP), APP12, APP13 (IPTC) и COM.
+// JFIF (APP0), цветовой профиль ICC (APP2) и Adobe (APP14) сохраняются:
+// они влияют на отображение цветов.
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, ErrMalformedImage
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+	pos := 2
+	for {
+		// Перед маркером допускается произвольное число байтов заполнения 0xFF
+		for pos < len(data) && data[pos] == 0xFF && pos+1 < len(data) && data[pos+1] == 0xFF {
+			pos++
+		}
+		if pos+4 > len(data) || data[pos] != 0xFF {
+			return nil, ErrMalformedImage
+		}
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, ErrMalformedImage
+		}
+
+		// После SOS идут сжатые данные до конца файла: копируем их без изменений
+		if marker == 0xDA {
+			return append(out, data[pos:]...), nil
+		}
+
+		switch marker {
+		case 0xE1, 0xEC, 0xED, 0xFE:
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+}
+
+// pngSignature — первые восемь байтов любого PNG-файла.
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngMetadataChunks — вспомогательные чанки PNG с метаданными.
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+// stripPNG удаляет чанки с EXIF, текстовыми полями и временем изменения.
+// Контрольная сумма считается для каждого чанка отдельно, поэтому остальные
+// чанки копируются без изменений.
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrMalformedImage
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	pos := len(pngSignature)
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return nil, ErrMalformedImage
+		}
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		chunkType := string(data[pos+4 : pos+8])
+		end := pos + 12 + length // длина, тип, данные и CRC
+		if end > len(data) {
+			return nil, ErrMalformedImage
+		}
+
+		if !pngMetadataChunks[chunkType] {
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+		if chunkType == "IEND" {
+			break
+		}
+	}
+	return out, nil
+}
+
+// Флаги чанка VP8X, сообщающие о наличии метаданных.
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+// stripWebP удаляет чанки EXIF и XMP из расширенного формата WebP (VP8X)
+// и сбрасывает соответствующие флаги. Простой формат метаданных не содержит.
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrMalformedImage
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:12]...)
+	pos := 12
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return nil, ErrMalformedImage
+		}
+		fourCC := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		if pos+8+size > len(data) {
+			return nil, ErrMalformedImage
+		}
+		// Данные чанка дополняются до четной длины; некоторые кодировщики
+		// не дописывают байт выравнивания у последнего чанка
+		end := min(pos+8+size+size%2, len(data))
+
+		switch fourCC {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), data[pos:end]...)
+			if size > 0 {
+				chunk[8] &^= webpFlagEXIF | webpFlagXMP
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+
+	// Размер RIFF не включает сигнатуру и само поле размера
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	return out, nil
+}
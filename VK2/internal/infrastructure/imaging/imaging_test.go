@@ -0,0 +1,185 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/rand"
+	"testing"
+)
+
+// testImage возвращает изображение с градиентом, шумом и, если нужно, прозрачностью.
+func testImage(width, height int, withAlpha bool) *image.NRGBA {
+	rng := rand.New(rand.NewSource(int64(width*1000 + height)))
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			alpha := uint8(0xFF)
+			if withAlpha {
+				alpha = uint8(x * 255 / max(width-1, 1))
+			}
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(x*7 + rng.Intn(8)),
+				G: uint8(y*5 + rng.Intn(8)),
+				B: uint8((x + y) * 3),
+				A: alpha,
+			})
+		}
+	}
+	return img
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// Изображение 3×2:
+	//   0 1 2
+	//   3 4 5
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for i := 0; i < 6; i++ {
+		src.SetNRGBA(i%3, i/3, color.NRGBA{R: uint8(i), A: 0xFF})
+	}
+
+	tests := []struct {
+		orientation int
+		want        [][]uint8
+	}{
+		{1, [][]uint8{{0, 1, 2}, {3, 4, 5}}},
+		{2, [][]uint8{{2, 1, 0}, {5, 4, 3}}},
+		{3, [][]uint8{{5, 4, 3}, {2, 1, 0}}},
+		{4, [][]uint8{{3, 4, 5}, {0, 1, 2}}},
+		{5, [][]uint8{{0, 3}, {1, 4}, {2, 5}}},
+		{6, [][]uint8{{3, 0}, {4, 1}, {5, 2}}},
+		{7, [][]uint8{{5, 2}, {4, 1}, {3, 0}}},
+		{8, [][]uint8{{2, 5}, {1, 4}, {0, 3}}},
+	}
+	for _, tt := range tests {
+		got := ApplyOrientation(src, tt.orientation)
+		if got.Bounds().Dx() != len(tt.want[0]) || got.Bounds().Dy() != len(tt.want) {
+			t.Errorf("orientation %d: size = %v, want %dx%d", tt.orientation, got.Bounds().Size(), len(tt.want[0]), len(tt.want))
+			continue
+		}
+		for y, row := range tt.want {
+			for x, want := range row {
+				if r := color.NRGBAModel.Convert(got.At(x, y)).(color.NRGBA).R; r != want {
+					t.Errorf("orientation %d: pixel (%d, %d) = %d, want %d", tt.orientation, x, y, r, want)
+				}
+			}
+		}
+	}
+}
+
+// exifWithOrientation возвращает сегмент APP1 с EXIF, содержащим только тег Orientation.
+func exifWithOrientation(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II*\x00")
+	} else {
+		copy(tiff, "MM\x00*")
+	}
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], exifTagOrientation)
+	order.PutUint16(tiff[12:], exifTypeShort)
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+
+	payload := append(append([]byte(nil), exifHeader...), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func TestStripMetadataAppliesJPEGOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(40, 24, false), nil); err != nil {
+		t.Fatal(err)
+	}
+	plain := buf.Bytes()
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		// Сегмент APP1 вставляется сразу после SOI
+		data := append(append(append([]byte(nil), plain[:2]...), exifWithOrientation(order, 6)...), plain[2:]...)
+		if got := Orientation(data); got != 6 {
+			t.Fatalf("Orientation() = %d, want 6", got)
+		}
+
+		stripped, contentType, err := StripMetadata(data, ContentTypeJPEG)
+		if err != nil || contentType != ContentTypeJPEG {
+			t.Fatalf("StripMetadata() = %q, %v, want %q", contentType, err, ContentTypeJPEG)
+		}
+		if got := Orientation(stripped); got != 1 {
+			t.Errorf("Orientation() after strip = %d, want 1", got)
+		}
+		config, err := DecodeConfig(stripped)
+		if err != nil {
+			t.Fatalf("DecodeConfig() error = %v", err)
+		}
+		if config.Width != 24 || config.Height != 40 {
+			t.Errorf("size after strip = %dx%d, want 24x40", config.Width, config.Height)
+		}
+	}
+
+	// Без ориентации файл не перекодируется
+	stripped, _, err := StripMetadata(plain, ContentTypeJPEG)
+	if err != nil {
+		t.Fatalf("StripMetadata() error = %v", err)
+	}
+	if !bytes.Equal(stripped, plain) {
+		t.Error("StripMetadata() changed an image without metadata")
+	}
+}
+
+// tinyWebP — изображение 1×1 в простом формате WebP без потерь (VP8L).
+var tinyWebP = []byte("RIFF\x1a\x00\x00\x00WEBPVP8L\x0d\x00\x00\x00\x2f\x00\x00\x00\x10\x07\x10\x11\x11\x88\x88\xfe\x07\x00")
+
+// webpWithOrientation упаковывает tinyWebP в расширенный формат (VP8X) с чанком EXIF.
+func webpWithOrientation(orientation uint16) []byte {
+	exif := exifWithOrientation(binary.LittleEndian, orientation)[4:] // Без маркера и длины сегмента JPEG
+
+	vp8x := []byte("VP8X\x0a\x00\x00\x00")
+	vp8x = append(vp8x, webpFlagEXIF, 0, 0, 0, 0, 0, 0, 0, 0, 0) // Холст 1×1: хранятся ширина и высота минус один
+
+	body := append([]byte("WEBP"), vp8x...)
+	body = append(body, tinyWebP[12:]...)
+	body = append(body, "EXIF"...)
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(exif)))
+	body = append(body, exif...)
+	if len(exif)%2 == 1 {
+		body = append(body, 0)
+	}
+
+	data := binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body)))
+	return append(data, body...)
+}
+
+func TestStripMetadataWebP(t *testing.T) {
+	// Без ориентации чанк EXIF удаляется, а формат сохраняется
+	data := webpWithOrientation(1)
+	stripped, contentType, err := StripMetadata(data, ContentTypeWebP)
+	if err != nil || contentType != ContentTypeWebP {
+		t.Fatalf("StripMetadata() = %q, %v, want %q", contentType, err, ContentTypeWebP)
+	}
+	if bytes.Contains(stripped, []byte("EXIF")) {
+		t.Error("StripMetadata() kept the EXIF chunk")
+	}
+	if _, err := DecodeConfig(stripped); err != nil {
+		t.Errorf("DecodeConfig() error = %v", err)
+	}
+
+	// С ориентацией изображение перекодируется в JPEG
+	data = webpWithOrientation(6)
+	if got := Orientation(data); got != 6 {
+		t.Fatalf("Orientation() = %d, want 6", got)
+	}
+	stripped, contentType, err = StripMetadata(data, ContentTypeWebP)
+	if err != nil || contentType != ContentTypeJPEG {
+		t.Fatalf("StripMetadata() = %q, %v, want %q", contentType, err, ContentTypeJPEG)
+	}
+	if detected, err := DetectContentType(stripped); err != nil || detected != ContentTypeJPEG {
+		t.Errorf("DetectContentType() = %q, %v, want %q", detected, err, ContentTypeJPEG)
+	}
+	if got := Orientation(stripped); got != 1 {
+		t.Errorf("Orientation() after strip = %d, want 1", got)
+	}
+}
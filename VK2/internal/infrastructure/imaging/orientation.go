@@ -0,0 +1,190 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// Тег EXIF Orientation: как повернуть и отразить пиксели, чтобы изображение
+// выглядело так, как его снимали. Значение 1 — пиксели уже в нужном положении.
+const (
+	exifTagOrientation = 0x0112
+	exifTypeShort      = 3
+)
+
+// exifHeader предшествует данным TIFF в сегменте APP1 JPEG и иногда в чанке EXIF WebP.
+var exifHeader = []byte("Exif\x00\x00")
+
+// Orientation возвращает значение тега EXIF Orientation (от 1 до 8) изображения
+// JPEG, PNG или WebP. Если тега нет или метаданные повреждены, возвращается 1.
+func Orientation(data []byte) int {
+	switch {
+	case len(data) >= 2 && data[0] == 0xFF && data[1] == 0xD8:
+		return jpegOrientation(data)
+	case bytes.HasPrefix(data, pngSignature):
+		return pngOrientation(data)
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return webpOrientation(data)
+	}
+	return 1
+}
+
+// jpegOrientation ищет EXIF в сегментах APP1 до начала сжатых данных.
+func jpegOrientation(data []byte) int {
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xFF {
+		marker := data[pos+1]
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if marker == 0xDA || length < 2 || end > len(data) {
+			break
+		}
+		if segment := data[pos+4 : end]; marker == 0xE1 && bytes.HasPrefix(segment, exifHeader) {
+			return exifOrientation(segment)
+		}
+		pos = end
+	}
+	return 1
+}
+
+// pngOrientation ищет чанк eXIf.
+func pngOrientation(data []byte) int {
+	pos := len(pngSignature)
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		chunkType := string(data[pos+4 : pos+8])
+		if length < 0 || pos+12+length > len(data) || chunkType == "IEND" {
+			break
+		}
+		if chunkType == "eXIf" {
+			return exifOrientation(data[pos+8 : pos+8+length])
+		}
+		pos += 12 + length
+	}
+	return 1
+}
+
+// webpOrientation ищет чанк EXIF расширенного формата WebP.
+func webpOrientation(data []byte) int {
+	pos := 12
+	for pos+8 <= len(data) {
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		if size < 0 || pos+8+size > len(data) {
+			break
+		}
+		if string(data[pos:pos+4]) == "EXIF" {
+			return exifOrientation(data[pos+8 : pos+8+size])
+		}
+		pos += 8 + size + size%2
+	}
+	return 1
+}
+
+// exifOrientation читает тег Orientation из первого каталога (IFD0) данных TIFF.
+func exifOrientation(tiff []byte) int {
+	tiff = bytes.TrimPrefix(tiff, exifHeader)
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:4]) {
+	case "II*\x00":
+		order = binary.LittleEndian
+	case "MM\x00*":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int64(order.Uint32(tiff[4:8]))
+	if offset < 8 || offset+2 > int64(len(tiff)) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := int(offset) + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) != exifTagOrientation {
+			continue
+		}
+		if order.Uint16(tiff[entry+2:]) != exifTypeShort {
+			break
+		}
+		if value := int(order.Uint16(tiff[entry+8:])); value >= 1 && value <= 8 {
+			return value
+		}
+		break
+	}
+	return 1
+}
+
+// ApplyOrientation поворачивает и отражает изображение согласно значению EXIF
+// Orientation, после чего ориентация в метаданных больше не нужна.
+func ApplyOrientation(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	src := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	// Значения 5–8 меняют ширину и высоту местами
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			sx, sy := orientedSource(orientation, x, y, width, height)
+			i, j := dst.PixOffset(x, y), src.PixOffset(sx, sy)
+			copy(dst.Pix[i:i+4], src.Pix[j:j+4])
+		}
+	}
+	return dst
+}
+
+// orientedSource возвращает координаты исходного пикселя размером width×height,
+// который после применения ориентации оказывается в точке (x, y).
+func orientedSource(orientation, x, y, width, height int) (int, int) {
+	switch orientation {
+	case 2: // Отражение по горизонтали
+		return width - 1 - x, y
+	case 3: // Поворот на 180°
+		return width - 1 - x, height - 1 - y
+	case 4: // Отражение по вертикали
+		return x, height - 1 - y
+	case 5: // Отражение относительно главной диагонали
+		return y, x
+	case 6: // Поворот на 90° по часовой стрелке
+		return y, height - 1 - x
+	case 7: // Отражение относительно побочной диагонали
+		return width - 1 - y, height - 1 - x
+	case 8: // Поворот на 90° против часовой стрелки
+		return width - 1 - y, x
+	}
+	return x, y
+}
+
+// DecodeOriented декодирует изображение и применяет к нему ориентацию из EXIF:
+// декодеры ее не учитывают, а уменьшенные копии и перекодированные файлы
+// сохраняются без метаданных.
+func DecodeOriented(data []byte) (image.Image, error) {
+	img, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return ApplyOrientation(img, Orientation(data)), nil
+}
@@ -0,0 +1,66 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	"golang.org/x/image/draw"
+)
+
+// jpegQuality — качество JPEG для вариантов изображения: заметно уменьшает размер
+// файла без видимых артефактов на фотографиях.
+const jpegQuality = 82
+
+// Decode декодирует изображение JPEG, PNG или WebP.
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedImage, err)
+	}
+	return img, nil
+}
+
+// ResizeToFit уменьшает изображение так, чтобы большая сторона не превышала maxSide,
+// сохраняя пропорции. Изображение меньше заданного размера не увеличивается.
+func ResizeToFit(src image.Image, maxSide int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSide && height <= maxSide {
+		return src
+	}
+
+	if width >= height {
+		height = max(height*maxSide/width, 1)
+		width = maxSide
+	} else {
+		width = max(width*maxSide/height, 1)
+		height = maxSide
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+	return dst
+}
+
+// EncodeJPEG кодирует изображение в JPEG. Прозрачные области (PNG, WebP)
+// заливаются белым: JPEG не поддерживает прозрачность.
+func EncodeJPEG(img image.Image) ([]byte, error) {
+	return encodeJPEG(img, jpegQuality)
+}
+
+// encodeJPEG кодирует изображение в JPEG с качеством quality.
+func encodeJPEG(img image.Image, quality int) ([]byte, error) {
+	bounds := img.Bounds()
+	flattened := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flattened, flattened.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flattened, flattened.Bounds(), img, bounds.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flattened, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode jpeg: %w", err)
+	}
+	return buf.Bytes(), nil
+}
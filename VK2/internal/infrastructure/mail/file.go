@@ -0,0 +1,57 @@
+// Package mail содержит реализации отправки писем пользователям.
+package mail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+// FileMailer сохраняет письма в каталог в виде .eml-файлов вместо отправки.
+// Используется при локальной разработке и в тестах: письмо можно открыть
+// почтовым клиентом или прочитать ссылку из него.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+var _ repository.Mailer = (*FileMailer)(nil)
+
+// NewFileMailer создает отправителя, сохраняющего письма в каталог dir.
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("не удалось создать каталог писем %s: %w", dir, err)
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+// Send записывает письмо в новый файл. Имя файла начинается с времени отправки,
+// поэтому письма в каталоге упорядочены по времени.
+func (m *FileMailer) Send(msg domain.EmailMessage) error {
+	data := formatMessage(m.from, msg, time.Now())
+
+	// Запись во временный файл с переименованием: читатель каталога не увидит недописанное письмо
+	tmp, err := os.CreateTemp(m.dir, ".mail-*")
+	if err != nil {
+		return fmt.Errorf("failed to create mail file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // Не срабатывает после успешного переименования
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+
+	name := fmt.Sprintf("%s_%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), filepath.Base(tmp.Name())[len(".mail-"):])
+	if err := os.Rename(tmp.Name(), filepath.Join(m.dir, name)); err != nil {
+		return fmt.Errorf("failed to store mail file: %w", err)
+	}
+	return nil
+}
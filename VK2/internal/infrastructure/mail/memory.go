@@ -0,0 +1,36 @@
+package mail
+
+import (
+	"sync"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+// MemoryMailer хранит отправленные письма в памяти. Предназначен для тестов:
+// письмо со ссылкой можно получить через Messages.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []domain.EmailMessage
+}
+
+var _ repository.Mailer = (*MemoryMailer)(nil)
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+// Send сохраняет письмо.
+func (m *MemoryMailer) Send(msg domain.EmailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages возвращает копию списка отправленных писем в порядке отправки.
+func (m *MemoryMailer) Messages() []domain.EmailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]domain.EmailMessage(nil), m.messages...)
+}
@@ -0,0 +1,31 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/mail"
+	"time"
+
+	"vk/internal/domain"
+)
+
+// formatMessage формирует письмо в формате RFC 5322 с текстом в UTF-8.
+func formatMessage(from string, msg domain.EmailMessage, date time.Time) []byte {
+	// Имя отправителя может быть не в ASCII и должно быть закодировано
+	if addr, err := mail.ParseAddress(from); err == nil {
+		from = addr.String()
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.Write(bytes.ReplaceAll([]byte(msg.Body), []byte("\n"), []byte("\r\n")))
+	return buf.Bytes()
+}
@@ -0,0 +1,113 @@
+package mail
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+// smtpTimeout ограничивает время на отправку одного письма, включая соединение.
+const smtpTimeout = 30 * time.Second
+
+// SMTPConfig содержит параметры почтового сервера.
+type SMTPConfig struct {
+	Host     string
+	Port     int // 587 или 25; соединение шифруется через STARTTLS, если сервер его поддерживает
+	Username string
+	Password string
+	From     string // Адрес отправителя, например "Маркетплейс <no-reply@example.com>"
+}
+
+// SMTPMailer отправляет письма через SMTP-сервер.
+type SMTPMailer struct {
+	addr     string
+	host     string
+	auth     smtp.Auth
+	from     string
+	envelope string // Адрес отправителя без имени для команды MAIL FROM
+}
+
+var _ repository.Mailer = (*SMTPMailer)(nil)
+
+// NewSMTPMailer проверяет параметры и создает отправителя.
+func NewSMTPMailer(cfg SMTPConfig) (*SMTPMailer, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("не задан адрес SMTP-сервера")
+	}
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		return nil, fmt.Errorf("некорректный порт SMTP-сервера: %d", cfg.Port)
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный адрес отправителя %q: %w", cfg.From, err)
+	}
+
+	m := &SMTPMailer{
+		addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		host:     cfg.Host,
+		from:     cfg.From,
+		envelope: from.Address,
+	}
+	if cfg.Username != "" {
+		// PlainAuth отказывается передавать пароль без TLS, если сервер не localhost
+		m.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return m, nil
+}
+
+// Send отправляет письмо одному получателю.
+func (m *SMTPMailer) Send(msg domain.EmailMessage) error {
+	conn, err := net.DialTimeout("tcp", m.addr, smtpTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+	if m.auth != nil {
+		if err := client.Auth(m.auth); err != nil {
+			return fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.envelope); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("smtp RCPT TO failed: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := w.Write(formatMessage(m.from, msg, time.Now())); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return client.Quit()
+}
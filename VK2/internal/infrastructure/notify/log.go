@@ -0,0 +1,26 @@
+// Package notify содержит реализации отправки уведомлений пользователям.
+package notify
+
+import (
+	"log"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+// LogNotifier записывает уведомления в журнал сервиса. Используется, пока не
+// подключен настоящий канал доставки (почта, push).
+type LogNotifier struct{}
+
+var _ repository.Notifier = LogNotifier{}
+
+func NewLogNotifier() LogNotifier {
+	return LogNotifier{}
+}
+
+// NotifyAdExpiring записывает в журнал напоминание об истечении срока объявления.
+func (LogNotifier) NotifyAdExpiring(ad *domain.Ad) error {
+	log.Printf("Уведомление пользователю %s: срок публикации объявления %s «%s» истекает %s.",
+		ad.UserID, ad.ID, ad.Title, ad.ExpiresAt.Format("2006-01-02 15:04 MST"))
+	return nil
+}
@@ -0,0 +1,270 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+type PGAdImageRepository struct {
+	db *sql.DB
+}
+
+func NewPGAdImageRepository(db *sql.DB) repository.AdImageRepository {
+	return &PGAdImageRepository{db: db}
+}
+
+// adImageColumns — список колонок изображения в порядке, ожидаемом adImageScanDest.
+const adImageColumns = `id, ad_id, storage_key, COALESCE(external_url, ''), content_type, position, is_cover, processing_status, processing_attempts, created_at`
+
+// adImageScanDest возвращает поля изображения для Scan в порядке adImageColumns.
+func adImageScanDest(image *domain.AdImage) []interface{} {
+	return []interface{}{&image.ID, &image.AdID, &image.StorageKey, &image.ExternalURL, &image.ContentType, &image.Position, &image.IsCover, &image.Status, &image.Attempts, &image.CreatedAt}
+}
+
+// AddImage реализует метод добавления изображения объявления для PostgreSQL.
+// Строка объявления блокируется до конца транзакции, поэтому одновременные
+// загрузки не получат одинаковую позицию, две обложки или лишнее изображение сверх лимита.
+func (r *PGAdImageRepository) AddImage(image *domain.AdImage, maxImages int) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var adID string
+	if err := tx.QueryRow(`SELECT id FROM ads WHERE id = $1 FOR UPDATE`, image.AdID).Scan(&adID); err != nil {
+		return false, fmt.Errorf("failed to lock ad in postgres: %w", err)
+	}
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM ad_images WHERE ad_id = $1`, image.AdID).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to count ad images in postgres: %w", err)
+	}
+	if count >= maxImages {
+		return false, nil
+	}
+
+	query := `
+		INSERT INTO ad_images (id, ad_id, storage_key, content_type, position, is_cover, created_at)
+		SELECT $1, $2, $3, $4, COALESCE(MAX(position) + 1, 0), COUNT(*) = 0, $5
+		FROM ad_images WHERE ad_id = $2
+		RETURNING position, is_cover, processing_status`
+	err = tx.QueryRow(query, image.ID, image.AdID, image.StorageKey, image.ContentType, image.CreatedAt).Scan(&image.Position, &image.IsCover, &image.Status)
+	if err != nil {
+		return false, fmt.Errorf("failed to add ad image in postgres: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// ListImages реализует метод получения изображений объявления для PostgreSQL.
+func (r *PGAdImageRepository) ListImages(adID string) ([]domain.AdImage, error) {
+	query := `SELECT ` + adImageColumns + ` FROM ad_images WHERE ad_id = $1 ORDER BY position, created_at`
+	return r.queryImages(query, adID)
+}
+
+// ListCoverImages реализует метод получения обложек объявлений для PostgreSQL.
+func (r *PGAdImageRepository) ListCoverImages(adIDs []string) ([]domain.AdImage, error) {
+	if len(adIDs) == 0 {
+		return nil, nil
+	}
+	query := `SELECT ` + adImageColumns + ` FROM ad_images WHERE ad_id = ANY($1::uuid[]) AND is_cover`
+	return r.queryImages(query, pq.Array(adIDs))
+}
+
+func (r *PGAdImageRepository) queryImages(query string, args ...interface{}) ([]domain.AdImage, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ad images from postgres: %w", err)
+	}
+	defer rows.Close()
+
+	var images []domain.AdImage
+	for rows.Next() {
+		image := domain.AdImage{}
+		if err := rows.Scan(adImageScanDest(&image)...); err != nil {
+			return nil, fmt.Errorf("failed to scan ad image row: %w", err)
+		}
+		images = append(images, image)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return images, nil
+}
+
+// DeleteImage реализует метод удаления изображения объявления для PostgreSQL.
+func (r *PGAdImageRepository) DeleteImage(adID, imageID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var wasCover bool
+	err = tx.QueryRow(`DELETE FROM ad_images WHERE ad_id = $1 AND id = $2 RETURNING is_cover`, adID, imageID).Scan(&wasCover)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete ad image from postgres: %w", err)
+	}
+
+	if wasCover {
+		query := `UPDATE ad_images SET is_cover = TRUE WHERE id = (
+			SELECT id FROM ad_images WHERE ad_id = $1 ORDER BY position, created_at LIMIT 1)`
+		if _, err := tx.Exec(query, adID); err != nil {
+			return fmt.Errorf("failed to assign ad cover image in postgres: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ReorderImages реализует метод изменения порядка изображений объявления для PostgreSQL.
+func (r *PGAdImageRepository) ReorderImages(adID string, imageIDs []string, coverID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Сначала снимаем признак обложки: иначе уникальный индекс нарушится,
+	// пока старая и новая обложки отмечены одновременно
+	if _, err := tx.Exec(`UPDATE ad_images SET is_cover = FALSE WHERE ad_id = $1 AND is_cover`, adID); err != nil {
+		return fmt.Errorf("failed to reset ad cover image in postgres: %w", err)
+	}
+	for position, imageID := range imageIDs {
+		query := `UPDATE ad_images SET position = $3, is_cover = $4 WHERE ad_id = $1 AND id = $2`
+		if _, err := tx.Exec(query, adID, imageID, position, imageID == coverID); err != nil {
+			return fmt.Errorf("failed to reorder ad images in postgres: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListImageVariants реализует метод получения вариантов изображений для PostgreSQL.
+func (r *PGAdImageRepository) ListImageVariants(imageIDs []string) ([]domain.AdImageVariant, error) {
+	if len(imageIDs) == 0 {
+		return nil, nil
+	}
+	query := `SELECT image_id, variant, storage_key, content_type, width, height
+		FROM ad_image_variants WHERE image_id = ANY($1::uuid[]) ORDER BY width`
+	rows, err := r.db.Query(query, pq.Array(imageIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ad image variants from postgres: %w", err)
+	}
+	defer rows.Close()
+
+	var variants []domain.AdImageVariant
+	for rows.Next() {
+		variant := domain.AdImageVariant{}
+		if err := rows.Scan(&variant.ImageID, &variant.Name, &variant.StorageKey, &variant.ContentType, &variant.Width, &variant.Height); err != nil {
+			return nil, fmt.Errorf("failed to scan ad image variant row: %w", err)
+		}
+		variants = append(variants, variant)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return variants, nil
+}
+
+// ClaimNextImage реализует метод получения изображения для обработки для PostgreSQL.
+// SKIP LOCKED позволяет нескольким обработчикам забирать разные изображения.
+func (r *PGAdImageRepository) ClaimNextImage(now, staleBefore time.Time, maxAttempts int) (*domain.AdImage, error) {
+	image := &domain.AdImage{}
+	query := `
+		UPDATE ad_images SET
+			processing_status = 'processing',
+			processing_started_at = $1,
+			processing_attempts = processing_attempts + 1
+		WHERE id = (
+			SELECT id FROM ad_images
+			WHERE (processing_status = 'pending'
+				OR (processing_status = 'processing' AND processing_started_at < $2))
+				AND processing_attempts < $3
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED)
+		RETURNING ` + adImageColumns
+	err := r.db.QueryRow(query, now, staleBefore, maxAttempts).Scan(adImageScanDest(image)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim ad image in postgres: %w", err)
+	}
+	return image, nil
+}
+
+// FailStaleImages реализует метод отметки зависших изображений для PostgreSQL.
+func (r *PGAdImageRepository) FailStaleImages(staleBefore time.Time, maxAttempts int) (int, error) {
+	query := `
+		UPDATE ad_images SET processing_status = 'failed'
+		WHERE processing_status = 'processing' AND processing_started_at < $1 AND processing_attempts >= $2`
+	result, err := r.db.Exec(query, staleBefore, maxAttempts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail stale ad images in postgres: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return int(affected), nil
+}
+
+// SaveImageVariants реализует метод сохранения вариантов изображения для PostgreSQL.
+func (r *PGAdImageRepository) SaveImageVariants(imageID string, variants []domain.AdImageVariant) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM ad_image_variants WHERE image_id = $1`, imageID); err != nil {
+		return fmt.Errorf("failed to delete ad image variants from postgres: %w", err)
+	}
+	for _, variant := range variants {
+		query := `INSERT INTO ad_image_variants (image_id, variant, storage_key, content_type, width, height) VALUES ($1, $2, $3, $4, $5, $6)`
+		if _, err := tx.Exec(query, imageID, variant.Name, variant.StorageKey, variant.ContentType, variant.Width, variant.Height); err != nil {
+			return fmt.Errorf("failed to save ad image variant in postgres: %w", err)
+		}
+	}
+	if _, err := tx.Exec(`UPDATE ad_images SET processing_status = 'ready' WHERE id = $1`, imageID); err != nil {
+		return fmt.Errorf("failed to update ad image status in postgres: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SetImageStatus реализует метод изменения статуса обработки изображения для PostgreSQL.
+func (r *PGAdImageRepository) SetImageStatus(imageID, status string) error {
+	if _, err := r.db.Exec(`UPDATE ad_images SET processing_status = $2 WHERE id = $1`, imageID, status); err != nil {
+		return fmt.Errorf("failed to update ad image status in postgres: %w", err)
+	}
+	return nil
+}
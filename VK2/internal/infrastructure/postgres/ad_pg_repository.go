@@ -3,8 +3,12 @@ package postgres
 import (
 	"database/sql"
 	"fmt"
+	"html"
 	"log"
 	"strings"
+	"time"
+
+	"github.com/lib/pq"
 
 	"vk/internal/adapter/repository"
 	"vk/internal/domain"
@@ -20,8 +24,8 @@ func NewPGAdRepository(db *sql.DB) repository.AdRepository {
 
 // CreateAd реализует метод создания объявления для PostgreSQL.
 func (r *PGAdRepository) CreateAd(ad *domain.Ad) error {
-	query := `INSERT INTO ads (id, user_id, title, description, image_url, price, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := r.db.Exec(query, ad.ID, ad.UserID, ad.Title, ad.Description, ad.ImageURL, ad.Price, ad.CreatedAt)
+	query := `INSERT INTO ads (id, user_id, category_id, title, description, price, currency, status, created_at, expires_at, publish_at, published_at) VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	_, err := r.db.Exec(query, ad.ID, ad.UserID, ad.CategoryID, ad.Title, ad.Description, ad.Price.Amount, ad.Price.Currency, ad.Status, ad.CreatedAt, ad.ExpiresAt, ad.PublishAt, ad.PublishedAt)
 	if err != nil {
 		log.Printf("Error creating ad in postgres: %v", err)
 		return fmt.Errorf("failed to create ad in postgres: %w", err)
@@ -32,8 +36,8 @@ func (r *PGAdRepository) CreateAd(ad *domain.Ad) error {
 // GetAdByID реализует метод получения объявления по ID для PostgreSQL.
 func (r *PGAdRepository) GetAdByID(id string) (*domain.Ad, error) {
 	ad := &domain.Ad{}
-	query := `SELECT id, user_id, title, description, image_url, price, created_at FROM ads WHERE id = $1`
-	err := r.db.QueryRow(query, id).Scan(&ad.ID, &ad.UserID, &ad.Title, &ad.Description, &ad.ImageURL, &ad.Price, &ad.CreatedAt)
+	query := `SELECT ` + adColumns + ` FROM ads WHERE id = $1`
+	err := r.db.QueryRow(query, id).Scan(adScanDest(ad)...)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -43,97 +47,353 @@ func (r *PGAdRepository) GetAdByID(id string) (*domain.Ad, error) {
 	return ad, nil
 }
 
-// ListAds реализует метод получения списка объявлений с пагинацией, сортировкой и фильтрацией для PostgreSQL.
-func (r *PGAdRepository) ListAds(offset, limit int, sortBy, sortOrder string, minPrice, maxPrice float64) ([]domain.Ad, error) {
-	var ads []domain.Ad
-	args := []interface{}{}
-	whereClauses := []string{}
-	argCounter := 1
+// UpdateAd реализует метод обновления объявления для PostgreSQL.
+func (r *PGAdRepository) UpdateAd(ad *domain.Ad) error {
+	query := `UPDATE ads SET category_id = NULLIF($2, '')::uuid, title = $3, description = $4, price = $5, currency = $6 WHERE id = $1`
+	_, err := r.db.Exec(query, ad.ID, ad.CategoryID, ad.Title, ad.Description, ad.Price.Amount, ad.Price.Currency)
+	if err != nil {
+		return fmt.Errorf("failed to update ad in postgres: %w", err)
+	}
+	return nil
+}
 
-	if minPrice > 0 {
-		whereClauses = append(whereClauses, fmt.Sprintf("price >= $%d", argCounter))
-		args = append(args, minPrice)
-		argCounter++
+// UpdateAdStatus реализует метод изменения статуса объявления для PostgreSQL.
+func (r *PGAdRepository) UpdateAdStatus(id, from, to string, expiresAt time.Time, publishedAt *time.Time) (bool, error) {
+	query := `
+		UPDATE ads SET
+			status = $3,
+			expires_at = $4,
+			expiry_reminded_at = CASE WHEN expires_at = $4 THEN expiry_reminded_at END,
+			publish_at = NULL,
+			published_at = COALESCE($5, published_at)
+		WHERE id = $1 AND status = $2`
+	result, err := r.db.Exec(query, id, from, to, expiresAt, publishedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to update ad status in postgres: %w", err)
 	}
-	if maxPrice > 0 && maxPrice >= minPrice {
-		whereClauses = append(whereClauses, fmt.Sprintf("price <= $%d", argCounter))
-		args = append(args, maxPrice)
-		argCounter++
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update ad status in postgres: %w", err)
 	}
+	return rows > 0, nil
+}
 
-	whereClause := ""
-	if len(whereClauses) > 0 {
-		whereClause = " WHERE " + strings.Join(whereClauses, " AND ")
+// PublishScheduledAds реализует метод публикации запланированных объявлений для PostgreSQL.
+func (r *PGAdRepository) PublishScheduledAds(now time.Time, limit int) (int, error) {
+	query := `
+		UPDATE ads SET status = 'published', publish_at = NULL, published_at = $1
+		WHERE id IN (
+			SELECT id FROM ads
+			WHERE status = 'scheduled' AND publish_at <= $1
+			ORDER BY publish_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED)`
+	result, err := r.db.Exec(query, now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to publish scheduled ads in postgres: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to publish scheduled ads in postgres: %w", err)
+	}
+	return int(rows), nil
+}
+
+// ArchiveExpiredAds реализует метод архивации истекших объявлений для PostgreSQL.
+func (r *PGAdRepository) ArchiveExpiredAds(now time.Time, limit int) (int, error) {
+	query := `
+		UPDATE ads SET status = 'archived'
+		WHERE id IN (
+			SELECT id FROM ads
+			WHERE status IN ('published', 'reserved') AND expires_at <= $1
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED)`
+	result, err := r.db.Exec(query, now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive expired ads in postgres: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive expired ads in postgres: %w", err)
 	}
+	return int(rows), nil
+}
 
-	orderByClause := " ORDER BY created_at DESC"
-	if sortBy != "" {
-		switch sortBy {
-		case "created_at":
-			orderByClause = " ORDER BY created_at"
-		case "price":
-			orderByClause = " ORDER BY price"
-		default:
+// ClaimExpiringAds реализует метод выбора объявлений для напоминания об истечении срока для PostgreSQL.
+func (r *PGAdRepository) ClaimExpiringAds(before, now time.Time, limit int) ([]domain.Ad, error) {
+	query := `
+		UPDATE ads SET expiry_reminded_at = $2
+		WHERE id IN (
+			SELECT id FROM ads
+			WHERE status IN ('published', 'reserved') AND expires_at <= $1 AND expiry_reminded_at IS NULL
+			ORDER BY expires_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED)
+		RETURNING ` + adColumns
+	rows, err := r.db.Query(query, before, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim expiring ads in postgres: %w", err)
+	}
+	defer rows.Close()
 
+	var ads []domain.Ad
+	for rows.Next() {
+		ad := domain.Ad{}
+		if err := rows.Scan(adScanDest(&ad)...); err != nil {
+			return nil, fmt.Errorf("failed to scan ad row: %w", err)
 		}
+		ads = append(ads, ad)
 	}
 
-	if sortOrder != "" {
-		if strings.ToUpper(sortOrder) == "ASC" {
-			orderByClause += " ASC"
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return ads, nil
+}
+
+// DeleteAd реализует метод удаления объявления для PostgreSQL.
+func (r *PGAdRepository) DeleteAd(id string) error {
+	query := `DELETE FROM ads WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete ad from postgres: %w", err)
+	}
+	return nil
+}
+
+// ListAds реализует метод получения списка объявлений с пагинацией, сортировкой и фильтрацией для PostgreSQL.
+func (r *PGAdRepository) ListAds(filter repository.AdFilter, page repository.AdPageRequest) ([]domain.Ad, *repository.AdCursor, error) {
+	var ads []domain.Ad
+	whereClause, args, tsQuery := buildAdWhereClause(filter)
+	argCounter := len(args) + 1
+
+	// Выражение сортировки и SQL-тип его значения для восстановления из курсора
+	sortExpr, sortType := "created_at", "timestamptz"
+	switch page.SortBy {
+	case repository.AdSortPublishedAt:
+		// Неопубликованные объявления (в списке своих объявлений) сортируются по
+		// моменту создания, чтобы в keyset-пагинации не было NULL
+		sortExpr = publishedAtSortExpr
+	case repository.AdSortPrice:
+		// Объявления в валюте без курса не имеют цены в базовой валюте и
+		// показываются в конце списка при любом направлении сортировки
+		missingPrice := missingPriceAsc
+		if page.SortDesc {
+			missingPrice = missingPriceDesc
+		}
+		sortExpr, sortType = fmt.Sprintf("COALESCE(%s, %s)", basePriceExpr, missingPrice), "numeric"
+	case repository.AdSortRelevance:
+		if tsQuery != "" {
+			sortExpr, sortType = fmt.Sprintf("ts_rank_cd(search_vector, %s)", tsQuery), "real"
+		}
+	}
+
+	direction, comparison := "ASC", ">"
+	if page.SortDesc {
+		direction, comparison = "DESC", "<"
+	}
+	// id — устойчивый дополнительный ключ для объявлений с равным значением сортировки
+	orderByClause := fmt.Sprintf(" ORDER BY %[1]s %[2]s, id %[2]s", sortExpr, direction)
+
+	paginationClause := ""
+	if page.After != nil {
+		// Keyset-пагинация: сравнение кортежей согласовано с направлением сортировки
+		condition := fmt.Sprintf("(%s, id) %s ($%d::%s, $%d::uuid)", sortExpr, comparison, argCounter, sortType, argCounter+1)
+		if whereClause == "" {
+			whereClause = " WHERE " + condition
 		} else {
-			orderByClause += " DESC"
+			whereClause += " AND " + condition
 		}
+		args = append(args, page.After.SortValue, page.After.ID)
+		argCounter += 2
+	} else {
+		paginationClause = fmt.Sprintf("OFFSET $%d", argCounter)
+		args = append(args, page.Offset)
+		argCounter++
+	}
+
+	columns := adColumns + fmt.Sprintf(", (%s)::text", sortExpr)
+	if tsQuery != "" {
+		// ts_headline дорогая функция, поэтому PostgreSQL вычисляет ее уже после
+		// сортировки и LIMIT, то есть только для строк текущей страницы
+		columns += fmt.Sprintf(`,
+			ts_headline('russian', title, %[1]s, $%[2]d),
+			ts_headline('russian', COALESCE(description, ''), %[1]s, $%[2]d)`, tsQuery, argCounter)
+		args = append(args, headlineOptions)
+		argCounter++
 	}
 
+	// Запрашиваем на одну строку больше, чтобы узнать, есть ли следующая страница
 	query := fmt.Sprintf(`
-		SELECT id, user_id, title, description, image_url, price, created_at
-		FROM ads
+		SELECT %s
+		FROM %s
 		%s
 		%s
-		OFFSET $%d LIMIT $%d`,
+		%s LIMIT $%d`,
+		columns,
+		adsWithRatesFrom,
 		whereClause,
 		orderByClause,
+		paginationClause,
 		argCounter,
-		argCounter+1,
 	)
-	args = append(args, offset, limit)
+	args = append(args, page.Limit+1)
 
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list ads from postgres: %w", err)
+		return nil, nil, fmt.Errorf("failed to list ads from postgres: %w", err)
 	}
 	defer rows.Close()
 
+	var sortValues []string
 	for rows.Next() {
 		ad := domain.Ad{}
-		if err := rows.Scan(&ad.ID, &ad.UserID, &ad.Title, &ad.Description, &ad.ImageURL, &ad.Price, &ad.CreatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan ad row: %w", err)
+		var sortValue, titleHeadline, descriptionHeadline string
+		dest := append(adScanDest(&ad), &sortValue)
+		if tsQuery != "" {
+			dest = append(dest, &titleHeadline, &descriptionHeadline)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan ad row: %w", err)
+		}
+		if tsQuery != "" {
+			ad.Highlight = &domain.AdHighlight{
+				Title:       formatHeadline(titleHeadline),
+				Description: formatHeadline(descriptionHeadline),
+			}
 		}
 		ads = append(ads, ad)
+		sortValues = append(sortValues, sortValue)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error during rows iteration: %w", err)
+		return nil, nil, fmt.Errorf("error during rows iteration: %w", err)
 	}
 
-	return ads, nil
+	var next *repository.AdCursor
+	if len(ads) > page.Limit {
+		ads = ads[:page.Limit]
+		last := len(ads) - 1
+		next = &repository.AdCursor{SortValue: sortValues[last], ID: ads[last].ID}
+	}
+
+	return ads, next, nil
 }
 
 // CountAds реализует метод подсчета объявлений с учетом фильтрации для PostgreSQL.
-func (r *PGAdRepository) CountAds(minPrice, maxPrice float64) (int, error) {
+func (r *PGAdRepository) CountAds(filter repository.AdFilter) (int, error) {
+	whereClause, args, _ := buildAdWhereClause(filter)
+
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM %s %s`, adsWithRatesFrom, whereClause)
+
+	var count int
+	err := r.db.QueryRow(query, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count ads from postgres: %w", err)
+	}
+	return count, nil
+}
+
+// adsWithRatesFrom — объявления вместе с курсом их валюты. Колонки курса
+// переименованы, чтобы не конфликтовать с колонками объявления.
+const adsWithRatesFrom = `ads LEFT JOIN (SELECT currency AS rate_currency, rate AS base_rate FROM exchange_rates) rates ON rates.rate_currency = ads.currency`
+
+// basePriceExpr — цена объявления в базовой валюте по текущему курсу. Умножение
+// NUMERIC выполняется точно, поэтому сравнение с границами фильтра не округляется.
+// Если курс валюты не задан, выражение равно NULL: такие объявления не проходят
+// фильтры по цене.
+const basePriceExpr = `price * rates.base_rate`
+
+// Значения сортировки по цене для объявлений в валюте без курса. missingPriceAsc
+// больше любой цены (NUMERIC(10, 2) * NUMERIC(12, 6) < 10^14), missingPriceDesc
+// меньше любой цены, поэтому такие объявления всегда оказываются в конце, а
+// keyset-пагинация не сталкивается с NULL.
+const (
+	missingPriceAsc  = "1000000000000000"
+	missingPriceDesc = "-1"
+)
+
+// publishedAtSortExpr — выражение сортировки по моменту публикации; совпадает
+// с выражением индекса idx_ads_published_published_at.
+const publishedAtSortExpr = `COALESCE(published_at, created_at)`
+
+// adColumns — список колонок объявления в порядке, ожидаемом adScanDest.
+const adColumns = `id, user_id, COALESCE(category_id::text, ''), title, description, price, currency, status, created_at, expires_at, publish_at, published_at`
+
+// adScanDest возвращает поля объявления для Scan в порядке adColumns.
+func adScanDest(ad *domain.Ad) []interface{} {
+	return []interface{}{&ad.ID, &ad.UserID, &ad.CategoryID, &ad.Title, &ad.Description, &ad.Price.Amount, &ad.Price.Currency, &ad.Status, &ad.CreatedAt, &ad.ExpiresAt, &ad.PublishAt, &ad.PublishedAt}
+}
+
+// Маркеры начала и конца совпадения в ts_headline. Символы из области частного
+// использования Unicode не встречаются в тексте объявлений, поэтому после
+// экранирования HTML их можно безопасно заменить на теги <mark>.
+const (
+	headlineStartSel = "\uE000"
+	headlineStopSel  = "\uE001"
+)
+
+var headlineOptions = fmt.Sprintf(`StartSel="%s", StopSel="%s", MaxWords=35, MinWords=15, MaxFragments=2`, headlineStartSel, headlineStopSel)
+
+var headlineReplacer = strings.NewReplacer(headlineStartSel, "<mark>", headlineStopSel, "</mark>")
+
+// formatHeadline экранирует текст фрагмента и выделяет совпадения тегами <mark>.
+func formatHeadline(headline string) string {
+	return headlineReplacer.Replace(html.EscapeString(headline))
+}
+
+// buildAdWhereClause формирует условие WHERE и его аргументы для фильтра объявлений.
+// Если задан поисковый запрос, также возвращает SQL-выражение tsquery для ранжирования.
+func buildAdWhereClause(filter repository.AdFilter) (string, []interface{}, string) {
 	args := []interface{}{}
 	whereClauses := []string{}
 	argCounter := 1
 
-	if minPrice > 0 {
-		whereClauses = append(whereClauses, fmt.Sprintf("price >= $%d", argCounter))
-		args = append(args, minPrice)
+	// Границы передаются десятичной строкой (domain.Amount реализует driver.Valuer)
+	// и вместе с ценами объявлений пересчитываются в базовую валюту точно, без округления
+	priceBound := func(operator string, bound domain.Amount) {
+		whereClauses = append(whereClauses, fmt.Sprintf(
+			"%s %s $%d::numeric * (SELECT rate FROM exchange_rates WHERE currency = $%d)",
+			basePriceExpr, operator, argCounter, argCounter+1))
+		args = append(args, bound, filter.PriceCurrency)
+		argCounter += 2
+	}
+	if filter.MinPrice > 0 {
+		priceBound(">=", filter.MinPrice)
+	}
+	if filter.MaxPrice > 0 && filter.MaxPrice >= filter.MinPrice {
+		priceBound("<=", filter.MaxPrice)
+	}
+	if len(filter.Statuses) > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("status = ANY($%d)", argCounter))
+		args = append(args, pq.Array(filter.Statuses))
+		argCounter++
+	}
+	if filter.UserID != "" {
+		whereClauses = append(whereClauses, fmt.Sprintf("user_id = $%d", argCounter))
+		args = append(args, filter.UserID)
 		argCounter++
 	}
-	if maxPrice > 0 && maxPrice >= minPrice {
-		whereClauses = append(whereClauses, fmt.Sprintf("price <= $%d", argCounter))
-		args = append(args, maxPrice)
+	if filter.CategoryID != "" {
+		// Категория вместе со всеми вложенными подкатегориями; UNION завершает
+		// обход, даже если в дереве окажется цикл
+		whereClauses = append(whereClauses, fmt.Sprintf(`category_id IN (
+			WITH RECURSIVE subtree AS (
+				SELECT id FROM categories WHERE id = $%d
+				UNION
+				SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+			)
+			SELECT id FROM subtree)`, argCounter))
+		args = append(args, filter.CategoryID)
+		argCounter++
+	}
+	tsQuery := ""
+	if filter.Query != "" {
+		// Полнотекстовый поиск по сгенерированной колонке search_vector (GIN-индекс)
+		tsQuery = fmt.Sprintf("websearch_to_tsquery('russian', $%d)", argCounter)
+		whereClauses = append(whereClauses, "search_vector @@ "+tsQuery)
+		args = append(args, filter.Query)
 		argCounter++
 	}
 
@@ -141,13 +401,5 @@ func (r *PGAdRepository) CountAds(minPrice, maxPrice float64) (int, error) {
 	if len(whereClauses) > 0 {
 		whereClause = " WHERE " + strings.Join(whereClauses, " AND ")
 	}
-
-	query := fmt.Sprintf(`SELECT COUNT(*) FROM ads %s`, whereClause)
-
-	var count int
-	err := r.db.QueryRow(query, args...).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("failed to count ads from postgres: %w", err)
-	}
-	return count, nil
+	return whereClause, args, tsQuery
 }
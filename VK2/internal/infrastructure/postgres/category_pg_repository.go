@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+type PGCategoryRepository struct {
+	db *sql.DB
+}
+
+func NewPGCategoryRepository(db *sql.DB) repository.CategoryRepository {
+	return &PGCategoryRepository{db: db}
+}
+
+// CreateCategory реализует метод создания категории для PostgreSQL.
+func (r *PGCategoryRepository) CreateCategory(category *domain.Category) error {
+	query := `INSERT INTO categories (id, parent_id, name, created_at) VALUES ($1, $2, $3, $4)`
+	_, err := r.db.Exec(query, category.ID, category.ParentID, category.Name, category.CreatedAt)
+	if err != nil {
+		if _, ok := uniqueViolation(err); ok {
+			return repository.ErrCategoryNameTaken
+		}
+		return fmt.Errorf("failed to create category in postgres: %w", err)
+	}
+	return nil
+}
+
+// GetCategoryByID реализует метод получения категории по ID для PostgreSQL.
+func (r *PGCategoryRepository) GetCategoryByID(id string) (*domain.Category, error) {
+	category := &domain.Category{}
+	query := `SELECT id, parent_id, name, created_at FROM categories WHERE id = $1`
+	err := r.db.QueryRow(query, id).Scan(&category.ID, &category.ParentID, &category.Name, &category.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // Категория не найдена
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category by ID from postgres: %w", err)
+	}
+	return category, nil
+}
+
+// ListCategories реализует метод получения всех категорий для PostgreSQL.
+func (r *PGCategoryRepository) ListCategories() ([]domain.Category, error) {
+	query := `SELECT id, parent_id, name, created_at FROM categories ORDER BY name`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories from postgres: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []domain.Category
+	for rows.Next() {
+		category := domain.Category{}
+		if err := rows.Scan(&category.ID, &category.ParentID, &category.Name, &category.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan category row: %w", err)
+		}
+		categories = append(categories, category)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return categories, nil
+}
+
+// UpdateCategory реализует метод обновления категории для PostgreSQL.
+// Изменения дерева выполняются по одному под блокировкой таблицы (чтение она не
+// блокирует), поэтому два встречных переноса не пройдут проверку одновременно.
+func (r *PGCategoryRepository) UpdateCategory(category *domain.Category) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return fmt.Errorf("failed to lock categories in postgres: %w", err)
+	}
+
+	if category.ParentID != nil {
+		// Поднимаемся от нового родителя к корню: встретив саму категорию, получим цикл.
+		// UNION вместо UNION ALL завершает обход, даже если цикл уже есть в данных
+		query := `
+			WITH RECURSIVE ancestors AS (
+				SELECT id, parent_id FROM categories WHERE id = $1
+				UNION
+				SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+			)
+			SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)`
+		var cycle bool
+		if err := tx.QueryRow(query, *category.ParentID, category.ID).Scan(&cycle); err != nil {
+			return fmt.Errorf("failed to check category ancestors in postgres: %w", err)
+		}
+		if cycle {
+			return repository.ErrCategoryCycle
+		}
+	}
+
+	query := `UPDATE categories SET parent_id = $2, name = $3 WHERE id = $1`
+	if _, err := tx.Exec(query, category.ID, category.ParentID, category.Name); err != nil {
+		if _, ok := uniqueViolation(err); ok {
+			return repository.ErrCategoryNameTaken
+		}
+		return fmt.Errorf("failed to update category in postgres: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// DeleteCategory реализует метод удаления категории для PostgreSQL.
+func (r *PGCategoryRepository) DeleteCategory(id string) error {
+	query := `DELETE FROM categories WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	if err != nil {
+		// Подкатегория или объявление могли появиться после проверки IsCategoryInUse
+		if foreignKeyViolation(err) {
+			return repository.ErrCategoryInUse
+		}
+		return fmt.Errorf("failed to delete category from postgres: %w", err)
+	}
+	return nil
+}
+
+// IsCategoryInUse реализует метод проверки использования категории для PostgreSQL.
+func (r *PGCategoryRepository) IsCategoryInUse(id string) (bool, error) {
+	var inUse bool
+	query := `SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1) OR EXISTS (SELECT 1 FROM ads WHERE category_id = $1)`
+	if err := r.db.QueryRow(query, id).Scan(&inUse); err != nil {
+		return false, fmt.Errorf("failed to check category usage in postgres: %w", err)
+	}
+	return inUse, nil
+}
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+type PGEmailOutboxRepository struct {
+	db *sql.DB
+}
+
+func NewPGEmailOutboxRepository(db *sql.DB) repository.EmailOutboxRepository {
+	return &PGEmailOutboxRepository{db: db}
+}
+
+// EnqueueEmail реализует метод постановки письма в очередь для PostgreSQL.
+func (r *PGEmailOutboxRepository) EnqueueEmail(email *domain.OutboxEmail) error {
+	query := `
+		INSERT INTO email_outbox (id, recipient, subject, body, attempts, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.db.Exec(query, email.ID, email.Message.To, email.Message.Subject, email.Message.Body,
+		email.Attempts, email.NextAttemptAt, email.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue email in postgres: %w", err)
+	}
+	return nil
+}
+
+// ClaimNextEmail реализует метод получения письма из очереди для PostgreSQL.
+// SKIP LOCKED позволяет нескольким экземплярам сервиса разбирать очередь параллельно.
+func (r *PGEmailOutboxRepository) ClaimNextEmail(now, retryAt time.Time) (*domain.OutboxEmail, error) {
+	email := &domain.OutboxEmail{}
+	query := `
+		UPDATE email_outbox SET attempts = attempts + 1, next_attempt_at = $2
+		WHERE id = (
+			SELECT id FROM email_outbox
+			WHERE next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED)
+		RETURNING id, recipient, subject, body, attempts, next_attempt_at, created_at`
+	err := r.db.QueryRow(query, now, retryAt).Scan(&email.ID, &email.Message.To, &email.Message.Subject,
+		&email.Message.Body, &email.Attempts, &email.NextAttemptAt, &email.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // Писем к отправке нет
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim email in postgres: %w", err)
+	}
+	return email, nil
+}
+
+// RescheduleEmail реализует метод переноса попытки отправки письма для PostgreSQL.
+func (r *PGEmailOutboxRepository) RescheduleEmail(id string, nextAttemptAt time.Time) error {
+	query := `UPDATE email_outbox SET next_attempt_at = $2 WHERE id = $1`
+	if _, err := r.db.Exec(query, id, nextAttemptAt); err != nil {
+		return fmt.Errorf("failed to reschedule email in postgres: %w", err)
+	}
+	return nil
+}
+
+// DeleteEmail реализует метод удаления письма из очереди для PostgreSQL.
+func (r *PGEmailOutboxRepository) DeleteEmail(id string) error {
+	query := `DELETE FROM email_outbox WHERE id = $1`
+	if _, err := r.db.Exec(query, id); err != nil {
+		return fmt.Errorf("failed to delete email in postgres: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+type PGEmailVerificationRepository struct {
+	db *sql.DB
+}
+
+func NewPGEmailVerificationRepository(db *sql.DB) repository.EmailVerificationRepository {
+	return &PGEmailVerificationRepository{db: db}
+}
+
+// CreateEmailVerificationToken реализует метод сохранения токена подтверждения почты для PostgreSQL.
+func (r *PGEmailVerificationRepository) CreateEmailVerificationToken(token *domain.EmailVerificationToken) error {
+	query := `INSERT INTO email_verification_tokens (id, user_id, email, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := r.db.Exec(query, token.ID, token.UserID, token.Email, token.TokenHash, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create email verification token in postgres: %w", err)
+	}
+	return nil
+}
+
+// GetEmailVerificationTokenByHash реализует метод получения токена подтверждения почты по хешу для PostgreSQL.
+func (r *PGEmailVerificationRepository) GetEmailVerificationTokenByHash(tokenHash string) (*domain.EmailVerificationToken, error) {
+	token := &domain.EmailVerificationToken{}
+	query := `SELECT id, user_id, email, token_hash, expires_at, created_at, used_at FROM email_verification_tokens WHERE token_hash = $1`
+	err := r.db.QueryRow(query, tokenHash).Scan(&token.ID, &token.UserID, &token.Email, &token.TokenHash, &token.ExpiresAt, &token.CreatedAt, &token.UsedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // Токен не найден
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email verification token by hash from postgres: %w", err)
+	}
+	return token, nil
+}
+
+// MarkEmailVerificationTokenUsed реализует метод пометки токена подтверждения почты использованным для PostgreSQL.
+// Условие в WHERE гарантирует, что из двух конкурентных обновлений успешным будет только одно.
+func (r *PGEmailVerificationRepository) MarkEmailVerificationTokenUsed(id string, usedAt time.Time) (bool, error) {
+	query := `UPDATE email_verification_tokens SET used_at = $2 WHERE id = $1 AND used_at IS NULL`
+	res, err := r.db.Exec(query, id, usedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark email verification token used in postgres: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// LastEmailVerificationSentAt реализует метод получения времени последней отправки письма подтверждения для PostgreSQL.
+func (r *PGEmailVerificationRepository) LastEmailVerificationSentAt(userID string) (*time.Time, error) {
+	var sentAt *time.Time
+	query := `SELECT max(created_at) FROM email_verification_tokens WHERE user_id = $1`
+	if err := r.db.QueryRow(query, userID).Scan(&sentAt); err != nil {
+		return nil, fmt.Errorf("failed to get last email verification time from postgres: %w", err)
+	}
+	return sentAt, nil
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+type PGExchangeRateRepository struct {
+	db *sql.DB
+}
+
+func NewPGExchangeRateRepository(db *sql.DB) repository.ExchangeRateRepository {
+	return &PGExchangeRateRepository{db: db}
+}
+
+// ListRates реализует метод получения всех курсов валют для PostgreSQL.
+func (r *PGExchangeRateRepository) ListRates() ([]domain.ExchangeRate, error) {
+	query := `SELECT currency, rate, updated_at FROM exchange_rates ORDER BY currency`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates from postgres: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []domain.ExchangeRate
+	for rows.Next() {
+		rate := domain.ExchangeRate{}
+		if err := rows.Scan(&rate.Currency, &rate.Rate, &rate.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange rate row: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return rates, nil
+}
+
+// GetRate реализует метод получения курса валюты для PostgreSQL.
+func (r *PGExchangeRateRepository) GetRate(currency string) (*domain.ExchangeRate, error) {
+	rate := &domain.ExchangeRate{}
+	query := `SELECT currency, rate, updated_at FROM exchange_rates WHERE currency = $1`
+	err := r.db.QueryRow(query, currency).Scan(&rate.Currency, &rate.Rate, &rate.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // Курс не задан
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rate from postgres: %w", err)
+	}
+	return rate, nil
+}
+
+// SetRate реализует метод создания или обновления курса валюты для PostgreSQL.
+func (r *PGExchangeRateRepository) SetRate(rate *domain.ExchangeRate) error {
+	query := `INSERT INTO exchange_rates (currency, rate, updated_at) VALUES ($1, $2, $3)
+		ON CONFLICT (currency) DO UPDATE SET rate = EXCLUDED.rate, updated_at = EXCLUDED.updated_at`
+	if _, err := r.db.Exec(query, rate.Currency, rate.Rate, rate.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to set exchange rate in postgres: %w", err)
+	}
+	return nil
+}
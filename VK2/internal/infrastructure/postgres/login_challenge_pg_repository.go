@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+type PGLoginChallengeRepository struct {
+	db *sql.DB
+}
+
+func NewPGLoginChallengeRepository(db *sql.DB) repository.LoginChallengeRepository {
+	return &PGLoginChallengeRepository{db: db}
+}
+
+// CreateLoginChallenge реализует метод сохранения незавершенного входа для PostgreSQL.
+func (r *PGLoginChallengeRepository) CreateLoginChallenge(challenge *domain.LoginChallenge) error {
+	query := `INSERT INTO login_challenges (id, user_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.db.Exec(query, challenge.ID, challenge.UserID, challenge.TokenHash, challenge.ExpiresAt, challenge.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create login challenge in postgres: %w", err)
+	}
+	return nil
+}
+
+// GetLoginChallengeByHash реализует метод получения незавершенного входа по хешу токена для PostgreSQL.
+func (r *PGLoginChallengeRepository) GetLoginChallengeByHash(tokenHash string) (*domain.LoginChallenge, error) {
+	challenge := &domain.LoginChallenge{}
+	query := `SELECT id, user_id, token_hash, attempts, expires_at, created_at, used_at FROM login_challenges WHERE token_hash = $1`
+	err := r.db.QueryRow(query, tokenHash).Scan(&challenge.ID, &challenge.UserID, &challenge.TokenHash, &challenge.Attempts, &challenge.ExpiresAt, &challenge.CreatedAt, &challenge.UsedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // Вход не найден
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login challenge by hash from postgres: %w", err)
+	}
+	return challenge, nil
+}
+
+// ClaimLoginChallengeAttempt реализует метод учета попытки ввода кода для PostgreSQL.
+// Попытка учитывается до проверки кода, поэтому параллельные запросы не превысят лимит.
+func (r *PGLoginChallengeRepository) ClaimLoginChallengeAttempt(id string, maxAttempts int) (bool, error) {
+	query := `UPDATE login_challenges SET attempts = attempts + 1 WHERE id = $1 AND used_at IS NULL AND attempts < $2`
+	res, err := r.db.Exec(query, id, maxAttempts)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim login challenge attempt in postgres: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// MarkLoginChallengeUsed реализует метод завершения входа для PostgreSQL.
+func (r *PGLoginChallengeRepository) MarkLoginChallengeUsed(id string, usedAt time.Time) (bool, error) {
+	query := `UPDATE login_challenges SET used_at = $2 WHERE id = $1 AND used_at IS NULL`
+	res, err := r.db.Exec(query, id, usedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark login challenge used in postgres: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+type PGLoginThrottleRepository struct {
+	db *sql.DB
+}
+
+func NewPGLoginThrottleRepository(db *sql.DB) repository.LoginThrottleRepository {
+	return &PGLoginThrottleRepository{db: db}
+}
+
+// ClaimLoginAttempt реализует метод учета попытки входа для PostgreSQL.
+// Счетчик читается с блокировкой строки, поэтому параллельные попытки учитываются
+// по очереди и не проходят мимо уже наступившей блокировки.
+func (r *PGLoginThrottleRepository) ClaimLoginAttempt(key string, policy domain.LoginThrottlePolicy, now time.Time) (*domain.LoginThrottle, bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Строка создается заранее, чтобы первую попытку тоже было на чем заблокировать
+	insertQuery := `INSERT INTO login_throttles (key, failures, last_failure_at) VALUES ($1, 0, $2) ON CONFLICT (key) DO NOTHING`
+	if _, err := tx.Exec(insertQuery, key, now); err != nil {
+		return nil, false, fmt.Errorf("failed to create login throttle in postgres: %w", err)
+	}
+
+	throttle := &domain.LoginThrottle{Key: key}
+	selectQuery := `SELECT failures, last_failure_at, locked_until FROM login_throttles WHERE key = $1 FOR UPDATE`
+	if err := tx.QueryRow(selectQuery, key).Scan(&throttle.Failures, &throttle.LastFailureAt, &throttle.LockedUntil); err != nil {
+		return nil, false, fmt.Errorf("failed to get login throttle from postgres: %w", err)
+	}
+	if throttle.Locked(now) {
+		return throttle, false, nil
+	}
+
+	throttle.RecordFailure(policy, now)
+	updateQuery := `UPDATE login_throttles SET failures = $2, last_failure_at = $3, locked_until = $4 WHERE key = $1`
+	if _, err := tx.Exec(updateQuery, key, throttle.Failures, throttle.LastFailureAt, throttle.LockedUntil); err != nil {
+		return nil, false, fmt.Errorf("failed to update login throttle in postgres: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return throttle, true, nil
+}
+
+// ReleaseLoginAttempt реализует метод отмены учета успешной попытки для PostgreSQL.
+// Попытка была учтена, только пока ключ не был заблокирован, поэтому блокировку
+// поставила сама эта попытка или параллельные ей; она снимается вместе с учетом.
+func (r *PGLoginThrottleRepository) ReleaseLoginAttempt(key string) error {
+	query := `UPDATE login_throttles SET failures = GREATEST(failures - 1, 0), locked_until = NULL WHERE key = $1`
+	if _, err := r.db.Exec(query, key); err != nil {
+		return fmt.Errorf("failed to release login attempt in postgres: %w", err)
+	}
+	return nil
+}
+
+// ResetLoginThrottle реализует метод сброса счетчика попыток входа для PostgreSQL.
+func (r *PGLoginThrottleRepository) ResetLoginThrottle(key string) error {
+	query := `DELETE FROM login_throttles WHERE key = $1`
+	if _, err := r.db.Exec(query, key); err != nil {
+		return fmt.Errorf("failed to reset login throttle in postgres: %w", err)
+	}
+	return nil
+}
+
+// DeleteStaleLoginThrottles реализует метод удаления устаревших счетчиков для PostgreSQL.
+func (r *PGLoginThrottleRepository) DeleteStaleLoginThrottles(before time.Time, limit int) (int, error) {
+	query := `
+		DELETE FROM login_throttles
+		WHERE key IN (
+			SELECT key FROM login_throttles
+			WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < $1)
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED)`
+	result, err := r.db.Exec(query, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stale login throttles in postgres: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stale login throttles in postgres: %w", err)
+	}
+	return int(rows), nil
+}
@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+type PGPasswordResetRepository struct {
+	db *sql.DB
+}
+
+func NewPGPasswordResetRepository(db *sql.DB) repository.PasswordResetRepository {
+	return &PGPasswordResetRepository{db: db}
+}
+
+// CreatePasswordResetToken реализует метод сохранения токена сброса пароля для PostgreSQL.
+func (r *PGPasswordResetRepository) CreatePasswordResetToken(token *domain.PasswordResetToken) error {
+	query := `INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.db.Exec(query, token.ID, token.UserID, token.TokenHash, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create password reset token in postgres: %w", err)
+	}
+	return nil
+}
+
+// GetPasswordResetTokenByHash реализует метод получения токена сброса пароля по хешу для PostgreSQL.
+func (r *PGPasswordResetRepository) GetPasswordResetTokenByHash(tokenHash string) (*domain.PasswordResetToken, error) {
+	token := &domain.PasswordResetToken{}
+	query := `SELECT id, user_id, token_hash, expires_at, created_at, used_at FROM password_reset_tokens WHERE token_hash = $1`
+	err := r.db.QueryRow(query, tokenHash).Scan(&token.ID, &token.UserID, &token.TokenHash, &token.ExpiresAt, &token.CreatedAt, &token.UsedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // Токен не найден
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password reset token by hash from postgres: %w", err)
+	}
+	return token, nil
+}
+
+// ResetPasswordWithToken реализует метод смены пароля по токену сброса для PostgreSQL.
+// Условие used_at IS NULL гарантирует, что из двух конкурентных запросов с одним токеном
+// пароль сменит только один; токен тратится только вместе со сменой пароля.
+func (r *PGPasswordResetRepository) ResetPasswordWithToken(tokenID, userID, passwordHash string, usedAt time.Time) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE password_reset_tokens SET used_at = $3 WHERE id = $1 AND user_id = $2 AND used_at IS NULL`
+	res, err := tx.Exec(query, tokenID, userID, usedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark password reset token used in postgres: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected != 1 {
+		return false, nil
+	}
+	if err := updatePasswordAndInvalidateTokens(tx, userID, passwordHash, usedAt); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// UpdatePasswordAndInvalidateTokens реализует метод смены пароля с аннулированием токенов сброса для PostgreSQL.
+// Оба изменения выполняются в одной транзакции: ссылка из письма не может пережить смену пароля.
+func (r *PGPasswordResetRepository) UpdatePasswordAndInvalidateTokens(userID, passwordHash string, usedAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updatePasswordAndInvalidateTokens(tx, userID, passwordHash, usedAt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// updatePasswordAndInvalidateTokens сохраняет хеш пароля и помечает использованными
+// все неиспользованные токены сброса пользователя в транзакции tx.
+func updatePasswordAndInvalidateTokens(tx *sql.Tx, userID, passwordHash string, usedAt time.Time) error {
+	if _, err := tx.Exec(`UPDATE users SET password_hash = $2 WHERE id = $1`, userID, passwordHash); err != nil {
+		return fmt.Errorf("failed to update password in postgres: %w", err)
+	}
+	query := `UPDATE password_reset_tokens SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL`
+	if _, err := tx.Exec(query, userID, usedAt); err != nil {
+		return fmt.Errorf("failed to invalidate password reset tokens in postgres: %w", err)
+	}
+	return nil
+}
+
+// EnqueuePasswordResetRequest реализует метод постановки запроса на сброс пароля в очередь для PostgreSQL.
+func (r *PGPasswordResetRepository) EnqueuePasswordResetRequest(request *domain.PasswordResetRequest) error {
+	query := `INSERT INTO password_reset_requests (id, email, created_at) VALUES ($1, $2, $3)`
+	if _, err := r.db.Exec(query, request.ID, request.Email, request.CreatedAt); err != nil {
+		return fmt.Errorf("failed to enqueue password reset request in postgres: %w", err)
+	}
+	return nil
+}
+
+// TakePasswordResetRequest реализует метод извлечения запроса на сброс пароля из очереди для PostgreSQL.
+// SKIP LOCKED позволяет нескольким экземплярам сервиса разбирать очередь параллельно.
+func (r *PGPasswordResetRepository) TakePasswordResetRequest() (*domain.PasswordResetRequest, error) {
+	request := &domain.PasswordResetRequest{}
+	query := `
+		DELETE FROM password_reset_requests
+		WHERE id = (
+			SELECT id FROM password_reset_requests
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED)
+		RETURNING id, email, created_at`
+	err := r.db.QueryRow(query).Scan(&request.ID, &request.Email, &request.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // Очередь пуста
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take password reset request from postgres: %w", err)
+	}
+	return request, nil
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+type PGProfileRepository struct {
+	db *sql.DB
+}
+
+func NewPGProfileRepository(db *sql.DB) repository.ProfileRepository {
+	return &PGProfileRepository{db: db}
+}
+
+// profileColumns — столбцы профиля в порядке, в котором их читает scanProfile.
+const profileColumns = `user_id, display_name, avatar_key, city, phone, bio, city_public, phone_public, updated_at`
+
+// GetProfile реализует метод получения профиля пользователя для PostgreSQL.
+func (r *PGProfileRepository) GetProfile(userID string) (*domain.UserProfile, error) {
+	query := `SELECT ` + profileColumns + ` FROM user_profiles WHERE user_id = $1`
+	profile, err := scanProfile(r.db.QueryRow(query, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user profile from postgres: %w", err)
+	}
+	return profile, nil
+}
+
+// UpdateProfile реализует метод изменения полей профиля для PostgreSQL. Новый
+// профиль получает значения по умолчанию из схемы таблицы для незаданных полей.
+func (r *PGProfileRepository) UpdateProfile(userID string, update repository.ProfileUpdate, updatedAt time.Time) (*domain.UserProfile, error) {
+	query := `
+		INSERT INTO user_profiles (user_id, display_name, city, phone, bio, city_public, phone_public, updated_at)
+		VALUES ($1, COALESCE($2::text, ''), COALESCE($3::text, ''), COALESCE($4::text, ''), COALESCE($5::text, ''),
+			COALESCE($6::boolean, TRUE), COALESCE($7::boolean, FALSE), $8)
+		ON CONFLICT (user_id) DO UPDATE SET
+			display_name = COALESCE($2::text, user_profiles.display_name),
+			city = COALESCE($3::text, user_profiles.city),
+			phone = COALESCE($4::text, user_profiles.phone),
+			bio = COALESCE($5::text, user_profiles.bio),
+			city_public = COALESCE($6::boolean, user_profiles.city_public),
+			phone_public = COALESCE($7::boolean, user_profiles.phone_public),
+			updated_at = EXCLUDED.updated_at
+		RETURNING ` + profileColumns
+	profile, err := scanProfile(r.db.QueryRow(query, userID, update.DisplayName, update.City, update.Phone,
+		update.Bio, update.CityPublic, update.PhonePublic, updatedAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user profile in postgres: %w", err)
+	}
+	return profile, nil
+}
+
+// ReplaceAvatarKey реализует метод замены аватара для PostgreSQL. Строка профиля
+// блокируется до конца транзакции, чтобы параллельная замена прочитала уже новый ключ.
+func (r *PGProfileRepository) ReplaceAvatarKey(userID, avatarKey string, updatedAt time.Time) (*domain.UserProfile, string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO user_profiles (user_id, updated_at) VALUES ($1, $2) ON CONFLICT (user_id) DO NOTHING`, userID, updatedAt); err != nil {
+		return nil, "", fmt.Errorf("failed to create user profile in postgres: %w", err)
+	}
+	var oldKey string
+	if err := tx.QueryRow(`SELECT avatar_key FROM user_profiles WHERE user_id = $1 FOR UPDATE`, userID).Scan(&oldKey); err != nil {
+		return nil, "", fmt.Errorf("failed to lock user profile in postgres: %w", err)
+	}
+	query := `UPDATE user_profiles SET avatar_key = $2, updated_at = $3 WHERE user_id = $1 RETURNING ` + profileColumns
+	profile, err := scanProfile(tx.QueryRow(query, userID, avatarKey, updatedAt))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to update user avatar in postgres: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return profile, oldKey, nil
+}
+
+// scanProfile читает профиль из строки со столбцами profileColumns.
+func scanProfile(row *sql.Row) (*domain.UserProfile, error) {
+	profile := &domain.UserProfile{}
+	err := row.Scan(&profile.UserID, &profile.DisplayName, &profile.AvatarKey, &profile.City, &profile.Phone,
+		&profile.Bio, &profile.CityPublic, &profile.PhonePublic, &profile.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+type PGRefreshTokenRepository struct {
+	db *sql.DB
+}
+
+func NewPGRefreshTokenRepository(db *sql.DB) repository.RefreshTokenRepository {
+	return &PGRefreshTokenRepository{db: db}
+}
+
+// CreateRefreshToken реализует метод сохранения refresh-токена для PostgreSQL.
+func (r *PGRefreshTokenRepository) CreateRefreshToken(token *domain.RefreshToken) error {
+	query := `INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := r.db.Exec(query, token.ID, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token in postgres: %w", err)
+	}
+	return nil
+}
+
+// GetRefreshTokenByHash реализует метод получения refresh-токена по хешу для PostgreSQL.
+func (r *PGRefreshTokenRepository) GetRefreshTokenByHash(tokenHash string) (*domain.RefreshToken, error) {
+	token := &domain.RefreshToken{}
+	query := `SELECT id, user_id, family_id, token_hash, expires_at, created_at, rotated_at, revoked_at FROM refresh_tokens WHERE token_hash = $1`
+	err := r.db.QueryRow(query, tokenHash).Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &token.ExpiresAt, &token.CreatedAt, &token.RotatedAt, &token.RevokedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // Токен не найден
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token by hash from postgres: %w", err)
+	}
+	return token, nil
+}
+
+// MarkRefreshTokenRotated реализует метод пометки refresh-токена использованным для PostgreSQL.
+// Условие в WHERE гарантирует, что из двух конкурентных обновлений успешным будет только одно.
+func (r *PGRefreshTokenRepository) MarkRefreshTokenRotated(id string, rotatedAt time.Time) (bool, error) {
+	query := `UPDATE refresh_tokens SET rotated_at = $2 WHERE id = $1 AND rotated_at IS NULL AND revoked_at IS NULL`
+	res, err := r.db.Exec(query, id, rotatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark refresh token rotated in postgres: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
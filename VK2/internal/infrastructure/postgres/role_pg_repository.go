@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"vk/internal/adapter/repository"
+)
+
+type PGRoleRepository struct {
+	db *sql.DB
+}
+
+func NewPGRoleRepository(db *sql.DB) repository.RoleRepository {
+	return &PGRoleRepository{db: db}
+}
+
+// RoleExists реализует метод проверки существования роли для PostgreSQL.
+func (r *PGRoleRepository) RoleExists(role string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM roles WHERE name = $1)`
+	if err := r.db.QueryRow(query, role).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check role in postgres: %w", err)
+	}
+	return exists, nil
+}
+
+// GetRolePermissions реализует метод получения разрешений роли для PostgreSQL.
+func (r *PGRoleRepository) GetRolePermissions(role string) ([]string, error) {
+	query := `SELECT permission FROM role_permissions WHERE role = $1 ORDER BY permission`
+	rows, err := r.db.Query(query, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role permissions from postgres: %w", err)
+	}
+	defer rows.Close()
+
+	var permissions []string
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, fmt.Errorf("failed to scan permission row: %w", err)
+		}
+		permissions = append(permissions, permission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+// sessionTouchInterval ограничивает частоту записи last_seen_at, чтобы
+// каждый запрос с токеном не приводил к UPDATE.
+const sessionTouchInterval = time.Minute
+
+type PGSessionRepository struct {
+	db *sql.DB
+}
+
+func NewPGSessionRepository(db *sql.DB) repository.SessionRepository {
+	return &PGSessionRepository{db: db}
+}
+
+// CreateSession реализует метод создания сессии для PostgreSQL.
+func (r *PGSessionRepository) CreateSession(session *domain.Session) error {
+	query := `INSERT INTO sessions (id, user_id, user_agent, ip, created_at, last_seen_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.db.Exec(query, session.ID, session.UserID, session.UserAgent, session.IP, session.CreatedAt, session.LastSeenAt, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session in postgres: %w", err)
+	}
+	return nil
+}
+
+// GetSessionByID реализует метод получения сессии по ID для PostgreSQL.
+func (r *PGSessionRepository) GetSessionByID(id string) (*domain.Session, error) {
+	session := &domain.Session{}
+	query := `SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at FROM sessions WHERE id = $1`
+	err := r.db.QueryRow(query, id).Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &session.RevokedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // Сессия не найдена
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session by ID from postgres: %w", err)
+	}
+	return session, nil
+}
+
+// ListActiveSessions реализует метод получения активных сессий пользователя для PostgreSQL.
+func (r *PGSessionRepository) ListActiveSessions(userID string, now time.Time) ([]domain.Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC`
+	rows, err := r.db.Query(query, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions from postgres: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []domain.Session
+	for rows.Next() {
+		session := domain.Session{}
+		if err := rows.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &session.RevokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan session row: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// TouchSession реализует метод обновления времени активности сессии для PostgreSQL.
+func (r *PGSessionRepository) TouchSession(id string, lastSeenAt time.Time) error {
+	query := `UPDATE sessions SET last_seen_at = $2 WHERE id = $1 AND last_seen_at < $3`
+	_, err := r.db.Exec(query, id, lastSeenAt, lastSeenAt.Add(-sessionTouchInterval))
+	if err != nil {
+		return fmt.Errorf("failed to touch session in postgres: %w", err)
+	}
+	return nil
+}
+
+// ExtendSession реализует метод продления сессии для PostgreSQL.
+func (r *PGSessionRepository) ExtendSession(id string, expiresAt time.Time) error {
+	query := `UPDATE sessions SET expires_at = $2 WHERE id = $1 AND revoked_at IS NULL`
+	_, err := r.db.Exec(query, id, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to extend session in postgres: %w", err)
+	}
+	return nil
+}
+
+// RevokeSession реализует метод отзыва сессии для PostgreSQL.
+func (r *PGSessionRepository) RevokeSession(id string, revokedAt time.Time) error {
+	return r.revoke(`WHERE id = $1 AND revoked_at IS NULL`, `WHERE family_id = $1 AND revoked_at IS NULL`, id, revokedAt)
+}
+
+// RevokeUserSessions реализует метод отзыва всех сессий пользователя для PostgreSQL.
+func (r *PGSessionRepository) RevokeUserSessions(userID string, revokedAt time.Time) error {
+	return r.revoke(`WHERE user_id = $1 AND revoked_at IS NULL`, `WHERE user_id = $1 AND revoked_at IS NULL`, userID, revokedAt)
+}
+
+// RevokeOtherUserSessions реализует метод отзыва остальных сессий пользователя для PostgreSQL.
+func (r *PGSessionRepository) RevokeOtherUserSessions(userID, keepSessionID string, revokedAt time.Time) error {
+	return r.revoke(`WHERE user_id = $1 AND id <> $3 AND revoked_at IS NULL`, `WHERE user_id = $1 AND family_id <> $3 AND revoked_at IS NULL`, userID, revokedAt, keepSessionID)
+}
+
+// revoke в одной транзакции отзывает сессии и refresh-токены, подходящие под условия.
+// Условия получают arg как $1, revokedAt как $2 и extra начиная с $3.
+func (r *PGSessionRepository) revoke(sessionsWhere, tokensWhere, arg string, revokedAt time.Time, extra ...interface{}) error {
+	args := append([]interface{}{arg, revokedAt}, extra...)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE sessions SET revoked_at = $2 `+sessionsWhere, args...); err != nil {
+		return fmt.Errorf("failed to revoke sessions in postgres: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at = $2 `+tokensWhere, args...); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens in postgres: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
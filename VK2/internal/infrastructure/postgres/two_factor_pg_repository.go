@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+type PGTwoFactorRepository struct {
+	db *sql.DB
+}
+
+func NewPGTwoFactorRepository(db *sql.DB) repository.TwoFactorRepository {
+	return &PGTwoFactorRepository{db: db}
+}
+
+// GetTOTPSecret реализует метод получения секрета TOTP для PostgreSQL.
+func (r *PGTwoFactorRepository) GetTOTPSecret(userID string) (*domain.TOTPSecret, error) {
+	secret := &domain.TOTPSecret{}
+	query := `SELECT user_id, secret, enabled_at, last_used_step, created_at FROM user_totp WHERE user_id = $1`
+	err := r.db.QueryRow(query, userID).Scan(&secret.UserID, &secret.Secret, &secret.EnabledAt, &secret.LastUsedStep, &secret.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // Второй фактор не подключался
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get totp secret from postgres: %w", err)
+	}
+	return secret, nil
+}
+
+// SavePendingTOTPSecret реализует метод сохранения неподтвержденного секрета TOTP для PostgreSQL.
+// Подтвержденный секрет не перезаписывается.
+func (r *PGTwoFactorRepository) SavePendingTOTPSecret(secret *domain.TOTPSecret) (bool, error) {
+	query := `
+		INSERT INTO user_totp (user_id, secret, enabled_at, last_used_step, created_at)
+		VALUES ($1, $2, NULL, 0, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, created_at = EXCLUDED.created_at
+		WHERE user_totp.enabled_at IS NULL`
+	res, err := r.db.Exec(query, secret.UserID, secret.Secret, secret.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to save totp secret in postgres: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// EnableTOTP реализует метод подтверждения второго фактора для PostgreSQL.
+// Подтверждение и замена кодов восстановления выполняются в одной транзакции.
+func (r *PGTwoFactorRepository) EnableTOTP(userID string, step int64, enabledAt time.Time, recoveryCodeHashes []string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE user_totp SET enabled_at = $2, last_used_step = $3 WHERE user_id = $1 AND enabled_at IS NULL`, userID, enabledAt, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to enable totp in postgres: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected != 1 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return false, fmt.Errorf("failed to delete recovery codes in postgres: %w", err)
+	}
+	for _, codeHash := range recoveryCodeHashes {
+		if _, err := tx.Exec(`INSERT INTO user_recovery_codes (id, user_id, code_hash) VALUES ($1, $2, $3)`, uuid.New().String(), userID, codeHash); err != nil {
+			return false, fmt.Errorf("failed to create recovery code in postgres: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// UseTOTPStep реализует метод учета принятого кода TOTP для PostgreSQL.
+// Условие в WHERE не дает принять один и тот же код дважды, в том числе конкурентно.
+func (r *PGTwoFactorRepository) UseTOTPStep(userID string, step int64) (bool, error) {
+	query := `UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1 AND enabled_at IS NOT NULL AND last_used_step < $2`
+	res, err := r.db.Exec(query, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to update totp step in postgres: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// UseRecoveryCode реализует метод использования кода восстановления для PostgreSQL.
+func (r *PGTwoFactorRepository) UseRecoveryCode(userID, codeHash string, usedAt time.Time) (bool, error) {
+	query := `UPDATE user_recovery_codes SET used_at = $3 WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	res, err := r.db.Exec(query, userID, codeHash, usedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code in postgres: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// DeleteTOTP реализует метод отключения второго фактора для PostgreSQL.
+func (r *PGTwoFactorRepository) DeleteTOTP(userID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes in postgres: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete totp secret in postgres: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -2,15 +2,19 @@ package postgres
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	"vk/internal/adapter/repository"
 	"vk/internal/domain"
 )
 
+// usersEmailIndex — уникальный индекс почты пользователей без учета регистра.
+const usersEmailIndex = "idx_users_email"
+
 type PGUserRepository struct {
 	db *sql.DB
 }
@@ -21,9 +25,16 @@ func NewPGUserRepository(db *sql.DB) repository.UserRepository {
 
 // CreateUser реализует метод создания пользователя для PostgreSQL.
 func (r *PGUserRepository) CreateUser(user *domain.User) error {
-	query := `INSERT INTO users (id, login, password_hash, created_at) VALUES ($1, $2, $3, $4)`
-	_, err := r.db.Exec(query, user.ID, user.Login, user.PasswordHash, user.CreatedAt)
+	query := `INSERT INTO users (id, login, email, password_hash, role, created_at) VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)`
+	_, err := r.db.Exec(query, user.ID, user.Login, user.Email, user.PasswordHash, user.Role, user.CreatedAt)
 	if err != nil {
+		// Проверка занятости логина и почты в сценарии не защищает от параллельной регистрации
+		if constraint, ok := uniqueViolation(err); ok {
+			if constraint == usersEmailIndex {
+				return repository.ErrEmailTaken
+			}
+			return repository.ErrLoginTaken
+		}
 		return fmt.Errorf("failed to create user in postgres: %w", err)
 	}
 	return nil
@@ -32,8 +43,8 @@ func (r *PGUserRepository) CreateUser(user *domain.User) error {
 // GetUserByLogin реализует метод получения пользователя по логину для PostgreSQL.
 func (r *PGUserRepository) GetUserByLogin(login string) (*domain.User, error) {
 	user := &domain.User{}
-	query := `SELECT id, login, password_hash, created_at FROM users WHERE login = $1`
-	err := r.db.QueryRow(query, login).Scan(&user.ID, &user.Login, &user.PasswordHash, &user.CreatedAt)
+	query := `SELECT id, login, COALESCE(email, ''), email_verified_at, password_hash, role, created_at FROM users WHERE login = $1`
+	err := r.db.QueryRow(query, login).Scan(&user.ID, &user.Login, &user.Email, &user.EmailVerifiedAt, &user.PasswordHash, &user.Role, &user.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil // Пользователь не найден
 	}
@@ -43,11 +54,25 @@ func (r *PGUserRepository) GetUserByLogin(login string) (*domain.User, error) {
 	return user, nil
 }
 
+// GetUserByEmail реализует метод получения пользователя по почте для PostgreSQL.
+func (r *PGUserRepository) GetUserByEmail(email string) (*domain.User, error) {
+	user := &domain.User{}
+	query := `SELECT id, login, COALESCE(email, ''), email_verified_at, password_hash, role, created_at FROM users WHERE lower(email) = lower($1)`
+	err := r.db.QueryRow(query, email).Scan(&user.ID, &user.Login, &user.Email, &user.EmailVerifiedAt, &user.PasswordHash, &user.Role, &user.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // Пользователь не найден
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by email from postgres: %w", err)
+	}
+	return user, nil
+}
+
 // GetUserByID реализует метод получения пользователя по ID для PostgreSQL.
 func (r *PGUserRepository) GetUserByID(id string) (*domain.User, error) {
 	user := &domain.User{}
-	query := `SELECT id, login, password_hash, created_at FROM users WHERE id = $1`
-	err := r.db.QueryRow(query, id).Scan(&user.ID, &user.Login, &user.PasswordHash, &user.CreatedAt)
+	query := `SELECT id, login, COALESCE(email, ''), email_verified_at, password_hash, role, created_at FROM users WHERE id = $1`
+	err := r.db.QueryRow(query, id).Scan(&user.ID, &user.Login, &user.Email, &user.EmailVerifiedAt, &user.PasswordHash, &user.Role, &user.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil // Пользователь не найден
 	}
@@ -57,6 +82,67 @@ func (r *PGUserRepository) GetUserByID(id string) (*domain.User, error) {
 	return user, nil
 }
 
+// UpdateUserRole реализует метод изменения роли пользователя для PostgreSQL.
+func (r *PGUserRepository) UpdateUserRole(id, role string) error {
+	query := `UPDATE users SET role = $2 WHERE id = $1`
+	_, err := r.db.Exec(query, id, role)
+	if err != nil {
+		return fmt.Errorf("failed to update user role in postgres: %w", err)
+	}
+	return nil
+}
+
+// UpdatePassword реализует метод замены хеша пароля для PostgreSQL.
+func (r *PGUserRepository) UpdatePassword(id, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $2 WHERE id = $1`
+	_, err := r.db.Exec(query, id, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to update password in postgres: %w", err)
+	}
+	return nil
+}
+
+// ReplacePasswordHash реализует метод замены пересчитанного хеша пароля для PostgreSQL.
+func (r *PGUserRepository) ReplacePasswordHash(id, oldHash, newHash string) (bool, error) {
+	query := `UPDATE users SET password_hash = $3 WHERE id = $1 AND password_hash = $2`
+	res, err := r.db.Exec(query, id, oldHash, newHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to replace password hash in postgres: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// UpdateEmail реализует метод смены почты пользователя для PostgreSQL.
+func (r *PGUserRepository) UpdateEmail(id, email string) error {
+	query := `UPDATE users SET email = NULLIF($2, ''), email_verified_at = NULL WHERE id = $1`
+	_, err := r.db.Exec(query, id, email)
+	if err != nil {
+		if _, ok := uniqueViolation(err); ok {
+			return repository.ErrEmailTaken
+		}
+		return fmt.Errorf("failed to update email in postgres: %w", err)
+	}
+	return nil
+}
+
+// MarkEmailVerified реализует метод подтверждения почты пользователя для PostgreSQL.
+func (r *PGUserRepository) MarkEmailVerified(id, email string, verifiedAt time.Time) (bool, error) {
+	query := `UPDATE users SET email_verified_at = $3 WHERE id = $1 AND lower(email) = lower($2)`
+	res, err := r.db.Exec(query, id, email, verifiedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark email verified in postgres: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
+
 // NewPostgresDB создает и возвращает новое соединение с базой данных PostgreSQL.
 func NewPostgresDB(connStr string) (*sql.DB, error) {
 	db, err := sql.Open("postgres", connStr)
@@ -75,3 +161,18 @@ func NewPostgresDB(connStr string) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// uniqueViolation сообщает, нарушено ли ограничение уникальности, и возвращает его имя.
+func uniqueViolation(err error) (string, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return pqErr.Constraint, true
+	}
+	return "", false
+}
+
+// foreignKeyViolation сообщает, нарушено ли ограничение внешнего ключа.
+func foreignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"vk/internal/adapter/repository"
+)
+
+var (
+	ErrSignatureExpired = errors.New("срок действия ссылки истек")
+	ErrInvalidSignature = errors.New("неверная подпись ссылки")
+)
+
+// LocalBlobStorage хранит файлы в каталоге на диске. Файлы отдаются самим
+// сервисом по ссылкам вида /files/<ключ>?expires=...&signature=..., подписанным HMAC.
+type LocalBlobStorage struct {
+	root       string
+	baseURL    string
+	signingKey []byte
+}
+
+var _ repository.BlobStorage = (*LocalBlobStorage)(nil)
+
+// NewLocalBlobStorage создает хранилище в каталоге root. baseURL — внешний адрес
+// сервиса для подписанных ссылок; пустое значение дает относительные ссылки.
+func NewLocalBlobStorage(root, baseURL string, signingKey []byte) (*LocalBlobStorage, error) {
+	if len(signingKey) == 0 {
+		return nil, errors.New("не задан ключ подписи ссылок")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("не удалось создать каталог хранилища %s: %w", root, err)
+	}
+	return &LocalBlobStorage{
+		root:       root,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		signingKey: signingKey,
+	}, nil
+}
+
+// Put сохраняет файл. Запись идет во временный файл с последующим переименованием,
+// чтобы при сбое не остался частично записанный файл.
+func (s *LocalBlobStorage) Put(key string, data []byte, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", key, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file for %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name()) // Не срабатывает после успешного переименования
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get читает файл.
+func (s *LocalBlobStorage) Get(key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// Delete удаляет файл.
+func (s *LocalBlobStorage) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// SignedURL возвращает ссылку на файл, которую проверяет VerifySignedURL.
+func (s *LocalBlobStorage) SignedURL(key string, ttl time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {s.sign(key, expires)},
+	}
+	return s.baseURL + "/files/" + key + "?" + query.Encode(), nil
+}
+
+// VerifySignedURL проверяет подпись и срок действия ссылки на файл key.
+func (s *LocalBlobStorage) VerifySignedURL(key, expires, signature string, now time.Time) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(key, expires))) {
+		return ErrInvalidSignature
+	}
+	if now.Unix() > expiresAt {
+		return ErrSignatureExpired
+	}
+	return nil
+}
+
+// sign вычисляет HMAC-SHA256 ключа файла и срока действия ссылки.
+func (s *LocalBlobStorage) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// path возвращает путь к файлу на диске.
+func (s *LocalBlobStorage) path(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
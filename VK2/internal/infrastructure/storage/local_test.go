@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocalBlobStorage(t *testing.T) *LocalBlobStorage {
+	t.Helper()
+	local, err := NewLocalBlobStorage(t.TempDir(), "https://api.example.com/", []byte("signing-key"))
+	if err != nil {
+		t.Fatalf("NewLocalBlobStorage() error = %v", err)
+	}
+	return local
+}
+
+func TestLocalBlobStoragePutGetDelete(t *testing.T) {
+	local := newTestLocalBlobStorage(t)
+
+	key := "ads/42/photo-1.jpg"
+	data := []byte("jpeg data")
+	if err := local.Put(key, data, "image/jpeg"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	got, err := local.Get(key)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Get() = %q, %v, want %q", got, err, data)
+	}
+
+	// После записи в каталоге не остается временных файлов
+	entries, err := os.ReadDir(filepath.Join(local.root, "ads", "42"))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("directory entries = %d, %v, want 1", len(entries), err)
+	}
+
+	if err := local.Delete(key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	got, err = local.Get(key)
+	if err != nil || got != nil {
+		t.Errorf("Get() after Delete = %q, %v, want nil, nil", got, err)
+	}
+	if err := local.Delete(key); err != nil {
+		t.Errorf("Delete() of missing file error = %v", err)
+	}
+	if err := local.Put("../escape", data, "text/plain"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Put(../escape) error = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestLocalBlobStorageVerifySignedURL(t *testing.T) {
+	local := newTestLocalBlobStorage(t)
+	key := "ads/42/photo-1.jpg"
+
+	signedURL, err := local.SignedURL(key, time.Hour)
+	if err != nil {
+		t.Fatalf("SignedURL() error = %v", err)
+	}
+	if !strings.HasPrefix(signedURL, "https://api.example.com/files/"+key+"?") {
+		t.Fatalf("SignedURL() = %s, want link to /files/%s", signedURL, key)
+	}
+	u, err := url.Parse(signedURL)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	expires := u.Query().Get("expires")
+	signature := u.Query().Get("signature")
+	now := time.Now()
+
+	tampered := []byte(signature)
+	tampered[0] ^= 1
+
+	tests := []struct {
+		name      string
+		key       string
+		expires   string
+		signature string
+		now       time.Time
+		want      error
+	}{
+		{"valid", key, expires, signature, now, nil},
+		{"expired", key, expires, signature, now.Add(time.Hour + time.Minute), ErrSignatureExpired},
+		{"other key", "ads/42/photo-2.jpg", expires, signature, now, ErrInvalidSignature},
+		{"extended expiry", key, expires + "0", signature, now, ErrInvalidSignature},
+		{"tampered signature", key, expires, string(tampered), now, ErrInvalidSignature},
+		{"empty signature", key, expires, "", now, ErrInvalidSignature},
+		{"non-numeric expiry", key, "soon", signature, now, ErrInvalidSignature},
+		{"invalid key", "../" + key, expires, signature, now, ErrInvalidKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := local.VerifySignedURL(tt.key, tt.expires, tt.signature, tt.now); !errors.Is(err, tt.want) {
+				t.Errorf("VerifySignedURL() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalBlobStorageOtherSigningKey(t *testing.T) {
+	local := newTestLocalBlobStorage(t)
+	signedURL, err := local.SignedURL("ads/1.jpg", time.Hour)
+	if err != nil {
+		t.Fatalf("SignedURL() error = %v", err)
+	}
+	u, _ := url.Parse(signedURL)
+
+	other, err := NewLocalBlobStorage(t.TempDir(), "", []byte("other-key"))
+	if err != nil {
+		t.Fatalf("NewLocalBlobStorage() error = %v", err)
+	}
+	err = other.VerifySignedURL("ads/1.jpg", u.Query().Get("expires"), u.Query().Get("signature"), time.Now())
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifySignedURL() with other key error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestNewLocalBlobStorageRequiresKey(t *testing.T) {
+	if _, err := NewLocalBlobStorage(t.TempDir(), "", nil); err == nil {
+		t.Error("NewLocalBlobStorage() without signing key error = nil")
+	}
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"vk/internal/adapter/repository"
+)
+
+// maxPresignTTL — наибольший срок действия подписанной ссылки в S3.
+const maxPresignTTL = 7 * 24 * time.Hour
+
+// S3Config описывает подключение к S3-совместимому хранилищу (AWS S3, MinIO и т.п.).
+type S3Config struct {
+	Endpoint        string // Адрес API, например http://minio:9000
+	PublicEndpoint  string // Адрес для подписанных ссылок, доступный клиентам; по умолчанию Endpoint
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3BlobStorage хранит файлы в бакете S3-совместимого хранилища. Используется
+// адресация в стиле пути (endpoint/bucket/key), которую поддерживают и AWS, и MinIO.
+type S3BlobStorage struct {
+	endpoint       *url.URL
+	publicEndpoint *url.URL
+	bucket         string
+	signer         *sigV4Signer
+	client         *http.Client
+}
+
+var _ repository.BlobStorage = (*S3BlobStorage)(nil)
+
+func NewS3BlobStorage(config S3Config) (*S3BlobStorage, error) {
+	if config.Bucket == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errors.New("не заданы бакет или ключи доступа S3")
+	}
+	endpoint, err := parseEndpoint(config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	publicEndpoint := endpoint
+	if config.PublicEndpoint != "" {
+		if publicEndpoint, err = parseEndpoint(config.PublicEndpoint); err != nil {
+			return nil, err
+		}
+	}
+	region := config.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3BlobStorage{
+		endpoint:       endpoint,
+		publicEndpoint: publicEndpoint,
+		bucket:         config.Bucket,
+		signer: &sigV4Signer{
+			accessKeyID:     config.AccessKeyID,
+			secretAccessKey: config.SecretAccessKey,
+			region:          region,
+			service:         "s3",
+		},
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// parseEndpoint разбирает адрес хранилища вида http(s)://host[:port].
+func parseEndpoint(endpoint string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimRight(endpoint, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("некорректный адрес S3 %q", endpoint)
+	}
+	return u, nil
+}
+
+// Put загружает файл в бакет.
+func (s *S3BlobStorage) Put(key string, data []byte, contentType string) error {
+	resp, err := s.do(http.MethodPut, key, data, contentType)
+	if err != nil {
+		return fmt.Errorf("failed to put %s to s3: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to put %s to s3: %w", key, responseError(resp))
+	}
+	return nil
+}
+
+// Get скачивает файл из бакета.
+func (s *S3BlobStorage) Get(key string) ([]byte, error) {
+	resp, err := s.do(http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s from s3: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from s3: %w", key, err)
+		}
+		return data, nil
+	case http.StatusNotFound:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("failed to get %s from s3: %w", key, responseError(resp))
+}
+
+// Delete удаляет файл из бакета. S3 отвечает успехом и для отсутствующих файлов.
+func (s *S3BlobStorage) Delete(key string) error {
+	resp, err := s.do(http.MethodDelete, key, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to delete %s from s3: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to delete %s from s3: %w", key, responseError(resp))
+	}
+	return nil
+}
+
+// SignedURL возвращает подписанную ссылку на скачивание файла напрямую из хранилища.
+func (s *S3BlobStorage) SignedURL(key string, ttl time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	ttl = min(ttl, maxPresignTTL)
+	return s.signer.presignURL(http.MethodGet, s.objectURL(s.publicEndpoint, key), ttl, time.Now()), nil
+}
+
+// do выполняет подписанный запрос к объекту key.
+func (s *S3BlobStorage) do(method, key string, body []byte, contentType string) (*http.Response, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, s.objectURL(s.endpoint, key).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.signer.signRequest(req, sha256Hex(body), time.Now())
+	return s.client.Do(req)
+}
+
+// objectURL возвращает адрес объекта в стиле пути: endpoint/bucket/key.
+func (s *S3BlobStorage) objectURL(endpoint *url.URL, key string) *url.URL {
+	u := *endpoint
+	u.Path = strings.TrimRight(u.Path, "/") + "/" + s.bucket + "/" + key
+	u.RawPath = ""
+	return &u
+}
+
+// responseError формирует ошибку из ответа хранилища (XML с кодом ошибки).
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,289 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testBucket          = "ads"
+	testAccessKeyID     = "test-access-key"
+	testSecretAccessKey = "test-secret-key"
+)
+
+// s3StandIn — S3-совместимый сервер в памяти: хранит объекты бакета и, как
+// настоящее хранилище, отклоняет запросы с неверной или просроченной подписью.
+type s3StandIn struct {
+	signer *sigV4Signer
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newS3StandIn(t *testing.T) (*s3StandIn, *httptest.Server) {
+	t.Helper()
+	standIn := &s3StandIn{
+		signer: &sigV4Signer{
+			accessKeyID:     testAccessKeyID,
+			secretAccessKey: testSecretAccessKey,
+			region:          "us-east-1",
+			service:         "s3",
+		},
+		objects: make(map[string][]byte),
+		types:   make(map[string]string),
+	}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+	return standIn, server
+}
+
+func (s *s3StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.URL.Query().Has("X-Amz-Signature") {
+		if !s.validPresigned(r) {
+			http.Error(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>", http.StatusForbidden)
+			return
+		}
+	} else if !s.validSigned(r, body) {
+		http.Error(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>", http.StatusForbidden)
+		return
+	}
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+testBucket+"/")
+	if !ok {
+		http.Error(w, "<Error><Code>NoSuchBucket</Code></Error>", http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		s.objects[key] = body
+		s.types[key] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		data, ok := s.objects[key]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", s.types[key])
+		w.Write(data)
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// validSigned проверяет подпись в заголовке Authorization и хеш тела запроса.
+func (s *s3StandIn) validSigned(r *http.Request, body []byte) bool {
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash != sha256Hex(body) {
+		return false
+	}
+	signedAt, err := time.Parse(sigV4DateFormat, r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return false
+	}
+
+	expected, err := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+	if err != nil {
+		return false
+	}
+	s.signer.signRequest(expected, payloadHash, signedAt)
+	return r.Header.Get("Authorization") == expected.Header.Get("Authorization")
+}
+
+// validPresigned проверяет подпись и срок действия подписанной ссылки.
+func (s *s3StandIn) validPresigned(r *http.Request) bool {
+	query := r.URL.Query()
+	signedAt, err := time.Parse(sigV4DateFormat, query.Get("X-Amz-Date"))
+	if err != nil {
+		return false
+	}
+	expires, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+	if err != nil || time.Now().After(signedAt.Add(time.Duration(expires)*time.Second)) {
+		return false
+	}
+
+	u := &url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path}
+	expected := s.signer.presignURL(r.Method, u, time.Duration(expires)*time.Second, signedAt)
+	return expected == "http://"+r.Host+r.URL.RequestURI()
+}
+
+func newTestS3BlobStorage(t *testing.T, endpoint, secretAccessKey string) *S3BlobStorage {
+	t.Helper()
+	s3, err := NewS3BlobStorage(S3Config{
+		Endpoint:        endpoint,
+		Bucket:          testBucket,
+		AccessKeyID:     testAccessKeyID,
+		SecretAccessKey: secretAccessKey,
+	})
+	if err != nil {
+		t.Fatalf("NewS3BlobStorage() error = %v", err)
+	}
+	return s3
+}
+
+func TestS3BlobStoragePutGetDelete(t *testing.T) {
+	standIn, server := newS3StandIn(t)
+	s3 := newTestS3BlobStorage(t, server.URL, testSecretAccessKey)
+
+	key := "ads/42/photo-1.jpg"
+	data := []byte("jpeg data")
+	if err := s3.Put(key, data, "image/jpeg"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if got := standIn.types[key]; got != "image/jpeg" {
+		t.Errorf("stored content type = %q, want image/jpeg", got)
+	}
+
+	got, err := s3.Get(key)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Get() = %q, %v, want %q", got, err, data)
+	}
+
+	if err := s3.Delete(key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	got, err = s3.Get(key)
+	if err != nil || got != nil {
+		t.Errorf("Get() after Delete = %q, %v, want nil, nil", got, err)
+	}
+	// Удаление отсутствующего файла не считается ошибкой
+	if err := s3.Delete(key); err != nil {
+		t.Errorf("Delete() of missing file error = %v", err)
+	}
+}
+
+func TestS3BlobStorageRejectedSignature(t *testing.T) {
+	_, server := newS3StandIn(t)
+	s3 := newTestS3BlobStorage(t, server.URL, "wrong-secret")
+
+	if err := s3.Put("ads/1.jpg", []byte("data"), "image/jpeg"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Put() error = %v, want 403", err)
+	}
+	if _, err := s3.Get("ads/1.jpg"); err == nil {
+		t.Error("Get() error = nil, want error")
+	}
+	if err := s3.Delete("ads/1.jpg"); err == nil {
+		t.Error("Delete() error = nil, want error")
+	}
+}
+
+func TestS3BlobStorageInvalidKey(t *testing.T) {
+	_, server := newS3StandIn(t)
+	s3 := newTestS3BlobStorage(t, server.URL, testSecretAccessKey)
+
+	if err := s3.Put("../secret", []byte("data"), "text/plain"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Put() error = %v, want ErrInvalidKey", err)
+	}
+	if _, err := s3.SignedURL("/etc/passwd", time.Minute); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("SignedURL() error = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestS3BlobStorageSignedURL(t *testing.T) {
+	_, server := newS3StandIn(t)
+	s3 := newTestS3BlobStorage(t, server.URL, testSecretAccessKey)
+	if err := s3.Put("ads/42/photo.jpg", []byte("jpeg data"), "image/jpeg"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	signedURL, err := s3.SignedURL("ads/42/photo.jpg", time.Hour)
+	if err != nil {
+		t.Fatalf("SignedURL() error = %v", err)
+	}
+	resp, err := http.Get(signedURL)
+	if err != nil {
+		t.Fatalf("GET signed URL error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "jpeg data" {
+		t.Errorf("GET signed URL = %d %q, want 200 \"jpeg data\"", resp.StatusCode, body)
+	}
+
+	// Ссылка действует только для подписанного файла
+	tampered := strings.Replace(signedURL, "photo.jpg", "other.jpg", 1)
+	resp, err = http.Get(tampered)
+	if err != nil {
+		t.Fatalf("GET tampered URL error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("GET tampered URL = %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestS3BlobStorageSignedURLOptions(t *testing.T) {
+	s3, err := NewS3BlobStorage(S3Config{
+		Endpoint:        "http://minio:9000",
+		PublicEndpoint:  "https://files.example.com/",
+		Bucket:          testBucket,
+		AccessKeyID:     testAccessKeyID,
+		SecretAccessKey: testSecretAccessKey,
+	})
+	if err != nil {
+		t.Fatalf("NewS3BlobStorage() error = %v", err)
+	}
+
+	signedURL, err := s3.SignedURL("ads/1.jpg", 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("SignedURL() error = %v", err)
+	}
+	u, err := url.Parse(signedURL)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	// Ссылка ведет на публичный адрес, а срок ограничен семью днями, как в S3
+	if u.Scheme != "https" || u.Host != "files.example.com" || u.Path != "/ads/ads/1.jpg" {
+		t.Errorf("SignedURL() = %s, want https://files.example.com/ads/ads/1.jpg", signedURL)
+	}
+	if got := u.Query().Get("X-Amz-Expires"); got != "604800" {
+		t.Errorf("X-Amz-Expires = %s, want 604800", got)
+	}
+}
+
+func TestNewS3BlobStorageInvalidConfig(t *testing.T) {
+	valid := S3Config{
+		Endpoint:        "http://minio:9000",
+		Bucket:          testBucket,
+		AccessKeyID:     testAccessKeyID,
+		SecretAccessKey: testSecretAccessKey,
+	}
+	changes := map[string]func(*S3Config){
+		"no bucket":              func(c *S3Config) { c.Bucket = "" },
+		"no access key":          func(c *S3Config) { c.AccessKeyID = "" },
+		"no secret key":          func(c *S3Config) { c.SecretAccessKey = "" },
+		"endpoint without host":  func(c *S3Config) { c.Endpoint = "http://" },
+		"endpoint other scheme":  func(c *S3Config) { c.Endpoint = "ftp://minio:9000" },
+		"invalid public address": func(c *S3Config) { c.PublicEndpoint = "files.example.com" },
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			config := valid
+			change(&config)
+			if _, err := NewS3BlobStorage(config); err == nil {
+				t.Error("NewS3BlobStorage() error = nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Подпись запросов AWS Signature Version 4 для S3-совместимых хранилищ:
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4DateFormat = "20060102T150405Z"
+	// unsignedPayload используется в подписанных ссылках: тело запроса заранее неизвестно.
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// sigV4Signer подписывает запросы ключом доступа для заданного региона и сервиса.
+type sigV4Signer struct {
+	accessKeyID     string
+	secretAccessKey string
+	region          string
+	service         string
+}
+
+// signRequest добавляет к запросу заголовки X-Amz-Date, X-Amz-Content-Sha256 и Authorization.
+// payloadHash — SHA-256 тела запроса в hex.
+func (s *sigV4Signer) signRequest(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format(sigV4DateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	canonicalHeaders, signedHeaders := canonicalizeHeaders(headers)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := s.scope(amzDate)
+	signature := s.signature(amzDate, scope, canonicalRequest)
+	req.Header.Set("Authorization", sigV4Algorithm+
+		" Credential="+s.accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+}
+
+// presignURL возвращает ссылку, подписанную в параметрах запроса и действующую в течение ttl.
+func (s *sigV4Signer) presignURL(method string, u *url.URL, ttl time.Duration, now time.Time) string {
+	amzDate := now.UTC().Format(sigV4DateFormat)
+	scope := s.scope(amzDate)
+
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", sigV4Algorithm)
+	query.Set("X-Amz-Credential", s.accessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalHeaders, signedHeaders := canonicalizeHeaders(map[string]string{"host": u.Host})
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalURI(u),
+		canonicalQuery(query),
+		canonicalHeaders,
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	signature := s.signature(amzDate, scope, canonicalRequest)
+	return u.Scheme + "://" + u.Host + canonicalURI(u) + "?" + canonicalQuery(query) + "&X-Amz-Signature=" + signature
+}
+
+// scope возвращает область действия подписи: дата/регион/сервис/aws4_request.
+func (s *sigV4Signer) scope(amzDate string) string {
+	return amzDate[:8] + "/" + s.region + "/" + s.service + "/aws4_request"
+}
+
+// signature вычисляет подпись канонического запроса ключом, производным от секрета.
+func (s *sigV4Signer) signature(amzDate, scope, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), amzDate[:8])
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// canonicalizeHeaders возвращает канонические заголовки и список подписанных заголовков.
+func canonicalizeHeaders(headers map[string]string) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	return canonical.String(), strings.Join(names, ";")
+}
+
+// canonicalURI кодирует путь посегментно; "/" между сегментами не кодируется.
+func canonicalURI(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery кодирует параметры запроса, отсортированные по имени.
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pairs []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(name)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode кодирует строку по RFC 3986: без изменений остаются только
+// латинские буквы, цифры и символы "-", ".", "_", "~".
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
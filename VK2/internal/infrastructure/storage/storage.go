@@ -0,0 +1,23 @@
+// Package storage содержит реализации repository.BlobStorage: локальную файловую
+// систему и S3-совместимое объектное хранилище.
+package storage
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("недопустимый ключ файла")
+
+// keyPattern ограничивает ключи безопасными символами: такой ключ не требует
+// экранирования в URL и не может выйти за пределы каталога хранилища.
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*(/[A-Za-z0-9_-][A-Za-z0-9._-]*)*$`)
+
+// validateKey проверяет ключ файла.
+func validateKey(key string) error {
+	if len(key) > 512 || !keyPattern.MatchString(key) || strings.Contains(key, "..") {
+		return ErrInvalidKey
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"photo.jpg", true},
+		{"ads/42/photo-1_large.webp", true},
+		{"avatars/user-1/avatar.v2.jpg", true},
+		{"", false},
+		{"..", false},
+		{"../secret", false},
+		{"ads/../../secret", false},
+		{"ads/..hidden", false},
+		{"ads/photo..jpg", false},
+		{"/etc/passwd", false},
+		{"ads//photo.jpg", false},
+		{"ads/", false},
+		{".hidden", false},
+		{"ads/.hidden", false},
+		{`ads\photo.jpg`, false},
+		{"ads/photo 1.jpg", false},
+		{"ads/фото.jpg", false},
+		{"ads/photo.jpg?x=1", false},
+		{strings.Repeat("a", 512), true},
+		{strings.Repeat("a", 513), false},
+	}
+	for _, tt := range tests {
+		err := validateKey(tt.key)
+		if tt.valid && err != nil {
+			t.Errorf("validateKey(%q) error = %v, want nil", tt.key, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("validateKey(%q) error = %v, want ErrInvalidKey", tt.key, err)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package util
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Поддерживаемые алгоритмы подписи JWT.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// minRSAKeyBits — минимальный допустимый размер RSA-ключа.
+const minRSAKeyBits = 2048
+
+// SigningKey описывает ключ подписи или проверки JWT. Ключ только для проверки
+// (например, выведенный из ротации открытый ключ) не содержит приватной части.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+
+	secret     []byte
+	privateKey crypto.Signer
+	publicKey  crypto.PublicKey
+}
+
+// NewHMACKey создает ключ HS256 из секрета. Идентификатор ключа выводится из
+// хеша секрета, поэтому для ротации достаточно сменить сам секрет.
+func NewHMACKey(secret string) *SigningKey {
+	sum := sha256.Sum256([]byte("kid:" + secret))
+	return &SigningKey{
+		ID:        "hs-" + hex.EncodeToString(sum[:8]),
+		Algorithm: AlgHS256,
+		secret:    []byte(secret),
+	}
+}
+
+// ParseKeyPEM разбирает PEM с приватным (PKCS#1, PKCS#8) или открытым (PKIX)
+// RSA- либо Ed25519-ключом. Идентификатор ключа — отпечаток JWK по RFC 7638.
+func ParseKeyPEM(data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("не удалось декодировать PEM")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип PEM-блока: %s", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось разобрать ключ: %w", err)
+	}
+
+	key := &SigningKey{}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm, key.privateKey, key.publicKey = AlgRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Algorithm, key.publicKey = AlgRS256, k
+	case ed25519.PrivateKey:
+		key.Algorithm, key.privateKey, key.publicKey = AlgEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Algorithm, key.publicKey = AlgEdDSA, k
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип ключа %T", parsed)
+	}
+
+	if rsaKey, ok := key.publicKey.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("RSA-ключ должен быть не короче %d бит", minRSAKeyBits)
+	}
+
+	key.ID = key.JWK().thumbprint()
+	return key, nil
+}
+
+// CanSign сообщает, может ли ключ использоваться для подписи.
+func (k *SigningKey) CanSign() bool {
+	return k.secret != nil || k.privateKey != nil
+}
+
+// IsAsymmetric сообщает, является ли ключ асимметричным (публикуется в JWKS).
+func (k *SigningKey) IsAsymmetric() bool {
+	return k.publicKey != nil
+}
+
+// sign подписывает данные ключом.
+func (k *SigningKey) sign(data []byte) ([]byte, error) {
+	switch k.Algorithm {
+	case AlgHS256:
+		h := hmac.New(sha256.New, k.secret)
+		h.Write(data)
+		return h.Sum(nil), nil
+	case AlgRS256:
+		digest := sha256.Sum256(data)
+		return k.privateKey.Sign(rand.Reader, digest[:], crypto.SHA256)
+	case AlgEdDSA:
+		return k.privateKey.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+	return nil, fmt.Errorf("неподдерживаемый алгоритм %s", k.Algorithm)
+}
+
+// verify проверяет подпись данных.
+func (k *SigningKey) verify(data, signature []byte) bool {
+	switch k.Algorithm {
+	case AlgHS256:
+		h := hmac.New(sha256.New, k.secret)
+		h.Write(data)
+		return hmac.Equal(signature, h.Sum(nil))
+	case AlgRS256:
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(k.publicKey.(*rsa.PublicKey), crypto.SHA256, digest[:], signature) == nil
+	case AlgEdDSA:
+		return ed25519.Verify(k.publicKey.(ed25519.PublicKey), data, signature)
+	}
+	return false
+}
+
+// JWK описывает открытый ключ в формате RFC 7517.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519)
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKSet описывает набор открытых ключей, публикуемый в /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK возвращает открытую часть асимметричного ключа.
+func (k *SigningKey) JWK() JWK {
+	jwk := JWK{KeyID: k.ID, Use: "sig", Algorithm: k.Algorithm}
+	switch pub := k.publicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// thumbprint вычисляет отпечаток JWK по RFC 7638.
+func (j JWK) thumbprint() string {
+	// Обязательные поля в лексикографическом порядке, без пробелов
+	var canonical []byte
+	switch j.KeyType {
+	case "RSA":
+		canonical, _ = json.Marshal(struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.KeyType, j.N})
+	case "OKP":
+		canonical, _ = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Curve, j.KeyType, j.X})
+	}
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
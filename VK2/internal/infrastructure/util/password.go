@@ -1,15 +1,244 @@
 package util
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
 
-// HashPassword хеширует пароль с использованием bcrypt.
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher — алгоритм хеширования паролей. Алгоритм и параметры
+// записываются в сам хеш, поэтому хеш проверяется независимо от текущих настроек.
+type PasswordHasher interface {
+	// Hash хеширует пароль с текущими параметрами.
+	Hash(password string) (string, error)
+	// Recognizes сообщает, создан ли хеш этим алгоритмом.
+	Recognizes(hash string) bool
+	// Verify проверяет пароль по хешу этого алгоритма. needsRehash сообщает,
+	// что хеш создан с параметрами, отличными от текущих.
+	Verify(password, hash string) (ok, needsRehash bool, err error)
+}
+
+// bcryptMaxPasswordLength — bcrypt учитывает только первые 72 байта пароля.
+const bcryptMaxPasswordLength = 72
+
+// BcryptHasher хеширует пароли алгоритмом bcrypt.
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher создает хешер bcrypt со стоимостью cost.
+func NewBcryptHasher(cost int) (*BcryptHasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("стоимость bcrypt должна быть от %d до %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return &BcryptHasher{cost: cost}, nil
+}
+
+// Hash хеширует пароль алгоритмом bcrypt.
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword(bcryptInput(password), h.cost)
+	return string(hash), err
+}
+
+// Recognizes сообщает, является ли hash хешем bcrypt.
+func (h *BcryptHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// Verify проверяет пароль по хешу bcrypt.
+func (h *BcryptHasher) Verify(password, hash string) (bool, bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), bcryptInput(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false, false, err
+	}
+	return true, cost != h.cost, nil
+}
+
+// bcryptInput возвращает байты, которые передаются bcrypt. Пароли длиннее
+// 72 байт заменяются их хешем SHA-256 в base64, чтобы bcrypt не отбрасывал
+// окончание пароля. Раньше такие пароли bcrypt не принимал, поэтому
+// старые хеши это не затрагивает.
+func bcryptInput(password string) []byte {
+	if len(password) <= bcryptMaxPasswordLength {
+		return []byte(password)
+	}
+	sum := sha256.Sum256([]byte(password))
+	return []byte(base64.StdEncoding.EncodeToString(sum[:]))
+}
+
+// Argon2idParams — параметры Argon2id.
+type Argon2idParams struct {
+	Memory      uint32 // Память в КиБ
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32 // Байт
+	KeyLength   uint32 // Байт
+}
+
+// DefaultArgon2idParams — минимальные параметры, которые рекомендует OWASP.
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+const argon2idPrefix = "$argon2id$"
+
+// Argon2idHasher хеширует пароли алгоритмом Argon2id. Хеш записывается в
+// формате PHC: $argon2id$v=19$m=19456,t=2,p=1$<соль>$<ключ>.
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+// NewArgon2idHasher создает хешер Argon2id с параметрами params.
+func NewArgon2idHasher(params Argon2idParams) (*Argon2idHasher, error) {
+	if params.Memory < 8*uint32(params.Parallelism) || params.Iterations < 1 || params.Parallelism < 1 {
+		return nil, fmt.Errorf("некорректные параметры Argon2id: память %d КиБ, итераций %d, потоков %d",
+			params.Memory, params.Iterations, params.Parallelism)
+	}
+	if params.SaltLength < 8 || params.KeyLength < 16 {
+		return nil, fmt.Errorf("соль Argon2id должна быть не короче 8 байт, ключ — не короче 16 байт")
+	}
+	return &Argon2idHasher{params: params}, nil
+}
+
+// Hash хеширует пароль алгоритмом Argon2id со случайной солью.
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать соль: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Recognizes сообщает, является ли hash хешем Argon2id.
+func (h *Argon2idHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, argon2idPrefix)
+}
+
+// Verify проверяет пароль по хешу Argon2id с параметрами из самого хеша.
+func (h *Argon2idHasher) Verify(password, hash string) (bool, bool, error) {
+	params, salt, key, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return false, false, err
+	}
+	computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(computed, key) != 1 {
+		return false, false, nil
+	}
+	return true, params != h.params, nil
+}
+
+// decodeArgon2idHash разбирает хеш Argon2id в формате PHC.
+func decodeArgon2idHash(hash string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("некорректный формат хеша Argon2id")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, fmt.Errorf("некорректная версия хеша Argon2id: %w", err)
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("неподдерживаемая версия Argon2id: %d", version)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("некорректные параметры хеша Argon2id: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("некорректная соль хеша Argon2id: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("некорректный ключ хеша Argon2id: %w", err)
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+// PasswordManager хеширует новые пароли текущим алгоритмом и проверяет хеши
+// любого из поддерживаемых алгоритмов. Хеши другого алгоритма или с другими
+// параметрами помечаются для пересчета, чтобы после смены настроек пароли
+// переводились на них при входе.
+type PasswordManager struct {
+	current   PasswordHasher
+	hashers   []PasswordHasher
+	dummyHash string
+}
+
+// NewPasswordManager создает менеджер паролей, который хеширует новые пароли хешером current.
+func NewPasswordManager(current PasswordHasher) (*PasswordManager, error) {
+	// Параметры для проверки берутся из хеша, поэтому для других алгоритмов подходят любые настройки
+	bcryptHasher, err := NewBcryptHasher(bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	argon2idHasher, err := NewArgon2idHasher(DefaultArgon2idParams)
+	if err != nil {
+		return nil, err
+	}
+
+	// Хеш для фиктивных проверок вычисляется заранее, чтобы первая проверка не была заметно дольше
+	dummyHash, err := current.Hash("dummy password")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось вычислить фиктивный хеш: %w", err)
+	}
+
+	return &PasswordManager{
+		current:   current,
+		hashers:   []PasswordHasher{current, bcryptHasher, argon2idHasher},
+		dummyHash: dummyHash,
+	}, nil
+}
+
+// Hash хеширует пароль текущим алгоритмом.
+func (m *PasswordManager) Hash(password string) (string, error) {
+	return m.current.Hash(password)
+}
+
+// Verify проверяет пароль по хешу. needsRehash сообщает, что пароль верен, но
+// хеш стоит пересчитать текущим алгоритмом с текущими параметрами.
+func (m *PasswordManager) Verify(password, hash string) (ok, needsRehash bool) {
+	for _, hasher := range m.hashers {
+		if !hasher.Recognizes(hash) {
+			continue
+		}
+		ok, needsRehash, err := hasher.Verify(password, hash)
+		if err != nil || !ok {
+			return false, false
+		}
+		return true, needsRehash || hasher != m.current
+	}
+	return false, false
 }
 
-// CheckPasswordHash сравнивает хешированный пароль с обычным паролем.
-func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+// CompareDummy тратит на проверку пароля столько же времени, сколько Verify,
+// но результат не важен. Вызывается, когда пользователь не найден, чтобы время
+// ответа не выдавало, какие логины существуют.
+func (m *PasswordManager) CompareDummy(password string) {
+	m.current.Verify(password, m.dummyHash)
 }
@@ -0,0 +1,487 @@
+package util
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// PasswordStrength — оценка стойкости пароля к подбору в духе zxcvbn: пароль
+// раскладывается на узнаваемые части (словарные слова, последовательности,
+// повторы, ряды клавиатуры, годы), и оценивается число попыток, за которое
+// перебор по таким шаблонам найдет пароль.
+type PasswordStrength struct {
+	Guesses float64 // Оценка числа попыток подбора
+	Score   int     // От 0 (угадывается мгновенно) до 4 (очень надежный)
+	Warning string  // Почему пароль слабый, если оценка низкая: узнанный шаблон или нехватка длины
+}
+
+const (
+	// bruteforceCardinality — сколько вариантов в среднем дает один символ при переборе.
+	bruteforceCardinality = 10
+	// minSingleCharGuesses, minMultiCharGuesses — наименьшие оценки для частей пароля,
+	// чтобы короткие шаблоны не выглядели надежнее перебора.
+	minSingleCharGuesses = 10
+	minMultiCharGuesses  = 50
+	// sequencePenalty — во сколько раз каждая следующая часть пароля усложняет
+	// подбор: нужно угадать и сами части, и то, как они соединены.
+	sequencePenalty = 10000
+)
+
+const (
+	warningCommonPassword = "это распространенный пароль или слово"
+	warningUserInput      = "пароль не должен содержать логин или почту"
+	warningSequence       = "последовательности вроде abc или 6543 легко угадать"
+	warningRepeat         = "повторы вроде aaa или abcabc легко угадать"
+	warningKeyboard       = "соседние клавиши вроде qwerty легко угадать"
+	warningYear           = "годы легко угадать"
+	warningTooShort       = "сделайте его длиннее, добавив еще слова или символы"
+)
+
+// commonPasswords — распространенные пароли и слова по убыванию частоты.
+// Чем раньше слово в списке, тем раньше его попробует перебор.
+var commonPasswords = []string{
+	"password", "qwerty", "admin", "welcome", "login", "letmein", "monkey", "dragon",
+	"master", "iloveyou", "sunshine", "princess", "football", "baseball", "shadow",
+	"superman", "batman", "trustno", "hello", "freedom", "whatever", "michael",
+	"jennifer", "jordan", "hunter", "ranger", "buster", "soccer", "hockey", "killer",
+	"pepper", "ginger", "summer", "winter", "spring", "autumn", "love", "secret",
+	"charlie", "andrew", "thomas", "daniel", "matrix", "starwars", "computer",
+	"internet", "google", "apple", "samsung", "market", "vkontakte", "yandex",
+	"mail", "user", "guest", "root", "test", "access", "flower", "orange", "banana",
+	"cheese", "chocolate", "cookie", "maggie", "jessica", "ashley", "nicole",
+	"amanda", "jasmine", "family", "friend", "forever", "angel", "blessed", "lovely",
+	"happy", "money", "naruto", "pokemon", "minecraft", "changeme", "default",
+	"pass", "word", "strong", "secure", "private", "my", "new", "old", "one", "two",
+	"three", "cat", "dog", "mother", "father", "sister", "brother", "baby", "honey",
+	"sweet", "star", "moon", "sun", "king", "queen", "boss", "god", "jesus", "world",
+	"game", "player", "gamer", "house", "home", "car", "phone", "russia", "moscow",
+	"parol", "privet", "lubov", "kotik", "solnce", "zaichik", "marina", "natasha",
+	"sergey", "alexandr", "dmitry", "andrey", "vladimir", "ivan", "olga", "tatiana",
+	"irina", "elena", "anna", "пароль", "привет", "любовь", "солнце", "котик",
+	"зайчик", "россия", "москва", "наташа", "марина",
+}
+
+var commonPasswordRanks = func() map[string]int {
+	ranks := make(map[string]int, len(commonPasswords))
+	for i, word := range commonPasswords {
+		ranks[word] = i + 1
+	}
+	return ranks
+}()
+
+// keyboardRows — ряды клавиатуры: пароли вроде qwerty или asdf набираются по ним.
+var keyboardRows = []string{
+	"1234567890-=", "qwertyuiop[]", "asdfghjkl;'", "zxcvbnm,./",
+	"йцукенгшщзхъ", "фывапролджэ", "ячсмитьбю",
+}
+
+// keyboardKeyGuesses — число вариантов начальной клавиши и направления для ряда клавиатуры.
+const keyboardKeyGuesses = 94 * 4
+
+// l33tSubstitutions — замены букв похожими символами. У единицы два варианта.
+var l33tSubstitutions = map[rune][]rune{
+	'4': {'a'}, '@': {'a'}, '8': {'b'}, '(': {'c'}, '3': {'e'}, '6': {'g'}, '9': {'g'},
+	'1': {'i', 'l'}, '!': {'i'}, '|': {'l'}, '0': {'o'}, '$': {'s'}, '5': {'s'},
+	'7': {'t'}, '+': {'t'}, '2': {'z'},
+}
+
+// passwordMatch — часть пароля с runes[i] по runes[j] включительно, узнанная шаблоном.
+type passwordMatch struct {
+	i, j    int
+	guesses float64
+	warning string // Пусто для перебора
+}
+
+// EstimatePasswordStrength оценивает стойкость пароля. userInputs — данные
+// пользователя (логин, почта), которые злоумышленник попробует в первую очередь.
+func EstimatePasswordStrength(password string, userInputs ...string) PasswordStrength {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return PasswordStrength{Guesses: 1}
+	}
+
+	matches := findPasswordMatches(runes, userInputRanks(userInputs))
+	guesses, sequence := mostGuessableSequence(runes, matches)
+	return PasswordStrength{
+		Guesses: guesses,
+		Score:   guessesToScore(guesses),
+		Warning: sequenceWarning(sequence),
+	}
+}
+
+// userInputRanks строит словарь из данных пользователя: логина и частей почты.
+func userInputRanks(userInputs []string) map[string]int {
+	ranks := make(map[string]int)
+	for _, input := range userInputs {
+		input = strings.ToLower(strings.TrimSpace(input))
+		parts := strings.FieldsFunc(input, func(r rune) bool { return r == '@' || r == '.' || r == '_' || r == '-' || r == '+' })
+		for _, word := range append(parts, input) {
+			if len([]rune(word)) >= 3 {
+				ranks[word] = 1
+			}
+		}
+	}
+	return ranks
+}
+
+// findPasswordMatches находит в пароле все части, узнаваемые шаблонами.
+func findPasswordMatches(runes []rune, userRanks map[string]int) []passwordMatch {
+	var matches []passwordMatch
+	matches = append(matches, dictionaryMatches(runes, commonPasswordRanks, warningCommonPassword)...)
+	matches = append(matches, dictionaryMatches(runes, userRanks, warningUserInput)...)
+	matches = append(matches, sequenceMatches(runes)...)
+	matches = append(matches, repeatMatches(runes, userRanks)...)
+	matches = append(matches, keyboardMatches(runes)...)
+	matches = append(matches, yearMatches(runes)...)
+	return matches
+}
+
+// dictionaryMatches находит словарные слова, в том числе записанные задом наперед
+// и с заменой букв похожими символами (p@ssw0rd).
+func dictionaryMatches(runes []rune, ranks map[string]int, warning string) []passwordMatch {
+	if len(ranks) == 0 {
+		return nil
+	}
+	lower := toLowerRunes(runes)
+
+	var matches []passwordMatch
+	for i := 0; i < len(lower); i++ {
+		for j := i + 1; j < len(lower); j++ {
+			original := runes[i : j+1]
+			best := math.Inf(1)
+			for _, candidate := range unleetVariants(lower[i : j+1]) {
+				l33tFactor := l33tVariations(lower[i:j+1], candidate)
+				if rank, ok := ranks[string(candidate)]; ok {
+					best = math.Min(best, float64(rank)*l33tFactor)
+				}
+				if rank, ok := ranks[string(reverseRunes(candidate))]; ok {
+					best = math.Min(best, float64(rank)*l33tFactor*2)
+				}
+			}
+			if !math.IsInf(best, 1) {
+				matches = append(matches, passwordMatch{i: i, j: j, guesses: best * uppercaseVariations(original), warning: warning})
+			}
+		}
+	}
+	return matches
+}
+
+// unleetVariants возвращает слово без замен и варианты с обратной заменой символов на буквы.
+func unleetVariants(word []rune) [][]rune {
+	variants := [][]rune{word}
+	for choice := 0; choice < 2; choice++ {
+		variant := make([]rune, len(word))
+		changed := false
+		for k, r := range word {
+			variant[k] = r
+			if letters, ok := l33tSubstitutions[r]; ok {
+				variant[k] = letters[min(choice, len(letters)-1)]
+				changed = true
+			}
+		}
+		if changed {
+			variants = append(variants, variant)
+		}
+	}
+	return variants
+}
+
+// l33tVariations — во сколько раз замены символов увеличивают перебор.
+func l33tVariations(word, unleeted []rune) float64 {
+	substituted := 0
+	for k := range word {
+		if word[k] != unleeted[k] {
+			substituted++
+		}
+	}
+	if substituted == 0 {
+		return 1
+	}
+	return math.Pow(2, float64(substituted))
+}
+
+// uppercaseVariations — во сколько раз заглавные буквы увеличивают перебор.
+// Заглавная первая или последняя буква или все заглавные почти не помогают.
+func uppercaseVariations(word []rune) float64 {
+	upper, lower := 0, 0
+	for _, r := range word {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+	if lower == 0 || (upper == 1 && (unicode.IsUpper(word[0]) || unicode.IsUpper(word[len(word)-1]))) {
+		return 2
+	}
+	variations := 0.0
+	for k := 1; k <= min(upper, lower); k++ {
+		variations += binomial(upper+lower, k)
+	}
+	return variations
+}
+
+// sequenceMatches находит последовательности символов вроде abcd, 4567, 9876.
+func sequenceMatches(runes []rune) []passwordMatch {
+	var matches []passwordMatch
+	for i := 0; i < len(runes)-2; {
+		delta := runes[i+1] - runes[i]
+		j := i + 1
+		if delta == 1 || delta == -1 {
+			for j+1 < len(runes) && runes[j+1]-runes[j] == delta {
+				j++
+			}
+		}
+		if j-i+1 >= 3 {
+			base := 26.0
+			switch {
+			case strings.ContainsRune("aAzZ019", runes[i]):
+				base = 4 // Очевидное начало
+			case unicode.IsDigit(runes[i]):
+				base = 10
+			}
+			if delta < 0 {
+				base *= 2
+			}
+			matches = append(matches, passwordMatch{i: i, j: j, guesses: base * float64(j-i+1), warning: warningSequence})
+			i = j
+			continue
+		}
+		i++
+	}
+	return matches
+}
+
+// repeatMatches находит повторы символа или группы символов: aaaa, abcabc.
+// Повтор оценивается как подбор повторяемой группы и числа повторов. С каждой
+// позиции берется самый длинный повтор, а поиск продолжается после него.
+func repeatMatches(runes []rune, userRanks map[string]int) []passwordMatch {
+	var matches []passwordMatch
+	for i := 0; i < len(runes); {
+		bestUnit, bestCount := 0, 0
+		for unit := 1; i+2*unit <= len(runes); unit++ {
+			count := 1
+			for i+(count+1)*unit <= len(runes) && string(runes[i+count*unit:i+(count+1)*unit]) == string(runes[i:i+unit]) {
+				count++
+			}
+			if (count >= 3 || (count == 2 && unit > 1)) && unit*count > bestUnit*bestCount {
+				bestUnit, bestCount = unit, count
+			}
+		}
+		if bestCount == 0 {
+			i++
+			continue
+		}
+
+		unitRunes := runes[i : i+bestUnit]
+		unitGuesses, _ := mostGuessableSequence(unitRunes, findPasswordMatches(unitRunes, userRanks))
+		end := i + bestUnit*bestCount
+		matches = append(matches, passwordMatch{i: i, j: end - 1, guesses: unitGuesses * float64(bestCount), warning: warningRepeat})
+		i = end
+	}
+	return matches
+}
+
+// keyboardMatches находит отрезки рядов клавиатуры длиной от трех клавиш, в том числе в обратную сторону.
+func keyboardMatches(runes []rune) []passwordMatch {
+	lowerRunes := toLowerRunes(runes)
+
+	var matches []passwordMatch
+	for i := 0; i < len(lowerRunes); i++ {
+		for j := i + 2; j < len(lowerRunes); j++ {
+			part := string(lowerRunes[i : j+1])
+			if !onKeyboardRow(part) {
+				break // Более длинный отрезок тоже не будет рядом клавиш
+			}
+			guesses := keyboardKeyGuesses * float64(j-i) * uppercaseVariations(runes[i:j+1])
+			matches = append(matches, passwordMatch{i: i, j: j, guesses: guesses, warning: warningKeyboard})
+		}
+	}
+	return matches
+}
+
+// onKeyboardRow сообщает, набирается ли строка подряд по одному ряду клавиатуры.
+func onKeyboardRow(part string) bool {
+	reversed := string(reverseRunes([]rune(part)))
+	for _, row := range keyboardRows {
+		if strings.Contains(row, part) || strings.Contains(row, reversed) {
+			return true
+		}
+	}
+	return false
+}
+
+// yearMatches находит годы 1900–2099.
+func yearMatches(runes []rune) []passwordMatch {
+	// Удаленность года в пароле считается от текущего
+	currentYear := time.Now().Year()
+
+	var matches []passwordMatch
+	for i := 0; i+4 <= len(runes); i++ {
+		year := 0
+		for _, r := range runes[i : i+4] {
+			if r < '0' || r > '9' {
+				year = -1
+				break
+			}
+			year = year*10 + int(r-'0')
+		}
+		if year < 1900 || year > 2099 {
+			continue
+		}
+		space := math.Max(math.Abs(float64(year-currentYear)), 20)
+		matches = append(matches, passwordMatch{i: i, j: i + 3, guesses: space, warning: warningYear})
+	}
+	return matches
+}
+
+// mostGuessableSequence выбирает разбиение пароля на части, при котором его
+// проще всего подобрать (как в zxcvbn). Участки, не узнанные ни одним шаблоном,
+// оцениваются как перебор. Разбиение на l частей оценивается как
+// l! * произведение оценок частей + sequencePenalty^(l-1).
+func mostGuessableSequence(runes []rune, matches []passwordMatch) (float64, []passwordMatch) {
+	n := len(runes)
+	if n == 0 {
+		return 1, nil
+	}
+
+	byEnd := make([][]passwordMatch, n)
+	for _, m := range matches {
+		m.guesses = math.Max(m.guesses, minMatchGuesses(m))
+		byEnd[m.j] = append(byEnd[m.j], m)
+	}
+	for j := 0; j < n; j++ {
+		for i := 0; i <= j; i++ {
+			m := passwordMatch{i: i, j: j, guesses: math.Pow(bruteforceCardinality, float64(j-i+1))}
+			m.guesses = math.Max(m.guesses, minMatchGuesses(m)+1)
+			byEnd[j] = append(byEnd[j], m)
+		}
+	}
+
+	best := make([]sequenceSteps, n)
+	for k := 0; k < n; k++ {
+		best[k] = make(sequenceSteps)
+		for _, m := range byEnd[k] {
+			if m.i == 0 {
+				best[k].consider(1, m.guesses, m)
+				continue
+			}
+			for l, prev := range best[m.i-1] {
+				best[k].consider(l+1, prev.product*m.guesses, m)
+			}
+		}
+	}
+
+	bestLen, bestGuesses := 0, math.Inf(1)
+	for l, s := range best[n-1] {
+		if s.guesses < bestGuesses || (s.guesses == bestGuesses && l < bestLen) {
+			bestLen, bestGuesses = l, s.guesses
+		}
+	}
+
+	sequence := make([]passwordMatch, 0, bestLen)
+	for k, l := n-1, bestLen; l > 0; l-- {
+		m := best[k][l].match
+		sequence = append(sequence, m)
+		k = m.i - 1
+	}
+	return bestGuesses, sequence
+}
+
+// sequenceStep — лучшее найденное разбиение начала пароля на части.
+type sequenceStep struct {
+	product float64       // Произведение оценок частей
+	guesses float64       // Оценка всего разбиения
+	match   passwordMatch // Последняя часть
+}
+
+// sequenceSteps — лучшие разбиения начала пароля по числу частей.
+type sequenceSteps map[int]sequenceStep
+
+// consider запоминает разбиение на l частей, если оно проще известного.
+func (steps sequenceSteps) consider(l int, product float64, m passwordMatch) {
+	guesses := factorial(l)*product + math.Pow(sequencePenalty, float64(l-1))
+	if current, ok := steps[l]; ok && current.guesses <= guesses {
+		return
+	}
+	steps[l] = sequenceStep{product: product, guesses: guesses, match: m}
+}
+
+// minMatchGuesses — наименьшая оценка части пароля в зависимости от ее длины.
+func minMatchGuesses(m passwordMatch) float64 {
+	if m.i == m.j {
+		return minSingleCharGuesses
+	}
+	return minMultiCharGuesses
+}
+
+// guessesToScore переводит число попыток в шкалу 0–4, как в zxcvbn.
+func guessesToScore(guesses float64) int {
+	const delta = 5
+	switch {
+	case guesses < 1e3+delta:
+		return 0
+	case guesses < 1e6+delta:
+		return 1
+	case guesses < 1e8+delta:
+		return 2
+	case guesses < 1e10+delta:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// sequenceWarning объясняет слабость пароля по самой длинной узнанной части.
+// Если ни один шаблон не узнан, пароль слаб только из-за длины.
+func sequenceWarning(sequence []passwordMatch) string {
+	warning, longest := warningTooShort, 0
+	for _, m := range sequence {
+		if m.warning != "" && m.j-m.i+1 > longest {
+			warning, longest = m.warning, m.j-m.i+1
+		}
+	}
+	return warning
+}
+
+// toLowerRunes переводит символы в нижний регистр по одному, сохраняя их позиции.
+func toLowerRunes(runes []rune) []rune {
+	lower := make([]rune, len(runes))
+	for k, r := range runes {
+		lower[k] = unicode.ToLower(r)
+	}
+	return lower
+}
+
+// reverseRunes возвращает символы в обратном порядке.
+func reverseRunes(runes []rune) []rune {
+	reversed := make([]rune, len(runes))
+	for k, r := range runes {
+		reversed[len(runes)-1-k] = r
+	}
+	return reversed
+}
+
+// binomial возвращает число сочетаний из n по k.
+func binomial(n, k int) float64 {
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+	return result
+}
+
+// factorial возвращает n!.
+func factorial(n int) float64 {
+	result := 1.0
+	for i := 2; i <= n; i++ {
+		result *= float64(i)
+	}
+	return result
+}
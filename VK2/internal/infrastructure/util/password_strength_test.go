@@ -0,0 +1,89 @@
+package util
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestEstimatePasswordStrength(t *testing.T) {
+	userInputs := []string{"alice", "alice.smith@example.com"}
+	tests := []struct {
+		password    string
+		wantScore   int
+		wantWarning string
+	}{
+		{"password", 0, warningCommonPassword},
+		{"P@ssw0rd", 0, warningCommonPassword},
+		{"drowssap", 0, warningCommonPassword},
+		{"abcdefgh", 0, warningSequence},
+		{"aaaaaaaa", 0, warningRepeat},
+		{"abcabcabc", 0, warningRepeat},
+		{"1999", 0, warningYear},
+		{"qwertyuiop", 1, warningKeyboard},
+		{"zxcvbnm,./", 1, warningKeyboard},
+		{"alice123", 1, warningUserInput},
+		{"Alice2024!", 2, warningUserInput},
+		// Случайный пароль минимальной длины проходит оценку по умолчанию
+		{"Xk9#mQ2!", 2, warningTooShort},
+		{"Пароль-Ёж-42", 3, warningCommonPassword},
+		{"Xk9#mQ2!vT4$", 4, warningTooShort},
+		{"correct horse battery staple", 4, warningTooShort},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			got := EstimatePasswordStrength(tt.password, userInputs...)
+			if got.Score != tt.wantScore || got.Warning != tt.wantWarning {
+				t.Errorf("EstimatePasswordStrength(%q) = %d, %q, want %d, %q", tt.password, got.Score, got.Warning, tt.wantScore, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestEstimatePasswordStrengthEmpty(t *testing.T) {
+	if got := EstimatePasswordStrength(""); got.Score != 0 || got.Guesses != 1 {
+		t.Errorf("EstimatePasswordStrength(\"\") = %+v, want score 0 and 1 guess", got)
+	}
+}
+
+func TestEstimatePasswordStrengthUserInputs(t *testing.T) {
+	without := EstimatePasswordStrength("alice123")
+	with := EstimatePasswordStrength("alice123", "alice")
+	if with.Guesses >= without.Guesses {
+		t.Errorf("guesses with login = %g, without = %g, want fewer with login", with.Guesses, without.Guesses)
+	}
+}
+
+func TestEstimatePasswordStrengthYears(t *testing.T) {
+	// Близкие к текущему годы подбираются раньше далеких
+	current := strconv.Itoa(time.Now().Year())
+	recent := EstimatePasswordStrength("Kx7#" + current)
+	distant := EstimatePasswordStrength("Kx7#1900")
+	if recent.Warning != warningYear || distant.Warning != warningYear {
+		t.Fatalf("warnings = %q, %q, want %q", recent.Warning, distant.Warning, warningYear)
+	}
+	if recent.Guesses >= distant.Guesses {
+		t.Errorf("guesses for %s = %g, for 1900 = %g, want fewer for the current year", current, recent.Guesses, distant.Guesses)
+	}
+}
+
+func TestGuessesToScore(t *testing.T) {
+	tests := []struct {
+		guesses float64
+		want    int
+	}{
+		{1, 0},
+		{1e3, 0},
+		{1e3 + 5, 1},
+		{1e6, 1},
+		{1e8, 2},
+		{1e8 + 5, 3},
+		{1e10, 3},
+		{1e12, 4},
+	}
+	for _, tt := range tests {
+		if got := guessesToScore(tt.guesses); got != tt.want {
+			t.Errorf("guessesToScore(%g) = %d, want %d", tt.guesses, got, tt.want)
+		}
+	}
+}
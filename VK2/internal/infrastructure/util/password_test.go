@@ -0,0 +1,251 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2idParams — дешевые параметры, чтобы тесты не тратили память и время.
+var testArgon2idParams = Argon2idParams{
+	Memory:      64,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func newTestBcryptHasher(t *testing.T, cost int) *BcryptHasher {
+	t.Helper()
+	hasher, err := NewBcryptHasher(cost)
+	if err != nil {
+		t.Fatalf("NewBcryptHasher(%d) error = %v", cost, err)
+	}
+	return hasher
+}
+
+func newTestArgon2idHasher(t *testing.T, params Argon2idParams) *Argon2idHasher {
+	t.Helper()
+	hasher, err := NewArgon2idHasher(params)
+	if err != nil {
+		t.Fatalf("NewArgon2idHasher(%+v) error = %v", params, err)
+	}
+	return hasher
+}
+
+func TestPasswordHashersRoundTrip(t *testing.T) {
+	longPassword := strings.Repeat("a", 80)
+	hashers := map[string]PasswordHasher{
+		"bcrypt":   newTestBcryptHasher(t, bcrypt.MinCost),
+		"argon2id": newTestArgon2idHasher(t, testArgon2idParams),
+	}
+	tests := []struct {
+		name      string
+		password  string
+		candidate string
+		wantOK    bool
+	}{
+		{"correct password", "Correct-Horse-42", "Correct-Horse-42", true},
+		{"wrong password", "Correct-Horse-42", "Correct-Horse-43", false},
+		{"empty candidate", "Correct-Horse-42", "", false},
+		{"unicode password", "пароль-Ёж-42", "пароль-Ёж-42", true},
+		{"long password", longPassword, longPassword, true},
+		// bcrypt сам отбросил бы все после 72-го байта
+		{"long password differs after 72 bytes", longPassword, longPassword[:79] + "b", false},
+	}
+	for name, hasher := range hashers {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				hash, err := hasher.Hash(tt.password)
+				if err != nil {
+					t.Fatalf("Hash() error = %v", err)
+				}
+				if !hasher.Recognizes(hash) {
+					t.Fatalf("Recognizes(%q) = false", hash)
+				}
+				ok, needsRehash, err := hasher.Verify(tt.candidate, hash)
+				if err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				if ok != tt.wantOK || needsRehash {
+					t.Errorf("Verify() = %v, %v, want %v, false", ok, needsRehash, tt.wantOK)
+				}
+			})
+		}
+	}
+}
+
+func TestArgon2idHashFormat(t *testing.T) {
+	hasher := newTestArgon2idHasher(t, testArgon2idParams)
+	first, err := hasher.Hash("Correct-Horse-42")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if !strings.HasPrefix(first, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("Hash() = %q, want PHC string with the configured parameters", first)
+	}
+	second, err := hasher.Hash("Correct-Horse-42")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if first == second {
+		t.Error("two hashes of the same password are equal, want different salts")
+	}
+}
+
+func TestBcryptNeedsRehashOnCostChange(t *testing.T) {
+	hash, err := newTestBcryptHasher(t, bcrypt.MinCost).Hash("Correct-Horse-42")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	ok, needsRehash, err := newTestBcryptHasher(t, bcrypt.MinCost+1).Verify("Correct-Horse-42", hash)
+	if err != nil || !ok || !needsRehash {
+		t.Errorf("Verify() with other cost = %v, %v, %v, want true, true, nil", ok, needsRehash, err)
+	}
+	// Неверный пароль не требует пересчета
+	ok, needsRehash, err = newTestBcryptHasher(t, bcrypt.MinCost+1).Verify("wrong", hash)
+	if err != nil || ok || needsRehash {
+		t.Errorf("Verify() with wrong password = %v, %v, %v, want false, false, nil", ok, needsRehash, err)
+	}
+}
+
+func TestArgon2idNeedsRehashOnParamsChange(t *testing.T) {
+	hash, err := newTestArgon2idHasher(t, testArgon2idParams).Hash("Correct-Horse-42")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	changes := map[string]func(*Argon2idParams){
+		"memory":      func(p *Argon2idParams) { p.Memory *= 2 },
+		"iterations":  func(p *Argon2idParams) { p.Iterations++ },
+		"parallelism": func(p *Argon2idParams) { p.Parallelism++ },
+		"salt length": func(p *Argon2idParams) { p.SaltLength = 32 },
+		"key length":  func(p *Argon2idParams) { p.KeyLength = 64 },
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			params := testArgon2idParams
+			change(&params)
+			// Хеш проверяется с параметрами из самого хеша
+			ok, needsRehash, err := newTestArgon2idHasher(t, params).Verify("Correct-Horse-42", hash)
+			if err != nil || !ok || !needsRehash {
+				t.Errorf("Verify() = %v, %v, %v, want true, true, nil", ok, needsRehash, err)
+			}
+		})
+	}
+}
+
+func TestArgon2idVerifyMalformedHash(t *testing.T) {
+	hasher := newTestArgon2idHasher(t, testArgon2idParams)
+	hashes := []string{
+		"$argon2id$",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHRzYWx0$a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdHNhbHRzYWx0$a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0$!!!",
+	}
+	for _, hash := range hashes {
+		if ok, _, err := hasher.Verify("password", hash); ok || err == nil {
+			t.Errorf("Verify(%q) = %v, %v, want false and error", hash, ok, err)
+		}
+	}
+}
+
+func TestNewPasswordHashersRejectInvalidParams(t *testing.T) {
+	for _, cost := range []int{bcrypt.MinCost - 1, bcrypt.MaxCost + 1} {
+		if _, err := NewBcryptHasher(cost); err == nil {
+			t.Errorf("NewBcryptHasher(%d) error = nil", cost)
+		}
+	}
+
+	invalid := []Argon2idParams{
+		{Memory: 4, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+		{Memory: 64, Iterations: 0, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+		{Memory: 64, Iterations: 1, Parallelism: 0, SaltLength: 16, KeyLength: 32},
+		{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 4, KeyLength: 32},
+		{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 8},
+	}
+	for _, params := range invalid {
+		if _, err := NewArgon2idHasher(params); err == nil {
+			t.Errorf("NewArgon2idHasher(%+v) error = nil", params)
+		}
+	}
+}
+
+func TestPasswordManagerVerify(t *testing.T) {
+	argon2idHasher := newTestArgon2idHasher(t, testArgon2idParams)
+	manager, err := NewPasswordManager(argon2idHasher)
+	if err != nil {
+		t.Fatalf("NewPasswordManager() error = %v", err)
+	}
+
+	currentHash, err := manager.Hash("Correct-Horse-42")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	bcryptHash, err := newTestBcryptHasher(t, bcrypt.MinCost).Hash("Correct-Horse-42")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	params := testArgon2idParams
+	params.Iterations++
+	oldArgon2idHash, err := newTestArgon2idHasher(t, params).Hash("Correct-Horse-42")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		password        string
+		hash            string
+		wantOK          bool
+		wantNeedsRehash bool
+	}{
+		{"current algorithm and params", "Correct-Horse-42", currentHash, true, false},
+		{"other algorithm", "Correct-Horse-42", bcryptHash, true, true},
+		{"other params", "Correct-Horse-42", oldArgon2idHash, true, true},
+		{"wrong password", "wrong", bcryptHash, false, false},
+		{"unknown format", "Correct-Horse-42", "plain:Correct-Horse-42", false, false},
+		{"malformed hash", "Correct-Horse-42", "$argon2id$broken", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash := manager.Verify(tt.password, tt.hash)
+			if ok != tt.wantOK || needsRehash != tt.wantNeedsRehash {
+				t.Errorf("Verify() = %v, %v, want %v, %v", ok, needsRehash, tt.wantOK, tt.wantNeedsRehash)
+			}
+		})
+	}
+}
+
+func TestPasswordManagerRehashRoundTrip(t *testing.T) {
+	// Пароль, захешированный bcrypt, после смены алгоритма пересчитывается и
+	// дальше проверяется без пометки для пересчета
+	bcryptManager, err := NewPasswordManager(newTestBcryptHasher(t, bcrypt.MinCost))
+	if err != nil {
+		t.Fatalf("NewPasswordManager() error = %v", err)
+	}
+	hash, err := bcryptManager.Hash("Correct-Horse-42")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	argon2idManager, err := NewPasswordManager(newTestArgon2idHasher(t, testArgon2idParams))
+	if err != nil {
+		t.Fatalf("NewPasswordManager() error = %v", err)
+	}
+	ok, needsRehash := argon2idManager.Verify("Correct-Horse-42", hash)
+	if !ok || !needsRehash {
+		t.Fatalf("Verify(bcrypt hash) = %v, %v, want true, true", ok, needsRehash)
+	}
+	rehashed, err := argon2idManager.Hash("Correct-Horse-42")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if ok, needsRehash := argon2idManager.Verify("Correct-Horse-42", rehashed); !ok || needsRehash {
+		t.Errorf("Verify(rehashed) = %v, %v, want true, false", ok, needsRehash)
+	}
+}
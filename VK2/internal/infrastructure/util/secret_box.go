@@ -0,0 +1,63 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrSecretBoxOpen возвращается, если данные повреждены или зашифрованы другим ключом.
+var ErrSecretBoxOpen = errors.New("не удалось расшифровать данные")
+
+// SecretBox шифрует строки AES-256-GCM для хранения в БД. Используется для
+// данных, которые нужно прочитать позже (например, письма с токенами в
+// очереди), поэтому хеширование для них не подходит.
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// NewSecretBox создает SecretBox. Ключ AES выводится из секрета через SHA-256,
+// поэтому секрет может быть произвольной длины.
+func NewSecretBox(secret []byte) (*SecretBox, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("не задан ключ шифрования")
+	}
+	key := sha256.Sum256(secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать шифр: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать шифр: %w", err)
+	}
+	return &SecretBox{aead: aead}, nil
+}
+
+// Seal шифрует строку и возвращает nonce и шифртекст в кодировке Base64 URL.
+func (b *SecretBox) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать nonce: %w", err)
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open расшифровывает строку, зашифрованную Seal.
+func (b *SecretBox) Open(sealed string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil || len(data) < b.aead.NonceSize() {
+		return "", ErrSecretBoxOpen
+	}
+	nonce, ciphertext := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrSecretBoxOpen
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,85 @@
+package util
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTestSecretBox(t *testing.T, secret string) *SecretBox {
+	t.Helper()
+	box, err := NewSecretBox([]byte(secret))
+	if err != nil {
+		t.Fatalf("NewSecretBox() error = %v", err)
+	}
+	return box
+}
+
+func TestSecretBoxRoundTrip(t *testing.T) {
+	box := newTestSecretBox(t, "outbox-secret")
+	plaintext := "Ссылка для сброса пароля: https://example.com/reset?token=abc"
+
+	sealed, err := box.Seal(plaintext)
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if strings.Contains(sealed, "token=abc") {
+		t.Errorf("Seal() = %q, contains plaintext", sealed)
+	}
+	again, err := box.Seal(plaintext)
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if again == sealed {
+		t.Error("Seal() returned the same ciphertext twice, want random nonce")
+	}
+
+	got, err := box.Open(sealed)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got != plaintext {
+		t.Errorf("Open() = %q, want %q", got, plaintext)
+	}
+}
+
+func TestSecretBoxOpenRejects(t *testing.T) {
+	box := newTestSecretBox(t, "outbox-secret")
+	sealed, err := box.Seal("body")
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	// Последний символ Base64 может нести незначащие биты, поэтому меняется средний
+	tampered := []byte(sealed)
+	if tampered[len(tampered)/2] == 'A' {
+		tampered[len(tampered)/2] = 'B'
+	} else {
+		tampered[len(tampered)/2] = 'A'
+	}
+
+	tests := []struct {
+		name   string
+		box    *SecretBox
+		sealed string
+	}{
+		{"other key", newTestSecretBox(t, "other-secret"), sealed},
+		{"tampered", box, string(tampered)},
+		{"plaintext", box, "body"},
+		{"too short", box, "AAAA"},
+		{"empty", box, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.box.Open(tt.sealed); !errors.Is(err, ErrSecretBoxOpen) {
+				t.Errorf("Open() error = %v, want ErrSecretBoxOpen", err)
+			}
+		})
+	}
+}
+
+func TestNewSecretBoxRejectsEmptySecret(t *testing.T) {
+	if _, err := NewSecretBox(nil); err == nil {
+		t.Error("NewSecretBox(nil) error = nil, want error")
+	}
+}
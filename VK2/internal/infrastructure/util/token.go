@@ -1,79 +1,251 @@
 package util
 
 import (
-	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"strconv"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 var (
 	ErrInvalidToken = errors.New("неверный или истекший токен")
 )
 
-// GenerateToken генерирует простой токен, состоящий из userID и временной метки,
-// подписанный с использованием HMAC-SHA256.
-// Формат токена: Base64(userID.timestamp.HMAC_Signature)
-func GenerateToken(userID string, secretKey string, expiration time.Duration) (string, error) {
-	// Создаем временную метку истечения срока действия
-	expiresAt := time.Now().Add(expiration).Unix()
+// clockSkew — допустимое расхождение часов при проверке exp и iat.
+const clockSkew = 30 * time.Second
 
-	// Составляем данные для подписи: userID.expiresAt
-	dataToSign := fmt.Sprintf("%s.%d", userID, expiresAt)
+// TokenClaims содержит данные access-токена.
+type TokenClaims struct {
+	ID          string // jti
+	UserID      string // sub
+	SessionID   string // sid
+	Role        string
+	Permissions []string
+	IssuedAt    time.Time
+	ExpiresAt   time.Time
+}
 
-	// Создаем HMAC-подпись
-	h := hmac.New(sha256.New, []byte(secretKey))
-	h.Write([]byte(dataToSign))
-	signature := base64.URLEncoding.EncodeToString(h.Sum(nil))
+// TokenManager выпускает и проверяет JWT (RFC 7519). Новые токены подписываются
+// текущим ключом, а проверяются любым из активных ключей по заголовку kid,
+// что позволяет ротировать ключи без завершения всех сессий.
+type TokenManager struct {
+	signingKey *SigningKey
+	keys       map[string]*SigningKey
+	issuer     string
+	audience   string
+}
 
-	token := fmt.Sprintf("%s.%d.%s", userID, expiresAt, signature)
+// NewTokenManager создает TokenManager. signingKey используется для подписи,
+// verificationKeys — только для проверки ранее выпущенных токенов.
+func NewTokenManager(signingKey *SigningKey, verificationKeys []*SigningKey, issuer, audience string) (*TokenManager, error) {
+	if signingKey == nil || !signingKey.CanSign() {
+		return nil, errors.New("не задан ключ подписи токенов")
+	}
 
-	encodedToken := base64.URLEncoding.EncodeToString([]byte(token))
+	keys := map[string]*SigningKey{signingKey.ID: signingKey}
+	for _, key := range verificationKeys {
+		if _, exists := keys[key.ID]; exists {
+			return nil, fmt.Errorf("ключ %s указан несколько раз", key.ID)
+		}
+		keys[key.ID] = key
+	}
+
+	return &TokenManager{
+		signingKey: signingKey,
+		keys:       keys,
+		issuer:     issuer,
+		audience:   audience,
+	}, nil
+}
 
-	return encodedToken, nil
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+	KeyID     string `json:"kid,omitempty"`
+}
+
+type jwtClaims struct {
+	Issuer      string      `json:"iss"`
+	Subject     string      `json:"sub"`
+	Audience    jwtAudience `json:"aud"`
+	IssuedAt    int64       `json:"iat"`
+	ExpiresAt   int64       `json:"exp"`
+	ID          string      `json:"jti"`
+	SessionID   string      `json:"sid,omitempty"`
+	Role        string      `json:"role,omitempty"`
+	Permissions []string    `json:"perms,omitempty"`
+}
+
+// jwtAudience поддерживает обе формы aud из RFC 7519: строку и массив строк.
+type jwtAudience []string
+
+func (a jwtAudience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
 }
 
-// ParseToken парсит и валидирует кастомный токен, возвращая ID пользователя.
-func ParseToken(tokenString string, secretKey string) (string, error) {
-	// Декодируем токен из Base64
-	decodedBytes, err := base64.URLEncoding.DecodeString(tokenString)
+func (a *jwtAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = jwtAudience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a jwtAudience) contains(audience string) bool {
+	for _, v := range a {
+		if v == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateToken выпускает подписанный JWT. Поля ID, IssuedAt и ExpiresAt
+// заполняются автоматически.
+func (m *TokenManager) GenerateToken(tokenClaims TokenClaims, expiration time.Duration) (string, error) {
+	now := time.Now()
+	claims := jwtClaims{
+		Issuer:      m.issuer,
+		Subject:     tokenClaims.UserID,
+		Audience:    jwtAudience{m.audience},
+		IssuedAt:    now.Unix(),
+		ExpiresAt:   now.Add(expiration).Unix(),
+		ID:          uuid.New().String(),
+		SessionID:   tokenClaims.SessionID,
+		Role:        tokenClaims.Role,
+		Permissions: tokenClaims.Permissions,
+	}
+
+	header, err := json.Marshal(jwtHeader{Algorithm: m.signingKey.Algorithm, Type: "JWT", KeyID: m.signingKey.ID})
 	if err != nil {
-		return "", ErrInvalidToken
+		return "", fmt.Errorf("не удалось сериализовать заголовок токена: %w", err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("не удалось сериализовать утверждения токена: %w", err)
 	}
-	decodedToken := string(decodedBytes)
 
-	parts := strings.Split(decodedToken, ".")
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature, err := m.signingKey.sign([]byte(signingInput))
+	if err != nil {
+		return "", fmt.Errorf("не удалось подписать токен: %w", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// ParseToken проверяет подпись, издателя, аудиторию и срок действия JWT и
+// возвращает его содержимое.
+func (m *TokenManager) ParseToken(tokenString string) (*TokenClaims, error) {
+	parts := strings.Split(tokenString, ".")
 	if len(parts) != 3 {
-		return "", ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
-	userID := parts[0]
-	expiresAtStr := parts[1]
-	receivedSignature := parts[2]
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
 
-	// Проверяем срок действия
-	expiresAt, err := strconv.ParseInt(expiresAtStr, 10, 64)
-	if err != nil {
-		return "", ErrInvalidToken
+	// Алгоритм определяется ключом, а не заголовком: это исключает подмену
+	// алгоритма (alg=none, HS256 с открытым ключом в качестве секрета)
+	key, ok := m.keys[header.KeyID]
+	if !ok || header.Algorithm != key.Algorithm {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !key.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return nil, ErrInvalidToken
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now()
+	if claims.Issuer != m.issuer || !claims.Audience.contains(m.audience) || claims.Subject == "" || claims.ID == "" {
+		return nil, ErrInvalidToken
 	}
-	if time.Now().Unix() > expiresAt {
-		return "", ErrInvalidToken // Токен истек
+	if now.Add(-clockSkew).Unix() > claims.ExpiresAt {
+		return nil, ErrInvalidToken // Токен истек
 	}
+	if claims.IssuedAt > now.Add(clockSkew).Unix() {
+		return nil, ErrInvalidToken // Токен выпущен в будущем
+	}
+
+	return &TokenClaims{
+		ID:          claims.ID,
+		UserID:      claims.Subject,
+		SessionID:   claims.SessionID,
+		Role:        claims.Role,
+		Permissions: claims.Permissions,
+		IssuedAt:    time.Unix(claims.IssuedAt, 0).UTC(),
+		ExpiresAt:   time.Unix(claims.ExpiresAt, 0).UTC(),
+	}, nil
+}
+
+// JWKS возвращает набор открытых асимметричных ключей для проверки токенов
+// сторонними сервисами. Симметричные ключи не публикуются.
+func (m *TokenManager) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	// Текущий ключ подписи публикуется первым
+	if m.signingKey.IsAsymmetric() {
+		set.Keys = append(set.Keys, m.signingKey.JWK())
+	}
+	ids := make([]string, 0, len(m.keys))
+	for id, key := range m.keys {
+		if id != m.signingKey.ID && key.IsAsymmetric() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		set.Keys = append(set.Keys, m.keys[id].JWK())
+	}
+	return set
+}
 
-	// Повторно генерируем подпись для проверки
-	dataToSign := fmt.Sprintf("%s.%d", userID, expiresAt)
-	h := hmac.New(sha256.New, []byte(secretKey))
-	h.Write([]byte(dataToSign))
-	expectedSignature := base64.URLEncoding.EncodeToString(h.Sum(nil))
+// decodeSegment декодирует Base64URL-сегмент JWT в JSON-структуру.
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
 
-	// Сравниваем подписи
-	if !hmac.Equal([]byte(receivedSignature), []byte(expectedSignature)) {
-		return "", ErrInvalidToken
+// GenerateOpaqueToken генерирует случайный непрозрачный токен (например, refresh-токен)
+// длиной 32 байта в кодировке Base64 URL.
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать случайные байты: %w", err)
 	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	return userID, nil
+// HashOpaqueToken возвращает SHA-256 хеш непрозрачного токена в hex-кодировке.
+// В хранилище сохраняется только хеш, чтобы утечка БД не давала действующих токенов.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
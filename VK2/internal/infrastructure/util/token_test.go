@@ -0,0 +1,424 @@
+package util
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "vk-marketplace"
+	testAudience = "vk-marketplace-api"
+)
+
+// rfc7638Modulus — модуль RSA-ключа из примера RFC 7638 (раздел 3.1).
+const rfc7638Modulus = "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"
+
+// encodePEM кодирует ключ в PEM так же, как его передают в JWT_PRIVATE_KEY_FILE.
+func encodePEM(t *testing.T, key any) []byte {
+	t.Helper()
+	var block *pem.Block
+	switch k := key.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		der, err := x509.MarshalPKIXPublicKey(k)
+		if err != nil {
+			t.Fatalf("MarshalPKIXPublicKey() error = %v", err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	default:
+		der, err := x509.MarshalPKCS8PrivateKey(k)
+		if err != nil {
+			t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	return pem.EncodeToMemory(block)
+}
+
+func parseTestKey(t *testing.T, key any) *SigningKey {
+	t.Helper()
+	parsed, err := ParseKeyPEM(encodePEM(t, key))
+	if err != nil {
+		t.Fatalf("ParseKeyPEM() error = %v", err)
+	}
+	return parsed
+}
+
+func newTestRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+	return key
+}
+
+func newTestEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey() error = %v", err)
+	}
+	return key
+}
+
+func newTestTokenManager(t *testing.T, signingKey *SigningKey, verificationKeys ...*SigningKey) *TokenManager {
+	t.Helper()
+	manager, err := NewTokenManager(signingKey, verificationKeys, testIssuer, testAudience)
+	if err != nil {
+		t.Fatalf("NewTokenManager() error = %v", err)
+	}
+	return manager
+}
+
+// validClaims возвращает утверждения, которые TokenManager принял бы.
+func validClaims(now time.Time) jwtClaims {
+	return jwtClaims{
+		Issuer:    testIssuer,
+		Subject:   "user-1",
+		Audience:  jwtAudience{testAudience},
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(15 * time.Minute).Unix(),
+		ID:        "token-1",
+		SessionID: "session-1",
+		Role:      "user",
+	}
+}
+
+// encodeSegment сериализует заголовок или утверждения в сегмент JWT.
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// signTestToken собирает JWT с произвольными заголовком и утверждениями и
+// подписывает его ключом key.
+func signTestToken(t *testing.T, key *SigningKey, header jwtHeader, claims jwtClaims) string {
+	t.Helper()
+	signingInput := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	signature, err := key.sign([]byte(signingInput))
+	if err != nil {
+		t.Fatalf("sign() error = %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestTokenRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		key  *SigningKey
+	}{
+		{"HS256", NewHMACKey("test-secret")},
+		{"RS256", parseTestKey(t, newTestRSAKey(t))},
+		{"EdDSA", parseTestKey(t, newTestEd25519Key(t))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.key.Algorithm != tt.name {
+				t.Fatalf("Algorithm = %s, want %s", tt.key.Algorithm, tt.name)
+			}
+			manager := newTestTokenManager(t, tt.key)
+			token, err := manager.GenerateToken(TokenClaims{
+				UserID:      "user-1",
+				SessionID:   "session-1",
+				Role:        "admin",
+				Permissions: []string{"roles:manage"},
+			}, 15*time.Minute)
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+
+			claims, err := manager.ParseToken(token)
+			if err != nil {
+				t.Fatalf("ParseToken() error = %v", err)
+			}
+			if claims.UserID != "user-1" || claims.SessionID != "session-1" || claims.Role != "admin" {
+				t.Errorf("ParseToken() = %+v, want user-1/session-1/admin", claims)
+			}
+			if len(claims.Permissions) != 1 || claims.Permissions[0] != "roles:manage" {
+				t.Errorf("Permissions = %v, want [roles:manage]", claims.Permissions)
+			}
+			if claims.ID == "" {
+				t.Error("ID is empty, want generated jti")
+			}
+			if got := claims.ExpiresAt.Sub(claims.IssuedAt); got != 15*time.Minute {
+				t.Errorf("ExpiresAt - IssuedAt = %v, want 15m", got)
+			}
+		})
+	}
+}
+
+func TestParseTokenRejects(t *testing.T) {
+	hmacKey := NewHMACKey("test-secret")
+	rsaPrivateKey := newTestRSAKey(t)
+	rsaKey := parseTestKey(t, rsaPrivateKey)
+	otherHMACKey := NewHMACKey("other-secret")
+	manager := newTestTokenManager(t, rsaKey, hmacKey)
+	now := time.Now()
+
+	header := func(key *SigningKey) jwtHeader {
+		return jwtHeader{Algorithm: key.Algorithm, Type: "JWT", KeyID: key.ID}
+	}
+	withClaims := func(modify func(*jwtClaims)) jwtClaims {
+		claims := validClaims(now)
+		modify(&claims)
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token func(t *testing.T) string
+	}{
+		{
+			name: "alg none",
+			token: func(t *testing.T) string {
+				h := jwtHeader{Algorithm: "none", Type: "JWT", KeyID: rsaKey.ID}
+				return encodeSegment(t, h) + "." + encodeSegment(t, validClaims(now)) + "."
+			},
+		},
+		{
+			name: "alg none without kid",
+			token: func(t *testing.T) string {
+				h := jwtHeader{Algorithm: "none", Type: "JWT"}
+				return encodeSegment(t, h) + "." + encodeSegment(t, validClaims(now)) + "."
+			},
+		},
+		{
+			name: "alg does not match kid",
+			token: func(t *testing.T) string {
+				h := jwtHeader{Algorithm: AlgRS256, Type: "JWT", KeyID: hmacKey.ID}
+				return signTestToken(t, hmacKey, h, validClaims(now))
+			},
+		},
+		{
+			name: "HS256 signed with RSA public key",
+			token: func(t *testing.T) string {
+				h := jwtHeader{Algorithm: AlgHS256, Type: "JWT", KeyID: rsaKey.ID}
+				signingInput := encodeSegment(t, h) + "." + encodeSegment(t, validClaims(now))
+				mac := hmac.New(sha256.New, encodePEM(t, &rsaPrivateKey.PublicKey))
+				mac.Write([]byte(signingInput))
+				return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+			},
+		},
+		{
+			name: "unknown kid",
+			token: func(t *testing.T) string {
+				return signTestToken(t, otherHMACKey, header(otherHMACKey), validClaims(now))
+			},
+		},
+		{
+			name: "known kid signed by another key",
+			token: func(t *testing.T) string {
+				return signTestToken(t, otherHMACKey, header(hmacKey), validClaims(now))
+			},
+		},
+		{
+			name: "wrong issuer",
+			token: func(t *testing.T) string {
+				return signTestToken(t, rsaKey, header(rsaKey), withClaims(func(c *jwtClaims) { c.Issuer = "evil" }))
+			},
+		},
+		{
+			name: "wrong audience",
+			token: func(t *testing.T) string {
+				return signTestToken(t, rsaKey, header(rsaKey), withClaims(func(c *jwtClaims) { c.Audience = jwtAudience{"other-api"} }))
+			},
+		},
+		{
+			name: "expired beyond skew",
+			token: func(t *testing.T) string {
+				return signTestToken(t, rsaKey, header(rsaKey), withClaims(func(c *jwtClaims) {
+					c.IssuedAt = now.Add(-time.Hour).Unix()
+					c.ExpiresAt = now.Add(-clockSkew - 5*time.Second).Unix()
+				}))
+			},
+		},
+		{
+			name: "issued in the future beyond skew",
+			token: func(t *testing.T) string {
+				return signTestToken(t, rsaKey, header(rsaKey), withClaims(func(c *jwtClaims) {
+					c.IssuedAt = now.Add(clockSkew + 5*time.Second).Unix()
+				}))
+			},
+		},
+		{
+			name: "tampered payload",
+			token: func(t *testing.T) string {
+				parts := strings.Split(signTestToken(t, rsaKey, header(rsaKey), validClaims(now)), ".")
+				parts[1] = encodeSegment(t, withClaims(func(c *jwtClaims) { c.Role = "admin" }))
+				return strings.Join(parts, ".")
+			},
+		},
+		{
+			name:  "malformed",
+			token: func(t *testing.T) string { return "not-a-jwt" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := manager.ParseToken(tt.token(t))
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("ParseToken() = %+v, %v, want ErrInvalidToken", claims, err)
+			}
+		})
+	}
+}
+
+func TestParseTokenClockSkew(t *testing.T) {
+	key := NewHMACKey("test-secret")
+	manager := newTestTokenManager(t, key)
+	now := time.Now()
+	header := jwtHeader{Algorithm: key.Algorithm, Type: "JWT", KeyID: key.ID}
+
+	// Расхождение часов в пределах clockSkew не делает токен недействительным
+	claims := validClaims(now)
+	claims.IssuedAt = now.Add(clockSkew - 10*time.Second).Unix()
+	if _, err := manager.ParseToken(signTestToken(t, key, header, claims)); err != nil {
+		t.Errorf("ParseToken(iat within skew) error = %v", err)
+	}
+
+	claims = validClaims(now)
+	claims.IssuedAt = now.Add(-time.Hour).Unix()
+	claims.ExpiresAt = now.Add(-clockSkew + 10*time.Second).Unix()
+	if _, err := manager.ParseToken(signTestToken(t, key, header, claims)); err != nil {
+		t.Errorf("ParseToken(exp within skew) error = %v", err)
+	}
+}
+
+func TestParseTokenAudienceArray(t *testing.T) {
+	key := NewHMACKey("test-secret")
+	manager := newTestTokenManager(t, key)
+	claims := validClaims(time.Now())
+	claims.Audience = jwtAudience{"other-api", testAudience}
+
+	token := signTestToken(t, key, jwtHeader{Algorithm: key.Algorithm, Type: "JWT", KeyID: key.ID}, claims)
+	if _, err := manager.ParseToken(token); err != nil {
+		t.Errorf("ParseToken(aud array) error = %v", err)
+	}
+}
+
+func TestTokenKeyRotation(t *testing.T) {
+	rsaPrivateKey := newTestRSAKey(t)
+	tests := []struct {
+		name        string
+		oldKey      *SigningKey
+		oldVerifier *SigningKey // Ключ, которым старые токены проверяются после ротации
+		newKey      *SigningKey
+	}{
+		{
+			name:        "HS256",
+			oldKey:      NewHMACKey("old-secret"),
+			oldVerifier: NewHMACKey("old-secret"),
+			newKey:      NewHMACKey("new-secret"),
+		},
+		{
+			name:        "RS256 to EdDSA",
+			oldKey:      parseTestKey(t, rsaPrivateKey),
+			oldVerifier: parseTestKey(t, &rsaPrivateKey.PublicKey),
+			newKey:      parseTestKey(t, newTestEd25519Key(t)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldToken, err := newTestTokenManager(t, tt.oldKey).GenerateToken(TokenClaims{UserID: "user-1"}, time.Minute)
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+
+			rotated := newTestTokenManager(t, tt.newKey, tt.oldVerifier)
+			if _, err := rotated.ParseToken(oldToken); err != nil {
+				t.Errorf("ParseToken(old token) after rotation error = %v", err)
+			}
+			newToken, err := rotated.GenerateToken(TokenClaims{UserID: "user-1"}, time.Minute)
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+			if _, err := rotated.ParseToken(newToken); err != nil {
+				t.Errorf("ParseToken(new token) error = %v", err)
+			}
+
+			// Когда прежний ключ выведен из проверки, его токены отклоняются
+			retired := newTestTokenManager(t, tt.newKey)
+			if _, err := retired.ParseToken(oldToken); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("ParseToken(old token) without old key error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestNewTokenManagerRejectsVerificationOnlySigningKey(t *testing.T) {
+	publicKey := parseTestKey(t, &newTestRSAKey(t).PublicKey)
+	if _, err := NewTokenManager(publicKey, nil, testIssuer, testAudience); err == nil {
+		t.Error("NewTokenManager(public key) error = nil, want error")
+	}
+}
+
+func TestJWKThumbprintRFC7638(t *testing.T) {
+	n, err := base64.RawURLEncoding.DecodeString(rfc7638Modulus)
+	if err != nil {
+		t.Fatalf("decode modulus: %v", err)
+	}
+	rsaKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+
+	// Пример Ed25519 из RFC 8037 (приложение A.3)
+	x, err := base64.RawURLEncoding.DecodeString("11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo")
+	if err != nil {
+		t.Fatalf("decode x: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  any
+		want string
+	}{
+		{"RSA", rsaKey, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"},
+		{"Ed25519", ed25519.PublicKey(x), "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := parseTestKey(t, tt.key)
+			if key.ID != tt.want {
+				t.Errorf("ID = %s, want %s", key.ID, tt.want)
+			}
+			if got := key.JWK().thumbprint(); got != tt.want {
+				t.Errorf("thumbprint() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWKSPublishesOnlyAsymmetricKeys(t *testing.T) {
+	signingKey := parseTestKey(t, newTestEd25519Key(t))
+	oldKey := parseTestKey(t, &newTestRSAKey(t).PublicKey)
+	manager := newTestTokenManager(t, signingKey, oldKey, NewHMACKey("old-secret"))
+
+	set := manager.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("JWKS() returned %d keys, want 2", len(set.Keys))
+	}
+	if set.Keys[0].KeyID != signingKey.ID || set.Keys[0].KeyType != "OKP" {
+		t.Errorf("first key = %+v, want current signing key", set.Keys[0])
+	}
+	if set.Keys[1].KeyID != oldKey.ID || set.Keys[1].KeyType != "RSA" || set.Keys[1].E != "AQAB" {
+		t.Errorf("second key = %+v, want old RSA key", set.Keys[1])
+	}
+}
@@ -0,0 +1,90 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Параметры TOTP (RFC 6238) — те, что поддерживают все приложения-аутентификаторы.
+const (
+	totpDigits     = 6
+	totpModulo     = 1_000_000 // 10^totpDigits
+	totpPeriod     = 30        // Секунд на один код
+	totpSecretSize = 20        // Байт, как рекомендует RFC 4226 для HMAC-SHA1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret создает случайный секрет TOTP в base32 без выравнивания.
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, totpSecretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать случайные байты: %w", err)
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPProvisioningURI возвращает ссылку otpauth:// для добавления секрета в
+// приложение-аутентификатор (обычно показывается QR-кодом).
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", strconv.Itoa(totpDigits))
+	query.Set("period", strconv.Itoa(totpPeriod))
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+account) + "?" + query.Encode()
+}
+
+// TOTPStep возвращает номер 30-секундного интервала для момента t.
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// TOTPCode вычисляет код для интервала step по RFC 4226.
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("некорректный секрет TOTP: %w", err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Динамическое усечение: 31 бит начиная со смещения из последнего полубайта
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%totpModulo), nil
+}
+
+// MatchTOTP проверяет код для момента t с допуском skew интервалов в обе стороны
+// на расхождение часов. Возвращает интервал, которому соответствует код: чтобы
+// код нельзя было использовать повторно, вызывающий запоминает последний интервал.
+func MatchTOTP(secret, code string, t time.Time, skew int) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	current := TOTPStep(t)
+	for delta := -int64(skew); delta <= int64(skew); delta++ {
+		expected, err := TOTPCode(secret, current+delta)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return current + delta, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,91 @@
+package util
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret — ключ "12345678901234567890" из тестовых векторов RFC 6238 в base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// rfc6238Vectors — тестовые векторы RFC 6238 (приложение B) для HMAC-SHA1;
+// в RFC коды восьмизначные, здесь взяты их последние 6 цифр.
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	for _, tt := range rfc6238Vectors {
+		step := TOTPStep(time.Unix(tt.unix, 0))
+		got, err := TOTPCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatalf("TOTPCode(%d) error = %v", step, err)
+		}
+		if got != tt.code {
+			t.Errorf("TOTPCode at %d = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestTOTPCodeLowercaseSecret(t *testing.T) {
+	got, err := TOTPCode(strings.ToLower(rfc6238Secret), 1)
+	if err != nil || got != "287082" {
+		t.Errorf("TOTPCode(lowercase) = %q, %v, want 287082", got, err)
+	}
+}
+
+func TestTOTPCodeInvalidSecret(t *testing.T) {
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("TOTPCode() error = nil, want error for invalid secret")
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := TOTPStep(now)
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", "050471", 1, current, true},
+		{"previous step within skew", "081804", 1, current - 1, true},
+		{"previous step without skew", "081804", 0, 0, false},
+		{"wrong code", "000000", 1, 0, false},
+		{"too short", "05047", 1, 0, false},
+		{"too long", "0504710", 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := MatchTOTP(rfc6238Secret, tt.code, now, tt.skew)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("MatchTOTP() = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("len(secret) = %d, want 32", len(secret))
+	}
+	if _, err := TOTPCode(secret, TOTPStep(time.Now())); err != nil {
+		t.Errorf("TOTPCode(generated secret) error = %v", err)
+	}
+}
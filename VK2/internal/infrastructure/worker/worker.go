@@ -0,0 +1,38 @@
+// Package worker запускает периодические фоновые задачи в процессе сервиса.
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Job выполняет одну единицу работы. Возвращает true, если работа была выполнена
+// и, возможно, есть еще: тогда следующий вызов следует сразу, без ожидания.
+type Job func() (bool, error)
+
+// Run вызывает job, пока не будет отменен ctx. Когда работы нет или произошла
+// ошибка, следующий вызов откладывается на interval.
+func Run(ctx context.Context, name string, interval time.Duration, job Job) {
+	log.Printf("Фоновая задача %s запущена (интервал %s).", name, interval)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("Фоновая задача %s остановлена.", name)
+			return
+		case <-timer.C:
+		}
+
+		wait := interval
+		done, err := job()
+		if err != nil {
+			log.Printf("Ошибка фоновой задачи %s: %v", name, err)
+		} else if done {
+			wait = 0
+		}
+		timer.Reset(wait)
+	}
+}
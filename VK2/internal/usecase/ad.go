@@ -3,6 +3,7 @@ package usecase
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,38 +13,102 @@ import (
 )
 
 var (
-	ErrAdNotFound = errors.New("ad not found")
+	ErrAdNotFound              = errors.New("ad not found")
+	ErrForbidden               = errors.New("only the owner or a moderator can modify this ad")
+	ErrInvalidStatusTransition = errors.New("ad status cannot be changed")
 )
 
 type AdUseCase struct {
-	adRepo repository.AdRepository
+	adRepo       repository.AdRepository
+	categoryRepo repository.CategoryRepository
+	rateRepo     repository.ExchangeRateRepository
+	imageRepo    repository.AdImageRepository
+	blobStorage  repository.BlobStorage
+	cursorKey    []byte        // Ключ подписи курсоров ленты
+	imageURLTTL  time.Duration // Срок действия ссылок на изображения
+	adTTL        time.Duration // Срок публикации объявления
 }
 
-func NewAdUseCase(adRepo repository.AdRepository) *AdUseCase {
-	return &AdUseCase{adRepo: adRepo}
+func NewAdUseCase(adRepo repository.AdRepository, categoryRepo repository.CategoryRepository, rateRepo repository.ExchangeRateRepository, imageRepo repository.AdImageRepository, blobStorage repository.BlobStorage, cursorKey []byte, imageURLTTL, adTTL time.Duration) *AdUseCase {
+	return &AdUseCase{
+		adRepo:       adRepo,
+		categoryRepo: categoryRepo,
+		rateRepo:     rateRepo,
+		imageRepo:    imageRepo,
+		blobStorage:  blobStorage,
+		cursorKey:    cursorKey,
+		imageURLTTL:  imageURLTTL,
+		adTTL:        adTTL,
+	}
+}
+
+// CreateAdParameters содержит поля нового объявления.
+type CreateAdParameters struct {
+	CategoryID  string
+	Title       string
+	Description string
+	Price       string     // Десятичная запись, проверяется без округления
+	Currency    string     // Пустая означает domain.DefaultCurrency
+	Status      string     // draft, published или scheduled; пустой означает published или scheduled, если задан PublishAt
+	PublishAt   *time.Time // Время отложенной публикации
 }
 
-// CreateAd создает новое объявление.
-func (uc *AdUseCase) CreateAd(userID, title, description, imageURL string, price float64) (*domain.Ad, error) {
-	// TODO: Добавить валидацию входных данных (длина, формат, цена > 0)
-	if title == "" || price <= 0 {
-		return nil, errors.New("title cannot be empty and price must be greater than 0")
+// CreateAd создает новое объявление. Изображения загружаются отдельно через UploadAdImage.
+// Объявление можно сразу опубликовать, сохранить черновиком или запланировать
+// публикацию на момент PublishAt. Срок публикации отсчитывается от момента публикации.
+func (uc *AdUseCase) CreateAd(userID string, params CreateAdParameters) (*domain.Ad, error) {
+	money, err := uc.parsePrice(params.Price, params.Currency)
+	if err != nil {
+		return nil, err
 	}
-	if len(title) > 255 {
-		return nil, errors.New("title is too long")
+	if err := validateAdFields(params.Title, params.Description, money); err != nil {
+		return nil, err
 	}
-	if len(description) > 1000 {
-		return nil, errors.New("description is too long")
+	if err := uc.validateCategory(params.CategoryID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	status, publishedAt := params.Status, now
+	if params.PublishAt != nil {
+		if status != "" && status != domain.AdStatusScheduled {
+			return nil, &ValidationErr{Message: "publish_at can only be set for a scheduled ad"}
+		}
+		if !params.PublishAt.After(now) {
+			return nil, &ValidationErr{Message: "publish_at must be in the future"}
+		}
+		status, publishedAt = domain.AdStatusScheduled, params.PublishAt.UTC()
+		params.PublishAt = &publishedAt
+	}
+	switch status {
+	case "":
+		status = domain.AdStatusPublished
+	case domain.AdStatusDraft, domain.AdStatusPublished:
+	case domain.AdStatusScheduled:
+		if params.PublishAt == nil {
+			return nil, &ValidationErr{Message: "publish_at is required for a scheduled ad"}
+		}
+	default:
+		return nil, &ValidationErr{Message: "a new ad can only be a draft, published or scheduled"}
+	}
+
+	var publishedTime *time.Time
+	if status == domain.AdStatusPublished {
+		publishedTime = &now
 	}
 
 	newAd := &domain.Ad{
 		ID:          uuid.New().String(),
 		UserID:      userID,
-		Title:       title,
-		Description: description,
-		ImageURL:    imageURL,
-		Price:       price,
-		CreatedAt:   time.Now().UTC(),
+		CategoryID:  params.CategoryID,
+		Title:       params.Title,
+		Description: params.Description,
+		Price:       money,
+		Status:      status,
+		CreatedAt:   now,
+		ExpiresAt:   publishedAt.Add(uc.adTTL),
+		PublishAt:   params.PublishAt,
+		PublishedAt: publishedTime,
 	}
 
 	if err := uc.adRepo.CreateAd(newAd); err != nil {
@@ -53,35 +118,451 @@ func (uc *AdUseCase) CreateAd(userID, title, description, imageURL string, price
 	return newAd, nil
 }
 
+// GetAd возвращает объявление по ID вместе со всеми изображениями. Черновики
+// и архивные объявления доступны только владельцу и модераторам; для остальных
+// их как будто не существует.
+func (uc *AdUseCase) GetAd(actor Actor, id string) (*domain.Ad, error) {
+	ad, err := uc.loadAd(id)
+	if err != nil {
+		return nil, err
+	}
+	if !domain.IsPubliclyVisibleAdStatus(ad.Status) && !canModifyAd(actor, ad) {
+		return nil, ErrAdNotFound
+	}
+	return ad, nil
+}
+
+// loadAd загружает объявление вместе с изображениями без проверки доступа.
+func (uc *AdUseCase) loadAd(id string) (*domain.Ad, error) {
+	// Некорректный UUID не может принадлежать ни одному объявлению
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrAdNotFound
+	}
+
+	ad, err := uc.adRepo.GetAdByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ad: %w", err)
+	}
+	if ad == nil {
+		return nil, ErrAdNotFound
+	}
+	if err := uc.loadImages(ad); err != nil {
+		return nil, err
+	}
+	return ad, nil
+}
+
+// UpdateAdParameters содержит изменяемые поля объявления. Nil-поля не изменяются.
+type UpdateAdParameters struct {
+	CategoryID  *string
+	Title       *string
+	Description *string
+	Price       *string // Десятичная запись, как в CreateAd
+	Currency    *string
+}
+
+// UpdateAd частично обновляет объявление. Изменять объявление может его владелец
+// или пользователь с разрешением на модерацию.
+func (uc *AdUseCase) UpdateAd(actor Actor, id string, params UpdateAdParameters) (*domain.Ad, error) {
+	ad, err := uc.getModifiableAd(actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.CategoryID != nil {
+		if err := uc.validateCategory(*params.CategoryID); err != nil {
+			return nil, err
+		}
+		ad.CategoryID = *params.CategoryID
+	}
+	if params.Title != nil {
+		ad.Title = *params.Title
+	}
+	if params.Description != nil {
+		ad.Description = *params.Description
+	}
+	if params.Price != nil || params.Currency != nil {
+		price, currency := ad.Price.Amount.String(), ad.Price.Currency
+		if params.Price != nil {
+			price = *params.Price
+		}
+		if params.Currency != nil {
+			currency = *params.Currency
+		}
+		ad.Price, err = uc.parsePrice(price, currency)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateAdFields(ad.Title, ad.Description, ad.Price); err != nil {
+		return nil, err
+	}
+
+	if err := uc.adRepo.UpdateAd(ad); err != nil {
+		return nil, fmt.Errorf("failed to update ad: %w", err)
+	}
+
+	return ad, nil
+}
+
+// DeleteAd удаляет объявление. Удалить объявление может его владелец
+// или пользователь с разрешением на модерацию.
+func (uc *AdUseCase) DeleteAd(actor Actor, id string) error {
+	ad, err := uc.getModifiableAd(actor, id)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.adRepo.DeleteAd(id); err != nil {
+		return fmt.Errorf("failed to delete ad: %w", err)
+	}
+	// Записи изображений удаляются каскадно вместе с объявлением, файлы — отдельно
+	for i := range ad.Images {
+		uc.deleteImageBlobs(&ad.Images[i])
+	}
+	return nil
+}
+
+// ChangeAdStatus переводит объявление в новый статус. Допустимые переходы
+// задает domain.CanTransitionAdStatus. Менять статус может владелец или модератор.
+func (uc *AdUseCase) ChangeAdStatus(actor Actor, id, status string) (*domain.Ad, error) {
+	if !domain.IsValidAdStatus(status) {
+		return nil, &ValidationErr{Message: fmt.Sprintf("unknown ad status %q", status)}
+	}
+	ad, err := uc.getModifiableAd(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if ad.Status == status {
+		return ad, nil
+	}
+	if !domain.CanTransitionAdStatus(ad.Status, status) {
+		return nil, fmt.Errorf("%w from %s to %s", ErrInvalidStatusTransition, ad.Status, status)
+	}
+
+	// Неопубликованное объявление при публикации получает полный срок и
+	// поднимается в начало ленты. Возврат из резерва публикацией не считается
+	expiresAt := ad.ExpiresAt
+	var publishedAt *time.Time
+	switch ad.Status {
+	case domain.AdStatusDraft, domain.AdStatusScheduled, domain.AdStatusArchived:
+		if status == domain.AdStatusPublished {
+			now := time.Now().UTC()
+			expiresAt, publishedAt = now.Add(uc.adTTL), &now
+		}
+	}
+	if err := uc.updateAdStatus(ad, status, expiresAt, publishedAt); err != nil {
+		return nil, err
+	}
+	return ad, nil
+}
+
+// RenewAd продлевает срок публикации объявления на полный срок от текущего момента.
+// Архивное объявление при этом публикуется снова. Продлить объявление может
+// владелец или модератор.
+func (uc *AdUseCase) RenewAd(actor Actor, id string) (*domain.Ad, error) {
+	ad, err := uc.getModifiableAd(actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	status := ad.Status
+	var publishedAt *time.Time
+	switch ad.Status {
+	case domain.AdStatusPublished, domain.AdStatusReserved:
+	case domain.AdStatusArchived:
+		status, publishedAt = domain.AdStatusPublished, &now
+	default:
+		return nil, fmt.Errorf("%w: %s ads cannot be renewed", ErrInvalidStatusTransition, ad.Status)
+	}
+
+	if err := uc.updateAdStatus(ad, status, now.Add(uc.adTTL), publishedAt); err != nil {
+		return nil, err
+	}
+	return ad, nil
+}
+
+// updateAdStatus сохраняет новый статус и срок публикации объявления, если его статус
+// не изменил параллельный запрос. Непустой publishedAt — момент новой публикации.
+func (uc *AdUseCase) updateAdStatus(ad *domain.Ad, status string, expiresAt time.Time, publishedAt *time.Time) error {
+	updated, err := uc.adRepo.UpdateAdStatus(ad.ID, ad.Status, status, expiresAt, publishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update ad status: %w", err)
+	}
+	if !updated {
+		return fmt.Errorf("%w: the ad status was changed concurrently", ErrInvalidStatusTransition)
+	}
+	ad.Status = status
+	ad.ExpiresAt = expiresAt
+	ad.PublishAt = nil
+	if publishedAt != nil {
+		ad.PublishedAt = publishedAt
+	}
+	return nil
+}
+
 type ListAdsParameters struct {
-	Page      int
-	Limit     int
-	SortBy    string // created_at, price
-	SortOrder string // asc, desc
-	MinPrice  float64
-	MaxPrice  float64
+	Page       int
+	Limit      int
+	Cursor     string        // Курсор keyset-пагинации; если задан, Page игнорируется
+	SortBy     string        // published_at, created_at, price, relevance (только вместе с Query)
+	SortOrder  string        // asc, desc
+	MinPrice   domain.Amount // В валюте Currency
+	MaxPrice   domain.Amount // В валюте Currency
+	Currency   string        // Валюта отображения цен; пустая — цены в исходных валютах, фильтр в базовой
+	CategoryID string        // Включая подкатегории
+	Query      string        // Полнотекстовый поиск
+	UserID     string        // Только объявления автора
+}
+
+// ListAdsResult содержит страницу объявлений.
+type ListAdsResult struct {
+	Ads        []domain.Ad
+	TotalCount int
+	NextCursor string // Пустой, если страница последняя
 }
 
+// maxSearchQueryLength ограничивает длину поискового запроса.
+const maxSearchQueryLength = 200
+
 // ListAds возвращает список объявлений с учетом пагинации, сортировки и фильтрации.
-func (uc *AdUseCase) ListAds(params ListAdsParameters) ([]domain.Ad, int, error) {
+// Поддерживаются два режима пагинации: по номеру страницы и по курсору.
+// В ленту попадают только опубликованные объявления.
+func (uc *AdUseCase) ListAds(params ListAdsParameters) (*ListAdsResult, error) {
+	return uc.listAds(params, []string{domain.AdStatusPublished})
+}
+
+// ListOwnAds возвращает объявления пользователя во всех статусах, включая черновики
+// и архивные. Если status не пуст, возвращаются только объявления в этом статусе.
+func (uc *AdUseCase) ListOwnAds(actor Actor, params ListAdsParameters, status string) (*ListAdsResult, error) {
+	var statuses []string
+	if status != "" {
+		if !domain.IsValidAdStatus(status) {
+			return nil, &ValidationErr{Message: fmt.Sprintf("unknown ad status %q", status)}
+		}
+		statuses = []string{status}
+	}
+	params.UserID = actor.UserID
+	return uc.listAds(params, statuses)
+}
+
+// listAds возвращает страницу объявлений в статусах statuses; пустой список не ограничивает статус.
+func (uc *AdUseCase) listAds(params ListAdsParameters, statuses []string) (*ListAdsResult, error) {
 	if params.Page < 1 {
 		params.Page = 1
 	}
 	if params.Limit < 1 || params.Limit > 100 { // Ограничение на размер страницы
 		params.Limit = 10
 	}
+	if params.CategoryID != "" {
+		if _, err := uuid.Parse(params.CategoryID); err != nil {
+			return nil, &ValidationErr{Message: "invalid category id"}
+		}
+	}
+	if params.UserID != "" {
+		if _, err := uuid.Parse(params.UserID); err != nil {
+			return nil, &ValidationErr{Message: "invalid user id"}
+		}
+	}
+
+	params.Query = strings.TrimSpace(params.Query)
+	if len(params.Query) > maxSearchQueryLength {
+		return nil, &ValidationErr{Message: "search query is too long"}
+	}
+
+	// Границы цены задаются в валюте отображения и сравниваются с ценами
+	// объявлений, пересчитанными по текущим курсам
+	params.Currency = strings.ToUpper(strings.TrimSpace(params.Currency))
+	priceCurrency := domain.BaseCurrency
+	if params.Currency != "" {
+		if _, err := uc.getRate(params.Currency); err != nil {
+			return nil, err
+		}
+		priceCurrency = params.Currency
+	}
+
+	filter := repository.AdFilter{
+		MinPrice:      params.MinPrice,
+		MaxPrice:      params.MaxPrice,
+		PriceCurrency: priceCurrency,
+		CategoryID:    params.CategoryID,
+		Query:         params.Query,
+		Statuses:      statuses,
+		UserID:        params.UserID,
+	}
+
+	sortBy, sortDesc := normalizeAdSort(params.SortBy, params.SortOrder, params.Query != "")
+	page := repository.AdPageRequest{
+		SortBy:   sortBy,
+		SortDesc: sortDesc,
+		Limit:    params.Limit,
+		Offset:   (params.Page - 1) * params.Limit,
+	}
+	if params.Cursor != "" {
+		after, err := decodeAdCursor(uc.cursorKey, params.Cursor, sortBy, sortDesc, filter)
+		if err != nil {
+			return nil, err
+		}
+		page.After = after
+	}
+
+	ads, next, err := uc.adRepo.ListAds(filter, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ads: %w", err)
+	}
+
+	totalCount, err := uc.adRepo.CountAds(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count ads: %w", err)
+	}
+
+	if params.Currency != "" {
+		if err := uc.convertPrices(ads, params.Currency); err != nil {
+			return nil, err
+		}
+	}
+	if err := uc.attachCoverImages(ads); err != nil {
+		return nil, err
+	}
+
+	return &ListAdsResult{
+		Ads:        ads,
+		TotalCount: totalCount,
+		NextCursor: encodeAdCursor(uc.cursorKey, next, sortBy, sortDesc, filter),
+	}, nil
+}
+
+// convertPrices заполняет ConvertedPrice объявлений ценой в валюте currency.
+func (uc *AdUseCase) convertPrices(ads []domain.Ad, currency string) error {
+	rates, err := uc.rateRepo.ListRates()
+	if err != nil {
+		return fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+	rateByCurrency := make(map[string]domain.Rate, len(rates))
+	for _, rate := range rates {
+		rateByCurrency[rate.Currency] = rate.Rate
+	}
+
+	for i := range ads {
+		from, ok := rateByCurrency[ads[i].Price.Currency]
+		if !ok {
+			continue
+		}
+		amount, ok := domain.ConvertAmount(ads[i].Price.Amount, from, rateByCurrency[currency])
+		if !ok {
+			continue
+		}
+		ads[i].ConvertedPrice = &domain.Money{Amount: amount, Currency: currency}
+	}
+	return nil
+}
+
+// normalizeAdSort приводит параметры сортировки к явному полю и направлению.
+// Без sort_by лента сортируется от недавно опубликованных к давним; при явном
+// поле по умолчанию используется порядок по возрастанию, а для релевантности — по убыванию.
+func normalizeAdSort(sortBy, sortOrder string, hasQuery bool) (string, bool) {
+	switch {
+	case sortBy == repository.AdSortPublishedAt || sortBy == repository.AdSortCreatedAt || sortBy == repository.AdSortPrice:
+	case sortBy == repository.AdSortRelevance && hasQuery:
+		if sortOrder == "" {
+			return sortBy, true
+		}
+	default:
+		if sortOrder == "" {
+			return repository.AdSortPublishedAt, true
+		}
+		sortBy = repository.AdSortPublishedAt
+	}
+	return sortBy, sortOrder != "" && strings.ToUpper(sortOrder) != "ASC"
+}
 
-	offset := (params.Page - 1) * params.Limit
+// getModifiableAd загружает объявление и проверяет, что пользователь может его изменять.
+func (uc *AdUseCase) getModifiableAd(actor Actor, id string) (*domain.Ad, error) {
+	ad, err := uc.GetAd(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if !canModifyAd(actor, ad) {
+		return nil, ErrForbidden
+	}
+	return ad, nil
+}
+
+// canModifyAd проверяет, что пользователь — владелец объявления или модератор.
+func canModifyAd(actor Actor, ad *domain.Ad) bool {
+	return ad.UserID == actor.UserID || actor.Can(domain.PermissionModerateAds)
+}
+
+// validateCategory проверяет, что категория объявления указана и существует.
+func (uc *AdUseCase) validateCategory(categoryID string) error {
+	if categoryID == "" {
+		return &ValidationErr{Message: "category_id is required"}
+	}
+	if _, err := uuid.Parse(categoryID); err != nil {
+		return &ValidationErr{Message: "category not found"}
+	}
 
-	ads, err := uc.adRepo.ListAds(offset, params.Limit, params.SortBy, params.SortOrder, params.MinPrice, params.MaxPrice)
+	category, err := uc.categoryRepo.GetCategoryByID(categoryID)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list ads: %w", err)
+		return fmt.Errorf("failed to get category: %w", err)
+	}
+	if category == nil {
+		return &ValidationErr{Message: "category not found"}
+	}
+	return nil
+}
+
+// parsePrice разбирает цену и валюту объявления. Цена должна помещаться в
+// NUMERIC(10, 2) без округления: не больше domain.MaxAmount, не точнее копейки.
+// Для валюты должен быть задан курс, иначе объявление не попадет в фильтры по цене.
+func (uc *AdUseCase) parsePrice(price, currency string) (domain.Money, error) {
+	amount, err := domain.ParseAmount(price)
+	switch {
+	case errors.Is(err, domain.ErrAmountPrecision):
+		return domain.Money{}, &ValidationErr{Message: "price must have at most 2 decimal places"}
+	case errors.Is(err, domain.ErrAmountRange):
+		return domain.Money{}, &ValidationErr{Message: fmt.Sprintf("price must not exceed %s", domain.MaxAmount)}
+	case err != nil:
+		return domain.Money{}, &ValidationErr{Message: "price must be a decimal number"}
 	}
 
-	totalCount, err := uc.adRepo.CountAds(params.MinPrice, params.MaxPrice)
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+	if _, err := uc.getRate(currency); err != nil {
+		return domain.Money{}, err
+	}
+	return domain.Money{Amount: amount, Currency: currency}, nil
+}
+
+// getRate возвращает курс поддерживаемой валюты к базовой.
+func (uc *AdUseCase) getRate(currency string) (domain.Rate, error) {
+	if !domain.IsSupportedCurrency(currency) {
+		return 0, &ValidationErr{Message: fmt.Sprintf("unsupported currency %q", currency)}
+	}
+	rate, err := uc.rateRepo.GetRate(currency)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count ads: %w", err)
+		return 0, fmt.Errorf("failed to get exchange rate: %w", err)
+	}
+	if rate == nil {
+		return 0, &ValidationErr{Message: fmt.Sprintf("no exchange rate for currency %q", currency)}
 	}
+	return rate.Rate, nil
+}
 
-	return ads, totalCount, nil
+// validateAdFields проверяет поля объявления (длина, цена > 0).
+func validateAdFields(title, description string, price domain.Money) error {
+	if title == "" || price.Amount <= 0 {
+		return &ValidationErr{Message: "title cannot be empty and price must be greater than 0"}
+	}
+	if len(title) > 255 {
+		return &ValidationErr{Message: "title is too long"}
+	}
+	if len(description) > 1000 {
+		return &ValidationErr{Message: "description is too long"}
+	}
+	return nil
 }
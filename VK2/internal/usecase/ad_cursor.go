@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"vk/internal/adapter/repository"
+)
+
+var errInvalidCursor = &ValidationErr{Message: "invalid cursor"}
+
+// adCursorPayload — содержимое непрозрачного курсора ленты. Поля сортировки и
+// отпечаток фильтров сохраняются в курсоре, чтобы курсор нельзя было применить
+// к другой выборке.
+type adCursorPayload struct {
+	SortBy    string `json:"s"`
+	SortDesc  bool   `json:"d"`
+	Filter    string `json:"f"`
+	SortValue string `json:"v"`
+	ID        string `json:"id"`
+}
+
+// maxCursorSortValueLength ограничивает длину значения сортировки в курсоре.
+const maxCursorSortValueLength = 64
+
+// numericPattern описывает текстовое представление NUMERIC в PostgreSQL.
+var numericPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// timestampLayouts — варианты текстового представления TIMESTAMPTZ в PostgreSQL
+// (смещение часового пояса выводится с точностью до часа, минуты или секунды).
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07",
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999-07:00:00",
+}
+
+// encodeAdCursor кодирует курсор следующей страницы в непрозрачную строку,
+// подписанную ключом key.
+func encodeAdCursor(key []byte, cursor *repository.AdCursor, sortBy string, sortDesc bool, filter repository.AdFilter) string {
+	if cursor == nil {
+		return ""
+	}
+	data, _ := json.Marshal(adCursorPayload{
+		SortBy:    sortBy,
+		SortDesc:  sortDesc,
+		Filter:    adFilterFingerprint(filter),
+		SortValue: cursor.SortValue,
+		ID:        cursor.ID,
+	})
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	return encoded + "." + signAdCursor(key, encoded)
+}
+
+// decodeAdCursor проверяет подпись курсора, то, что он выдан для той же
+// сортировки и тех же фильтров, и формат значения сортировки.
+func decodeAdCursor(key []byte, encoded, sortBy string, sortDesc bool, filter repository.AdFilter) (*repository.AdCursor, error) {
+	body, signature, ok := strings.Cut(encoded, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(signAdCursor(key, body))) {
+		return nil, errInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var payload adCursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, errInvalidCursor
+	}
+	if _, err := uuid.Parse(payload.ID); err != nil {
+		return nil, errInvalidCursor
+	}
+	if payload.SortBy != sortBy || payload.SortDesc != sortDesc {
+		return nil, &ValidationErr{Message: "cursor does not match sort_by and sort_order"}
+	}
+	if payload.Filter != adFilterFingerprint(filter) {
+		return nil, &ValidationErr{Message: "cursor does not match the filters"}
+	}
+	if !validAdCursorSortValue(sortBy, payload.SortValue) {
+		return nil, errInvalidCursor
+	}
+
+	return &repository.AdCursor{SortValue: payload.SortValue, ID: payload.ID}, nil
+}
+
+// signAdCursor вычисляет HMAC-SHA256 закодированного курсора.
+func signAdCursor(key []byte, body string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// adFilterFingerprint возвращает короткий отпечаток фильтров выборки.
+func adFilterFingerprint(filter repository.AdFilter) string {
+	data, _ := json.Marshal(filter)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// validAdCursorSortValue проверяет, что значение сортировки из курсора
+// приводится к SQL-типу поля сортировки.
+func validAdCursorSortValue(sortBy, value string) bool {
+	if value == "" || len(value) > maxCursorSortValueLength {
+		return false
+	}
+	switch sortBy {
+	case repository.AdSortPublishedAt, repository.AdSortCreatedAt:
+		for _, layout := range timestampLayouts {
+			if _, err := time.Parse(layout, value); err == nil {
+				return true
+			}
+		}
+		return false
+	case repository.AdSortPrice:
+		return numericPattern.MatchString(value)
+	case repository.AdSortRelevance:
+		f, err := strconv.ParseFloat(value, 32)
+		return err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+
+	"vk/internal/adapter/repository"
+)
+
+var testCursorKey = []byte("test-cursor-key")
+
+const testCursorID = "8f14e45f-ceea-467f-a8f5-0c2b1a3d9e01"
+
+func TestAdCursorRoundTrip(t *testing.T) {
+	filter := repository.AdFilter{CategoryID: "c1", Query: "велосипед", MinPrice: 100}
+	tests := []struct {
+		sortBy string
+		value  string
+	}{
+		{repository.AdSortPublishedAt, "2024-03-01 12:30:45.123456+00"},
+		{repository.AdSortCreatedAt, "2024-03-01 12:30:45.123456+00"},
+		{repository.AdSortCreatedAt, "2024-03-01 12:30:45+03"},
+		{repository.AdSortCreatedAt, "2024-03-01 12:30:45.5+05:30"},
+		{repository.AdSortPrice, "1500.50"},
+		{repository.AdSortPrice, "-3"},
+		{repository.AdSortRelevance, "0.0607927"},
+		{repository.AdSortRelevance, "1e-20"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sortBy+"/"+tt.value, func(t *testing.T) {
+			cursor := &repository.AdCursor{SortValue: tt.value, ID: testCursorID}
+			encoded := encodeAdCursor(testCursorKey, cursor, tt.sortBy, true, filter)
+
+			decoded, err := decodeAdCursor(testCursorKey, encoded, tt.sortBy, true, filter)
+			if err != nil {
+				t.Fatalf("decodeAdCursor() error = %v", err)
+			}
+			if *decoded != *cursor {
+				t.Errorf("decodeAdCursor() = %+v, want %+v", decoded, cursor)
+			}
+		})
+	}
+}
+
+func TestEncodeAdCursorNil(t *testing.T) {
+	if got := encodeAdCursor(testCursorKey, nil, repository.AdSortPrice, false, repository.AdFilter{}); got != "" {
+		t.Errorf("encodeAdCursor(nil) = %q, want empty", got)
+	}
+}
+
+func TestDecodeAdCursorRejects(t *testing.T) {
+	filter := repository.AdFilter{CategoryID: "c1"}
+	valid := encodeAdCursor(testCursorKey, &repository.AdCursor{SortValue: "100.00", ID: testCursorID}, repository.AdSortPrice, false, filter)
+	body, signature, _ := strings.Cut(valid, ".")
+
+	signed := func(value, id string, sortBy string) string {
+		return encodeAdCursor(testCursorKey, &repository.AdCursor{SortValue: value, ID: id}, sortBy, false, filter)
+	}
+
+	tests := []struct {
+		name     string
+		encoded  string
+		sortBy   string
+		sortDesc bool
+		filter   repository.AdFilter
+		wantMsg  string
+	}{
+		{"garbage", "not-a-cursor", repository.AdSortPrice, false, filter, "invalid cursor"},
+		{"missing signature", body, repository.AdSortPrice, false, filter, "invalid cursor"},
+		{"tampered body", body + "x." + signature, repository.AdSortPrice, false, filter, "invalid cursor"},
+		{"tampered signature", body + "." + strings.Repeat("A", len(signature)), repository.AdSortPrice, false, filter, "invalid cursor"},
+		{"other key", encodeAdCursor([]byte("other"), &repository.AdCursor{SortValue: "100.00", ID: testCursorID}, repository.AdSortPrice, false, filter), repository.AdSortPrice, false, filter, "invalid cursor"},
+		{"other sort field", valid, repository.AdSortCreatedAt, false, filter, "cursor does not match sort_by and sort_order"},
+		{"other sort order", valid, repository.AdSortPrice, true, filter, "cursor does not match sort_by and sort_order"},
+		{"other filters", valid, repository.AdSortPrice, false, repository.AdFilter{CategoryID: "c2"}, "cursor does not match the filters"},
+		{"invalid id", signed("100.00", "42", repository.AdSortPrice), repository.AdSortPrice, false, filter, "invalid cursor"},
+		{"empty value", signed("", testCursorID, repository.AdSortPrice), repository.AdSortPrice, false, filter, "invalid cursor"},
+		{"non-numeric price", signed("100; DROP TABLE ads", testCursorID, repository.AdSortPrice), repository.AdSortPrice, false, filter, "invalid cursor"},
+		{"price with exponent", signed("1e5", testCursorID, repository.AdSortPrice), repository.AdSortPrice, false, filter, "invalid cursor"},
+		{"invalid timestamp", signed("yesterday", testCursorID, repository.AdSortCreatedAt), repository.AdSortCreatedAt, false, filter, "invalid cursor"},
+		{"invalid relevance", signed("NaN", testCursorID, repository.AdSortRelevance), repository.AdSortRelevance, false, filter, "invalid cursor"},
+		{"too long value", signed(strings.Repeat("1", maxCursorSortValueLength+1), testCursorID, repository.AdSortPrice), repository.AdSortPrice, false, filter, "invalid cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeAdCursor(testCursorKey, tt.encoded, tt.sortBy, tt.sortDesc, tt.filter)
+			validationErr, ok := err.(*ValidationErr)
+			if !ok {
+				t.Fatalf("decodeAdCursor() error = %v, want *ValidationErr", err)
+			}
+			if validationErr.Message != tt.wantMsg {
+				t.Errorf("decodeAdCursor() error = %q, want %q", validationErr.Message, tt.wantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"vk/internal/adapter/repository"
+)
+
+// adExpirationBatchSize — сколько объявлений фоновая задача обрабатывает за один вызов.
+const adExpirationBatchSize = 100
+
+// AdExpirationUseCase переносит в архив объявления с истекшим сроком публикации
+// и заранее напоминает владельцам о скором истечении срока.
+type AdExpirationUseCase struct {
+	adRepo         repository.AdRepository
+	notifier       repository.Notifier
+	reminderBefore time.Duration // За сколько до истечения срока отправляется напоминание
+}
+
+func NewAdExpirationUseCase(adRepo repository.AdRepository, notifier repository.Notifier, reminderBefore time.Duration) *AdExpirationUseCase {
+	return &AdExpirationUseCase{
+		adRepo:         adRepo,
+		notifier:       notifier,
+		reminderBefore: reminderBefore,
+	}
+}
+
+// ArchiveExpiredAds переносит в архив очередную партию истекших объявлений.
+// Возвращает true, если партия заполнена полностью и истекших объявлений может быть больше.
+func (uc *AdExpirationUseCase) ArchiveExpiredAds() (bool, error) {
+	count, err := uc.adRepo.ArchiveExpiredAds(time.Now().UTC(), adExpirationBatchSize)
+	if err != nil {
+		return false, fmt.Errorf("failed to archive expired ads: %w", err)
+	}
+	if count > 0 {
+		log.Printf("В архив перенесено объявлений с истекшим сроком: %d.", count)
+	}
+	return count == adExpirationBatchSize, nil
+}
+
+// SendExpiryReminders отправляет напоминания владельцам объявлений, срок которых
+// скоро истечет. Напоминание отмечается отправленным до отправки: при сбое
+// уведомление может потеряться, но не будет отправлено повторно.
+func (uc *AdExpirationUseCase) SendExpiryReminders() (bool, error) {
+	now := time.Now().UTC()
+	ads, err := uc.adRepo.ClaimExpiringAds(now.Add(uc.reminderBefore), now, adExpirationBatchSize)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim expiring ads: %w", err)
+	}
+
+	for i := range ads {
+		if err := uc.notifier.NotifyAdExpiring(&ads[i]); err != nil {
+			log.Printf("failed to notify about expiring ad %s: %v", ads[i].ID, err)
+		}
+	}
+	return len(ads) == adExpirationBatchSize, nil
+}
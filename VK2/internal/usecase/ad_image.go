@@ -0,0 +1,305 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"vk/internal/domain"
+	"vk/internal/infrastructure/imaging"
+)
+
+var (
+	ErrImageNotFound    = errors.New("image not found")
+	ErrImageTooLarge    = fmt.Errorf("image must not exceed %d MB", MaxImageSize>>20)
+	ErrUnsupportedImage = errors.New("only JPEG, PNG and WebP images are supported")
+)
+
+// MaxImageSize — наибольший размер загружаемого изображения в байтах.
+const MaxImageSize = 10 << 20
+
+// MaxAdImages — наибольшее число изображений у одного объявления.
+const MaxAdImages = 10
+
+var errTooManyImages = &ValidationErr{Message: fmt.Sprintf("an ad can have at most %d images", MaxAdImages)}
+
+// maxImagePixels ограничивает число пикселей изображения: небольшой файл
+// может описывать огромное изображение, декодирование которого займет всю память.
+const maxImagePixels = 40_000_000
+
+// UploadAdImage добавляет изображение в конец списка изображений объявления;
+// первое изображение становится обложкой. Формат определяется по содержимому файла,
+// метаданные (EXIF с геопозицией и т.п.) удаляются.
+// Загрузить изображение может владелец объявления или модератор.
+func (uc *AdUseCase) UploadAdImage(actor Actor, id string, data []byte) (*domain.Ad, error) {
+	ad, err := uc.getModifiableAd(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(ad.Images) >= MaxAdImages {
+		return nil, errTooManyImages
+	}
+
+	contentType, err := checkImage(data)
+	if err != nil {
+		return nil, err
+	}
+	data, contentType, err = imaging.StripMetadata(data, contentType)
+	if err != nil {
+		return nil, &ValidationErr{Message: "image file is corrupted"}
+	}
+
+	image := &domain.AdImage{
+		ID:          uuid.New().String(),
+		AdID:        ad.ID,
+		ContentType: contentType,
+		CreatedAt:   time.Now().UTC(),
+	}
+	image.StorageKey = fmt.Sprintf("ads/%s/%s%s", ad.ID, image.ID, imaging.Extension(contentType))
+	if err := uc.blobStorage.Put(image.StorageKey, data, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store image: %w", err)
+	}
+	added, err := uc.imageRepo.AddImage(image, MaxAdImages)
+	if err != nil {
+		uc.deleteBlob(image.StorageKey)
+		return nil, fmt.Errorf("failed to add image: %w", err)
+	}
+	if !added {
+		// Лимит заполнили одновременные загрузки
+		uc.deleteBlob(image.StorageKey)
+		return nil, errTooManyImages
+	}
+
+	if err := uc.loadImages(ad); err != nil {
+		return nil, err
+	}
+	return ad, nil
+}
+
+// DeleteAdImage удаляет изображение объявления. Если оно было обложкой,
+// обложкой становится первое из оставшихся изображений.
+func (uc *AdUseCase) DeleteAdImage(actor Actor, id, imageID string) (*domain.Ad, error) {
+	ad, err := uc.getModifiableAd(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	image := findAdImage(ad.Images, imageID)
+	if image == nil {
+		return nil, ErrImageNotFound
+	}
+
+	if err := uc.imageRepo.DeleteImage(ad.ID, image.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete image: %w", err)
+	}
+	uc.deleteImageBlobs(image)
+
+	if err := uc.loadImages(ad); err != nil {
+		return nil, err
+	}
+	return ad, nil
+}
+
+// ReorderAdImages задает порядок изображений объявления. imageIDs должен
+// перечислять все изображения объявления ровно по одному разу. Если coverID
+// пуст, обложка не меняется.
+func (uc *AdUseCase) ReorderAdImages(actor Actor, id string, imageIDs []string, coverID string) (*domain.Ad, error) {
+	ad, err := uc.getModifiableAd(actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(imageIDs) != len(ad.Images) {
+		return nil, &ValidationErr{Message: "image_ids must list every image of the ad exactly once"}
+	}
+	seen := make(map[string]bool, len(imageIDs))
+	for _, imageID := range imageIDs {
+		if seen[imageID] || findAdImage(ad.Images, imageID) == nil {
+			return nil, &ValidationErr{Message: "image_ids must list every image of the ad exactly once"}
+		}
+		seen[imageID] = true
+	}
+
+	if coverID == "" {
+		for _, image := range ad.Images {
+			if image.IsCover {
+				coverID = image.ID
+			}
+		}
+	} else if !seen[coverID] {
+		return nil, &ValidationErr{Message: "cover_id must be one of the ad images"}
+	}
+
+	if err := uc.imageRepo.ReorderImages(ad.ID, imageIDs, coverID); err != nil {
+		return nil, fmt.Errorf("failed to reorder images: %w", err)
+	}
+
+	if err := uc.loadImages(ad); err != nil {
+		return nil, err
+	}
+	return ad, nil
+}
+
+// loadImages загружает изображения объявления и их готовые варианты с подписанными
+// ссылками и заполняет ссылку на обложку.
+func (uc *AdUseCase) loadImages(ad *domain.Ad) error {
+	images, err := uc.imageRepo.ListImages(ad.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list ad images: %w", err)
+	}
+	if err := uc.attachVariants(images); err != nil {
+		return err
+	}
+
+	ad.Images = images
+	ad.ImageURL = ""
+	for i := range ad.Images {
+		uc.signImageURL(&ad.Images[i])
+		if ad.Images[i].IsCover {
+			ad.ImageURL = ad.Images[i].URL
+		}
+	}
+	return nil
+}
+
+// attachCoverImages заполняет ссылки на обложки для страницы ленты. Лента отдает
+// только миниатюры: пока миниатюра обложки не готова, ссылка остается пустой,
+// чтобы не загружать клиенту оригинал в полном размере.
+func (uc *AdUseCase) attachCoverImages(ads []domain.Ad) error {
+	adIDs := make([]string, len(ads))
+	for i := range ads {
+		adIDs[i] = ads[i].ID
+	}
+	covers, err := uc.imageRepo.ListCoverImages(adIDs)
+	if err != nil {
+		return fmt.Errorf("failed to list cover images: %w", err)
+	}
+
+	if err := uc.attachVariants(covers); err != nil {
+		return err
+	}
+
+	thumbnailByAd := make(map[string]*domain.AdImageVariant, len(covers))
+	for i := range covers {
+		if thumbnail := covers[i].Variant(domain.ImageVariantThumbnail); thumbnail != nil {
+			thumbnailByAd[covers[i].AdID] = thumbnail
+		}
+	}
+	externalByAd := make(map[string]string)
+	for i := range covers {
+		if covers[i].ExternalURL != "" {
+			externalByAd[covers[i].AdID] = covers[i].ExternalURL
+		}
+	}
+	for i := range ads {
+		if thumbnail, ok := thumbnailByAd[ads[i].ID]; ok {
+			uc.signVariantURL(thumbnail)
+			ads[i].ImageURL = thumbnail.URL
+		} else if url, ok := externalByAd[ads[i].ID]; ok {
+			// У внешних изображений миниатюр нет, в ленте показывается сама ссылка
+			ads[i].ImageURL = url
+		}
+	}
+	return nil
+}
+
+// attachVariants загружает готовые варианты изображений одним запросом.
+func (uc *AdUseCase) attachVariants(images []domain.AdImage) error {
+	imageIDs := make([]string, len(images))
+	for i := range images {
+		imageIDs[i] = images[i].ID
+	}
+	variants, err := uc.imageRepo.ListImageVariants(imageIDs)
+	if err != nil {
+		return fmt.Errorf("failed to list image variants: %w", err)
+	}
+
+	imageByID := make(map[string]*domain.AdImage, len(images))
+	for i := range images {
+		imageByID[images[i].ID] = &images[i]
+	}
+	for _, variant := range variants {
+		if image, ok := imageByID[variant.ImageID]; ok {
+			image.Variants = append(image.Variants, variant)
+		}
+	}
+	return nil
+}
+
+// signImageURL заполняет подписанные ссылки на изображение и его варианты.
+// Внешние изображения отдаются по исходной ссылке.
+func (uc *AdUseCase) signImageURL(image *domain.AdImage) {
+	if image.ExternalURL != "" {
+		image.URL = image.ExternalURL
+		return
+	}
+	url, err := uc.blobStorage.SignedURL(image.StorageKey, uc.imageURLTTL)
+	if err != nil {
+		log.Printf("failed to sign url for image %s: %v", image.ID, err)
+		return
+	}
+	image.URL = url
+	for i := range image.Variants {
+		uc.signVariantURL(&image.Variants[i])
+	}
+}
+
+// signVariantURL заполняет подписанную ссылку на вариант изображения.
+func (uc *AdUseCase) signVariantURL(variant *domain.AdImageVariant) {
+	url, err := uc.blobStorage.SignedURL(variant.StorageKey, uc.imageURLTTL)
+	if err != nil {
+		log.Printf("failed to sign url for %s variant of image %s: %v", variant.Name, variant.ImageID, err)
+		return
+	}
+	variant.URL = url
+}
+
+// deleteImageBlobs удаляет файлы изображения и всех его вариантов.
+func (uc *AdUseCase) deleteImageBlobs(image *domain.AdImage) {
+	if image.StorageKey != "" {
+		uc.deleteBlob(image.StorageKey)
+	}
+	for _, variant := range image.Variants {
+		uc.deleteBlob(variant.StorageKey)
+	}
+}
+
+// deleteBlob удаляет файл, которому больше не соответствует ни одна запись.
+// Ошибка не прерывает сценарий: лишний файл не влияет на работу сервиса.
+func (uc *AdUseCase) deleteBlob(key string) {
+	if err := uc.blobStorage.Delete(key); err != nil {
+		log.Printf("failed to delete blob %s: %v", key, err)
+	}
+}
+
+// checkImage проверяет размер и формат загружаемого изображения и возвращает
+// его MIME-тип, определенный по содержимому файла.
+func checkImage(data []byte) (string, error) {
+	if len(data) > MaxImageSize {
+		return "", ErrImageTooLarge
+	}
+	contentType, err := imaging.DetectContentType(data)
+	if err != nil {
+		return "", ErrUnsupportedImage
+	}
+	config, err := imaging.DecodeConfig(data)
+	if err != nil {
+		return "", &ValidationErr{Message: "image file is corrupted"}
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxImagePixels {
+		return "", &ValidationErr{Message: fmt.Sprintf("image must not exceed %d megapixels", maxImagePixels/1_000_000)}
+	}
+	return contentType, nil
+}
+
+// findAdImage ищет изображение по ID среди изображений объявления.
+func findAdImage(images []domain.AdImage, imageID string) *domain.AdImage {
+	for i := range images {
+		if images[i].ID == imageID {
+			return &images[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"vk/internal/adapter/repository"
+)
+
+// adSchedulerBatchSize — сколько объявлений планировщик публикует за один вызов.
+const adSchedulerBatchSize = 100
+
+// AdSchedulerUseCase публикует объявления с отложенной публикацией. Расписание
+// хранится в БД (статус scheduled и publish_at), поэтому переживает перезапуск:
+// объявления, время которых наступило во время простоя, публикуются при первом запуске.
+type AdSchedulerUseCase struct {
+	adRepo repository.AdRepository
+}
+
+func NewAdSchedulerUseCase(adRepo repository.AdRepository) *AdSchedulerUseCase {
+	return &AdSchedulerUseCase{adRepo: adRepo}
+}
+
+// PublishScheduledAds публикует очередную партию объявлений, время публикации которых наступило.
+// Возвращает true, если партия заполнена полностью и таких объявлений может быть больше.
+func (uc *AdSchedulerUseCase) PublishScheduledAds() (bool, error) {
+	count, err := uc.adRepo.PublishScheduledAds(time.Now().UTC(), adSchedulerBatchSize)
+	if err != nil {
+		return false, fmt.Errorf("failed to publish scheduled ads: %w", err)
+	}
+	if count > 0 {
+		log.Printf("Опубликовано запланированных объявлений: %d.", count)
+	}
+	return count == adSchedulerBatchSize, nil
+}
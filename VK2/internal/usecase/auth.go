@@ -3,6 +3,8 @@ package usecase
 import (
 	"errors"
 	"fmt"
+	"log"
+	"net/mail"
 	"strings"
 	"time"
 
@@ -15,6 +17,7 @@ import (
 
 var (
 	ErrUserAlreadyExists  = errors.New("пользователь с таким логином уже существует")
+	ErrEmailAlreadyExists = errors.New("пользователь с такой почтой уже существует")
 	ErrInvalidCredentials = errors.New("неверные учетные данные")
 )
 
@@ -26,22 +29,55 @@ func (e *ValidationErr) Error() string {
 	return e.Message
 }
 
+// RateLimitErr сообщает, что действие повторяется слишком часто и его можно
+// повторить через RetryAfter.
+type RateLimitErr struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitErr) Error() string {
+	return e.Message
+}
+
 type AuthUseCase struct {
-	userRepo        repository.UserRepository
-	tokenSecretKey  string
-	tokenExpiration time.Duration
+	userRepo               repository.UserRepository
+	refreshTokenRepo       repository.RefreshTokenRepository
+	sessionRepo            repository.SessionRepository
+	roleRepo               repository.RoleRepository
+	emailUseCase           *EmailUseCase
+	twoFactorUseCase       *TwoFactorUseCase
+	loginThrottle          *LoginThrottleUseCase
+	passwordPolicy         *PasswordPolicy
+	passwordManager        *util.PasswordManager
+	tokenManager           *util.TokenManager
+	tokenExpiration        time.Duration
+	refreshTokenExpiration time.Duration
 }
 
-func NewAuthUseCase(userRepo repository.UserRepository, tokenSecretKey string, tokenExpiration time.Duration) *AuthUseCase {
+func NewAuthUseCase(userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository, sessionRepo repository.SessionRepository, roleRepo repository.RoleRepository, emailUseCase *EmailUseCase, twoFactorUseCase *TwoFactorUseCase, loginThrottle *LoginThrottleUseCase, passwordPolicy *PasswordPolicy, passwordManager *util.PasswordManager, tokenManager *util.TokenManager, tokenExpiration, refreshTokenExpiration time.Duration) *AuthUseCase {
 	return &AuthUseCase{
-		userRepo:        userRepo,
-		tokenSecretKey:  tokenSecretKey,
-		tokenExpiration: tokenExpiration,
+		userRepo:               userRepo,
+		refreshTokenRepo:       refreshTokenRepo,
+		sessionRepo:            sessionRepo,
+		roleRepo:               roleRepo,
+		emailUseCase:           emailUseCase,
+		twoFactorUseCase:       twoFactorUseCase,
+		loginThrottle:          loginThrottle,
+		passwordPolicy:         passwordPolicy,
+		passwordManager:        passwordManager,
+		tokenManager:           tokenManager,
+		tokenExpiration:        tokenExpiration,
+		refreshTokenExpiration: refreshTokenExpiration,
 	}
 }
 
-// RegisterUser регистрирует нового пользователя.
-func (uc *AuthUseCase) RegisterUser(login, password string) (*domain.User, error) {
+// maxEmailLength — наибольшая длина адреса почты по RFC 5321.
+const maxEmailLength = 254
+
+// RegisterUser регистрирует нового пользователя и отправляет письмо для
+// подтверждения почты. Пока почта не подтверждена, размещать объявления нельзя.
+func (uc *AuthUseCase) RegisterUser(login, password, email string) (*domain.User, error) {
 
 	if len(login) < 3 || len(login) > 50 {
 		return nil, &ValidationErr{Message: "логин должен быть от 3 до 50 символов"}
@@ -49,11 +85,15 @@ func (uc *AuthUseCase) RegisterUser(login, password string) (*domain.User, error
 	if !isValidLogin(login) {
 		return nil, &ValidationErr{Message: "логин может содержать только буквы, цифры, подчеркивания и дефисы"}
 	}
-	if len(password) < 8 || len(password) > 100 {
-		return nil, &ValidationErr{Message: "пароль должен быть от 8 до 100 символов"}
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil, &ValidationErr{Message: "почта обязательна"}
 	}
-	if !isValidPassword(password) {
-		return nil, &ValidationErr{Message: "пароль должен содержать хотя бы одну заглавную букву, одну строчную букву, одну цифру и один специальный символ"}
+	if !isValidEmail(email) {
+		return nil, &ValidationErr{Message: "некорректный адрес почты"}
+	}
+	if err := uc.passwordPolicy.Validate(password, login, email); err != nil {
+		return nil, err
 	}
 
 	// Проверка на существование пользователя
@@ -64,9 +104,16 @@ func (uc *AuthUseCase) RegisterUser(login, password string) (*domain.User, error
 	if existingUser != nil {
 		return nil, ErrUserAlreadyExists
 	}
+	existingUser, err = uc.userRepo.GetUserByEmail(email)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось проверить существующего пользователя: %w", err)
+	}
+	if existingUser != nil {
+		return nil, ErrEmailAlreadyExists
+	}
 
 	// Хеширование пароля
-	hashedPassword, err := util.HashPassword(password)
+	hashedPassword, err := uc.passwordManager.Hash(password)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось хешировать пароль: %w", err)
 	}
@@ -75,40 +122,127 @@ func (uc *AuthUseCase) RegisterUser(login, password string) (*domain.User, error
 	newUser := &domain.User{
 		ID:           uuid.New().String(),
 		Login:        login,
+		Email:        email,
 		PasswordHash: hashedPassword,
+		Role:         domain.RoleUser,
 		CreatedAt:    time.Now().UTC(),
 	}
 
 	// Сохранение пользователя в репозитории
 	if err := uc.userRepo.CreateUser(newUser); err != nil {
+		// Логин или почту успели занять параллельной регистрацией
+		if errors.Is(err, repository.ErrLoginTaken) {
+			return nil, ErrUserAlreadyExists
+		}
+		if errors.Is(err, repository.ErrEmailTaken) {
+			return nil, ErrEmailAlreadyExists
+		}
 		return nil, fmt.Errorf("не удалось создать пользователя: %w", err)
 	}
 
+	// Письмо можно запросить повторно, поэтому ошибка отправки не отменяет регистрацию
+	if err := uc.emailUseCase.SendVerification(newUser); err != nil {
+		log.Printf("failed to send verification email to user %s: %v", newUser.ID, err)
+	}
+
 	return newUser, nil
 }
 
-// AuthenticateUser аутентифицирует пользователя и возвращает токен.
-func (uc *AuthUseCase) AuthenticateUser(login, password string) (string, error) {
+// LoginResult — итог проверки пароля: пара токенов или, если у пользователя
+// подключен второй фактор, незавершенный вход, который нужно подтвердить кодом.
+type LoginResult struct {
+	Tokens    *AuthTokens
+	Challenge *TwoFactorChallenge
+}
+
+// AuthenticateUser аутентифицирует пользователя по паролю. Без второго фактора
+// открывает новую сессию и возвращает пару токенов, иначе — незавершенный вход.
+// После нескольких неудачных попыток подряд вход по логину или с адреса клиента
+// временно блокируется (RateLimitErr).
+func (uc *AuthUseCase) AuthenticateUser(login, password string, client ClientInfo) (*LoginResult, error) {
+	if err := uc.loginThrottle.BeginAttempt(login, client.IP); err != nil {
+		return nil, err
+	}
+
 	user, err := uc.userRepo.GetUserByLogin(login)
 	if err != nil {
-		return "", fmt.Errorf("не удалось получить пользователя по логину: %w", err)
+		return nil, fmt.Errorf("не удалось получить пользователя по логину: %w", err)
 	}
 	if user == nil {
-		return "", ErrInvalidCredentials
+		// Сравнение с фиктивным хешем, чтобы по времени ответа нельзя было узнать, существует ли логин
+		uc.passwordManager.CompareDummy(password)
+		return nil, ErrInvalidCredentials
 	}
 
 	// Проверка пароля
-	if !util.CheckPasswordHash(password, user.PasswordHash) {
-		return "", ErrInvalidCredentials
+	ok, needsRehash := uc.passwordManager.Verify(password, user.PasswordHash)
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	if needsRehash {
+		uc.rehashPassword(user, password)
+	}
+
+	challenge, err := uc.twoFactorUseCase.StartLogin(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		// Счетчик сбрасывается только после проверки кода: иначе верный пароль
+		// позволял бы начинать новые входы и перебирать коды без ограничений
+		return &LoginResult{Challenge: challenge}, nil
 	}
+	uc.loginThrottle.AttemptSucceeded(login, client.IP)
 
-	// Генерация кастомного токена
-	token, err := util.GenerateToken(user.ID, uc.tokenSecretKey, uc.tokenExpiration)
+	tokens, err := uc.openSession(user, client)
 	if err != nil {
-		return "", fmt.Errorf("не удалось сгенерировать токен: %w", err)
+		return nil, err
 	}
+	return &LoginResult{Tokens: tokens}, nil
+}
+
+// CompleteTwoFactorLogin завершает вход кодом второго фактора и возвращает пару токенов.
+func (uc *AuthUseCase) CompleteTwoFactorLogin(challengeToken, code string, client ClientInfo) (*AuthTokens, error) {
+	userID, err := uc.twoFactorUseCase.CompleteLogin(challengeToken, code)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить пользователя: %w", err)
+	}
+	if user == nil {
+		return nil, ErrInvalidLoginChallenge
+	}
+	uc.loginThrottle.AttemptSucceeded(user.Login, client.IP)
+	return uc.openSession(user, client)
+}
 
-	return token, nil
+// rehashPassword пересчитывает хеш пароля текущим алгоритмом с текущими
+// параметрами. Хеш заменяется, только если пароль не успели сменить
+// параллельно; ошибка не мешает входу, хеш пересчитается при следующем.
+func (uc *AuthUseCase) rehashPassword(user *domain.User, password string) {
+	hashedPassword, err := uc.passwordManager.Hash(password)
+	if err != nil {
+		log.Printf("failed to rehash password of user %s: %v", user.ID, err)
+		return
+	}
+	if _, err := uc.userRepo.ReplacePasswordHash(user.ID, user.PasswordHash, hashedPassword); err != nil {
+		log.Printf("failed to save rehashed password of user %s: %v", user.ID, err)
+		return
+	}
+	user.PasswordHash = hashedPassword
+}
+
+// openSession открывает новую сессию и выдает для нее пару токенов.
+func (uc *AuthUseCase) openSession(user *domain.User, client ClientInfo) (*AuthTokens, error) {
+	session, err := uc.createSession(user.ID, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.issueTokens(user, session.ID)
 }
 
 // isValidLogin проверяет соответствие логина требованиям (буквы, цифры, _, -)
@@ -122,6 +256,26 @@ func isValidLogin(login string) bool {
 	return true
 }
 
+// validatePassword проверяет длину и состав нового пароля. Полная проверка — PasswordPolicy.Validate.
+func validatePassword(password string) error {
+	if len(password) < 8 || len(password) > 100 {
+		return &ValidationErr{Message: "пароль должен быть от 8 до 100 символов"}
+	}
+	if !isValidPassword(password) {
+		return &ValidationErr{Message: "пароль должен содержать хотя бы одну заглавную букву, одну строчную букву, одну цифру и один специальный символ"}
+	}
+	return nil
+}
+
+// isValidEmail проверяет, что строка — одиночный адрес почты без имени и комментариев.
+func isValidEmail(email string) bool {
+	if len(email) > maxEmailLength {
+		return false
+	}
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email && strings.Contains(email[strings.LastIndex(email, "@")+1:], ".")
+}
+
 // isValidPassword проверяет соответствие пароля требованиям (минимум 1 заглавная, 1 строчная, 1 цифра, 1 спецсимвол)
 func isValidPassword(password string) bool {
 	hasUpper := false
@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("категория не найдена")
+	ErrCategoryInUse    = errors.New("категория содержит подкатегории или объявления")
+)
+
+// maxCategoryNameLength — максимальная длина названия категории в символах.
+const maxCategoryNameLength = 100
+
+const categoryNameTakenMessage = "категория с таким названием уже существует на этом уровне"
+
+// CategoryNode — узел дерева категорий.
+type CategoryNode struct {
+	domain.Category
+	Children []*CategoryNode
+}
+
+type CategoryUseCase struct {
+	categoryRepo repository.CategoryRepository
+}
+
+func NewCategoryUseCase(categoryRepo repository.CategoryRepository) *CategoryUseCase {
+	return &CategoryUseCase{categoryRepo: categoryRepo}
+}
+
+// GetCategoryTree возвращает дерево категорий, отсортированное по названию.
+func (uc *CategoryUseCase) GetCategoryTree() ([]*CategoryNode, error) {
+	categories, err := uc.categoryRepo.ListCategories()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить категории: %w", err)
+	}
+
+	nodes := make(map[string]*CategoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &CategoryNode{Category: category, Children: []*CategoryNode{}}
+	}
+
+	// Категории приходят отсортированными по названию, поэтому порядок детей сохраняется
+	roots := []*CategoryNode{}
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID == nil {
+			roots = append(roots, node)
+			continue
+		}
+		if parent, ok := nodes[*category.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+	return roots, nil
+}
+
+// CreateCategory создает категорию. Если parentID не nil, категория становится подкатегорией.
+func (uc *CategoryUseCase) CreateCategory(name string, parentID *string) (*domain.Category, error) {
+	category := &domain.Category{
+		ID:        uuid.New().String(),
+		ParentID:  parentID,
+		Name:      strings.TrimSpace(name),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := uc.validateCategory(category); err != nil {
+		return nil, err
+	}
+
+	if err := uc.categoryRepo.CreateCategory(category); err != nil {
+		if errors.Is(err, repository.ErrCategoryNameTaken) {
+			return nil, &ValidationErr{Message: categoryNameTakenMessage}
+		}
+		return nil, fmt.Errorf("не удалось создать категорию: %w", err)
+	}
+	return category, nil
+}
+
+// UpdateCategoryParameters содержит изменяемые поля категории. Nil-поля не изменяются;
+// пустая строка в ParentID переносит категорию в корень дерева.
+type UpdateCategoryParameters struct {
+	Name     *string
+	ParentID *string
+}
+
+// UpdateCategory переименовывает категорию или переносит ее в другую ветку дерева.
+func (uc *CategoryUseCase) UpdateCategory(id string, params UpdateCategoryParameters) (*domain.Category, error) {
+	category, err := uc.GetCategory(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Name != nil {
+		category.Name = strings.TrimSpace(*params.Name)
+	}
+	if params.ParentID != nil {
+		if *params.ParentID == "" {
+			category.ParentID = nil
+		} else {
+			parentID := *params.ParentID
+			category.ParentID = &parentID
+		}
+	}
+
+	if err := uc.validateCategory(category); err != nil {
+		return nil, err
+	}
+
+	if err := uc.categoryRepo.UpdateCategory(category); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrCategoryCycle):
+			return nil, &ValidationErr{Message: "категорию нельзя перенести в ее собственную подкатегорию"}
+		case errors.Is(err, repository.ErrCategoryNameTaken):
+			return nil, &ValidationErr{Message: categoryNameTakenMessage}
+		}
+		return nil, fmt.Errorf("не удалось обновить категорию: %w", err)
+	}
+	return category, nil
+}
+
+// DeleteCategory удаляет категорию без подкатегорий и объявлений.
+func (uc *CategoryUseCase) DeleteCategory(id string) error {
+	if _, err := uc.GetCategory(id); err != nil {
+		return err
+	}
+
+	inUse, err := uc.categoryRepo.IsCategoryInUse(id)
+	if err != nil {
+		return fmt.Errorf("не удалось проверить использование категории: %w", err)
+	}
+	if inUse {
+		return ErrCategoryInUse
+	}
+
+	if err := uc.categoryRepo.DeleteCategory(id); err != nil {
+		if errors.Is(err, repository.ErrCategoryInUse) {
+			return ErrCategoryInUse
+		}
+		return fmt.Errorf("не удалось удалить категорию: %w", err)
+	}
+	return nil
+}
+
+// GetCategory возвращает категорию по ID.
+func (uc *CategoryUseCase) GetCategory(id string) (*domain.Category, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	category, err := uc.categoryRepo.GetCategoryByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить категорию: %w", err)
+	}
+	if category == nil {
+		return nil, ErrCategoryNotFound
+	}
+	return category, nil
+}
+
+// validateCategory проверяет название, существование родителя и уникальность
+// названия среди соседних категорий. Циклы в дереве проверяет хранилище при
+// сохранении, чтобы одновременные переносы не обошли проверку.
+func (uc *CategoryUseCase) validateCategory(category *domain.Category) error {
+	if category.Name == "" || !utf8.ValidString(category.Name) || utf8.RuneCountInString(category.Name) > maxCategoryNameLength {
+		return &ValidationErr{Message: fmt.Sprintf("название категории должно быть от 1 до %d символов", maxCategoryNameLength)}
+	}
+
+	categories, err := uc.categoryRepo.ListCategories()
+	if err != nil {
+		return fmt.Errorf("не удалось получить категории: %w", err)
+	}
+	byID := make(map[string]domain.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	if category.ParentID != nil {
+		if _, ok := byID[*category.ParentID]; !ok {
+			return &ValidationErr{Message: "родительская категория не найдена"}
+		}
+	}
+
+	for _, sibling := range categories {
+		if sibling.ID != category.ID && sameParent(sibling.ParentID, category.ParentID) && strings.EqualFold(sibling.Name, category.Name) {
+			return &ValidationErr{Message: categoryNameTakenMessage}
+		}
+	}
+	return nil
+}
+
+func sameParent(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+	"vk/internal/infrastructure/util"
+)
+
+var (
+	ErrEmailNotVerified              = errors.New("почта не подтверждена")
+	ErrEmailAlreadyVerified          = errors.New("почта уже подтверждена")
+	ErrInvalidEmailVerificationToken = errors.New("ссылка для подтверждения почты недействительна или устарела")
+)
+
+// EmailUseCase отвечает за почту пользователей и ее подтверждение.
+type EmailUseCase struct {
+	userRepo         repository.UserRepository
+	verificationRepo repository.EmailVerificationRepository
+	mailer           repository.Mailer
+	loginThrottle    *LoginThrottleUseCase
+	passwordManager  *util.PasswordManager
+	tokenTTL         time.Duration // Срок действия ссылки для подтверждения
+	resendInterval   time.Duration // Минимальный интервал между письмами одному пользователю
+	verifyURL        string        // Адрес страницы подтверждения; токен добавляется параметром token
+}
+
+func NewEmailUseCase(userRepo repository.UserRepository, verificationRepo repository.EmailVerificationRepository, mailer repository.Mailer, loginThrottle *LoginThrottleUseCase, passwordManager *util.PasswordManager, tokenTTL, resendInterval time.Duration, verifyURL string) *EmailUseCase {
+	return &EmailUseCase{
+		userRepo:         userRepo,
+		verificationRepo: verificationRepo,
+		mailer:           mailer,
+		loginThrottle:    loginThrottle,
+		passwordManager:  passwordManager,
+		tokenTTL:         tokenTTL,
+		resendInterval:   resendInterval,
+		verifyURL:        verifyURL,
+	}
+}
+
+// SendVerification отправляет на почту пользователя ссылку для ее подтверждения.
+func (uc *EmailUseCase) SendVerification(user *domain.User) error {
+	token, err := util.GenerateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("не удалось сгенерировать токен подтверждения почты: %w", err)
+	}
+	now := time.Now().UTC()
+	if err := uc.verificationRepo.CreateEmailVerificationToken(&domain.EmailVerificationToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: util.HashOpaqueToken(token),
+		ExpiresAt: now.Add(uc.tokenTTL),
+		CreatedAt: now,
+	}); err != nil {
+		return fmt.Errorf("не удалось сохранить токен подтверждения почты: %w", err)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Здравствуйте, %s!\n\n", user.Login)
+	body.WriteString("Подтвердите адрес почты, чтобы размещать объявления.\n")
+	if uc.verifyURL != "" {
+		fmt.Fprintf(&body, "Для подтверждения перейдите по ссылке:\n%s\n", withTokenParam(uc.verifyURL, token))
+	} else {
+		fmt.Fprintf(&body, "Код подтверждения:\n%s\n", token)
+	}
+	fmt.Fprintf(&body, "\nСсылка действует %d ч. Если вы не регистрировались, просто проигнорируйте это письмо.\n", int(uc.tokenTTL.Hours()))
+
+	if err := uc.mailer.Send(domain.EmailMessage{
+		To:      user.Email,
+		Subject: "Подтверждение почты",
+		Body:    body.String(),
+	}); err != nil {
+		return fmt.Errorf("не удалось отправить письмо: %w", err)
+	}
+	return nil
+}
+
+// ResendVerification повторно отправляет письмо для подтверждения почты.
+// Письма одному пользователю отправляются не чаще, чем раз в resendInterval.
+func (uc *EmailUseCase) ResendVerification(userID string) error {
+	user, err := uc.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return &ValidationErr{Message: "почта не указана"}
+	}
+	if user.EmailVerified() {
+		return ErrEmailAlreadyVerified
+	}
+
+	sentAt, err := uc.verificationRepo.LastEmailVerificationSentAt(user.ID)
+	if err != nil {
+		return fmt.Errorf("не удалось проверить время отправки письма: %w", err)
+	}
+	if sentAt != nil {
+		if wait := sentAt.Add(uc.resendInterval).Sub(time.Now().UTC()); wait > 0 {
+			return &RateLimitErr{Message: "письмо уже отправлено, повторите запрос позже", RetryAfter: wait}
+		}
+	}
+
+	return uc.SendVerification(user)
+}
+
+// VerifyEmail подтверждает почту по токену из письма. Токен действует, только
+// пока у пользователя тот же адрес, на который было отправлено письмо.
+func (uc *EmailUseCase) VerifyEmail(token string) error {
+	stored, err := uc.verificationRepo.GetEmailVerificationTokenByHash(util.HashOpaqueToken(token))
+	if err != nil {
+		return fmt.Errorf("не удалось получить токен подтверждения почты: %w", err)
+	}
+	now := time.Now().UTC()
+	if stored == nil || stored.UsedAt != nil || now.After(stored.ExpiresAt) {
+		return ErrInvalidEmailVerificationToken
+	}
+	used, err := uc.verificationRepo.MarkEmailVerificationTokenUsed(stored.ID, now)
+	if err != nil {
+		return fmt.Errorf("не удалось обновить токен подтверждения почты: %w", err)
+	}
+	if !used {
+		// Токен успели использовать параллельным запросом
+		return ErrInvalidEmailVerificationToken
+	}
+
+	verified, err := uc.userRepo.MarkEmailVerified(stored.UserID, stored.Email, now)
+	if err != nil {
+		return fmt.Errorf("не удалось подтвердить почту: %w", err)
+	}
+	if !verified {
+		// Почта изменилась после отправки письма
+		return ErrInvalidEmailVerificationToken
+	}
+	return nil
+}
+
+// ChangeEmail меняет почту пользователя после проверки пароля и отправляет
+// письмо для подтверждения нового адреса. До подтверждения размещать
+// объявления нельзя. Неверный пароль учитывается так же, как неудачная попытка
+// входа с адреса ip.
+func (uc *EmailUseCase) ChangeEmail(userID, password, email, ip string) (*domain.User, error) {
+	user, err := uc.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.loginThrottle.BeginPasswordCheck(user.Login, ip); err != nil {
+		return nil, err
+	}
+	if ok, _ := uc.passwordManager.Verify(password, user.PasswordHash); !ok {
+		return nil, ErrWrongPassword
+	}
+	uc.loginThrottle.AttemptSucceeded(user.Login, ip)
+
+	email = strings.TrimSpace(email)
+	if !isValidEmail(email) {
+		return nil, &ValidationErr{Message: "некорректный адрес почты"}
+	}
+	if strings.EqualFold(email, user.Email) {
+		return nil, &ValidationErr{Message: "новая почта совпадает с текущей"}
+	}
+	existingUser, err := uc.userRepo.GetUserByEmail(email)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось проверить существующего пользователя: %w", err)
+	}
+	if existingUser != nil {
+		return nil, ErrEmailAlreadyExists
+	}
+
+	if err := uc.userRepo.UpdateEmail(user.ID, email); err != nil {
+		if errors.Is(err, repository.ErrEmailTaken) {
+			return nil, ErrEmailAlreadyExists
+		}
+		return nil, fmt.Errorf("не удалось изменить почту: %w", err)
+	}
+	user.Email = email
+	user.EmailVerifiedAt = nil
+
+	if err := uc.SendVerification(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// RequireVerifiedEmail возвращает ErrEmailNotVerified, если почта пользователя
+// не подтверждена. Пользователи, зарегистрированные до появления подтверждения
+// почты, отмечены подтвердившими ее при миграции, даже если почта не указана.
+func (uc *EmailUseCase) RequireVerifiedEmail(userID string) error {
+	user, err := uc.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt == nil {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// getUser находит пользователя по ID.
+func (uc *EmailUseCase) getUser(userID string) (*domain.User, error) {
+	user, err := uc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить пользователя: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// withTokenParam добавляет токен параметром token к адресу страницы из письма.
+func withTokenParam(pageURL, token string) string {
+	separator := "?"
+	if strings.Contains(pageURL, "?") {
+		separator = "&"
+	}
+	return pageURL + separator + "token=" + url.QueryEscape(token)
+}
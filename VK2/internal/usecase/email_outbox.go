@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+	"vk/internal/infrastructure/util"
+)
+
+// maxEmailDeliveryAttempts — сколько раз обработчик пытается отправить письмо,
+// прежде чем удалить его из очереди.
+const maxEmailDeliveryAttempts = 5
+
+// emailDeliveryTimeout — время, после которого незавершенная отправка считается
+// прерванной (например, сервис был перезапущен) и письмо отправляется снова.
+const emailDeliveryTimeout = 5 * time.Minute
+
+// emailRetryDelay — пауза перед второй попыткой отправки; каждая следующая вдвое дольше.
+const emailRetryDelay = time.Minute
+
+// EmailOutboxRetention — наибольшее время, которое письмо проводит в очереди:
+// все попытки, прерванные по emailDeliveryTimeout, и паузы между ними. Письма
+// со ссылками для сброса пароля должны уходить или удаляться раньше, чем
+// истекает ссылка, поэтому срок действия ссылки должен быть больше.
+const EmailOutboxRetention = maxEmailDeliveryAttempts*emailDeliveryTimeout +
+	emailRetryDelay*(1<<(maxEmailDeliveryAttempts-1)-1)
+
+// EmailOutboxUseCase ставит письма в очередь и отправляет их в фоне, чтобы
+// медленный или недоступный почтовый сервер не задерживал ответы API. Сам
+// реализует Mailer, поэтому передается сценариям вместо отправителя писем.
+// Текст письма хранится в очереди зашифрованным: в нем бывают токены сброса
+// пароля и подтверждения почты, которые не должны читаться из БД.
+type EmailOutboxUseCase struct {
+	outboxRepo repository.EmailOutboxRepository
+	mailer     repository.Mailer
+	bodyBox    *util.SecretBox
+}
+
+var _ repository.Mailer = (*EmailOutboxUseCase)(nil)
+
+func NewEmailOutboxUseCase(outboxRepo repository.EmailOutboxRepository, mailer repository.Mailer, bodyBox *util.SecretBox) *EmailOutboxUseCase {
+	return &EmailOutboxUseCase{
+		outboxRepo: outboxRepo,
+		mailer:     mailer,
+		bodyBox:    bodyBox,
+	}
+}
+
+// Send ставит письмо в очередь на отправку.
+func (uc *EmailOutboxUseCase) Send(msg domain.EmailMessage) error {
+	body, err := uc.bodyBox.Seal(msg.Body)
+	if err != nil {
+		return fmt.Errorf("не удалось зашифровать письмо: %w", err)
+	}
+	msg.Body = body
+
+	now := time.Now().UTC()
+	if err := uc.outboxRepo.EnqueueEmail(&domain.OutboxEmail{
+		ID:            uuid.New().String(),
+		Message:       msg,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}); err != nil {
+		return fmt.Errorf("не удалось поставить письмо в очередь: %w", err)
+	}
+	return nil
+}
+
+// DeliverNextEmail отправляет одно письмо из очереди. Возвращает false, если
+// писем к отправке нет. Неотправленное письмо откладывается, а исчерпав
+// попытки, удаляется из очереди.
+func (uc *EmailOutboxUseCase) DeliverNextEmail() (bool, error) {
+	now := time.Now().UTC()
+	email, err := uc.outboxRepo.ClaimNextEmail(now, now.Add(emailDeliveryTimeout))
+	if err != nil {
+		return false, fmt.Errorf("failed to claim email: %w", err)
+	}
+	if email == nil {
+		return false, nil
+	}
+
+	body, err := uc.bodyBox.Open(email.Message.Body)
+	if err != nil {
+		// Письмо зашифровано другим ключом и никогда не будет отправлено
+		if deleteErr := uc.outboxRepo.DeleteEmail(email.ID); deleteErr != nil {
+			log.Printf("failed to delete email %s: %v", email.ID, deleteErr)
+		}
+		return true, fmt.Errorf("dropping email %s: %w", email.ID, err)
+	}
+	email.Message.Body = body
+
+	if err := uc.mailer.Send(email.Message); err != nil {
+		if email.Attempts >= maxEmailDeliveryAttempts {
+			log.Printf("dropping email %s after %d attempts", email.ID, email.Attempts)
+			if deleteErr := uc.outboxRepo.DeleteEmail(email.ID); deleteErr != nil {
+				log.Printf("failed to delete email %s: %v", email.ID, deleteErr)
+			}
+		} else {
+			nextAttemptAt := time.Now().UTC().Add(emailRetryDelay << (email.Attempts - 1))
+			if rescheduleErr := uc.outboxRepo.RescheduleEmail(email.ID, nextAttemptAt); rescheduleErr != nil {
+				log.Printf("failed to reschedule email %s: %v", email.ID, rescheduleErr)
+			}
+		}
+		return true, fmt.Errorf("failed to send email %s (attempt %d): %w", email.ID, email.Attempts, err)
+	}
+
+	if err := uc.outboxRepo.DeleteEmail(email.ID); err != nil {
+		return true, fmt.Errorf("failed to delete sent email %s: %w", email.ID, err)
+	}
+	return true, nil
+}
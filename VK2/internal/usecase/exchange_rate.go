@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+type ExchangeRateUseCase struct {
+	rateRepo repository.ExchangeRateRepository
+}
+
+func NewExchangeRateUseCase(rateRepo repository.ExchangeRateRepository) *ExchangeRateUseCase {
+	return &ExchangeRateUseCase{rateRepo: rateRepo}
+}
+
+// ListRates возвращает курсы всех валют к базовой валюте.
+func (uc *ExchangeRateUseCase) ListRates() ([]domain.ExchangeRate, error) {
+	rates, err := uc.rateRepo.ListRates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+	return rates, nil
+}
+
+// SetRate задает курс валюты: сколько единиц базовой валюты стоит единица currency.
+// Курс базовой валюты изменить нельзя.
+func (uc *ExchangeRateUseCase) SetRate(currency, rate string) (*domain.ExchangeRate, error) {
+	exchangeRate, err := newExchangeRate(currency, rate)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.rateRepo.SetRate(exchangeRate); err != nil {
+		return nil, fmt.Errorf("failed to save exchange rate: %w", err)
+	}
+	return exchangeRate, nil
+}
+
+// SetRates задает несколько курсов, например из файла при запуске сервиса.
+// Если хотя бы один курс некорректен, ни один курс не сохраняется.
+func (uc *ExchangeRateUseCase) SetRates(rates map[string]string) error {
+	exchangeRates := make([]*domain.ExchangeRate, 0, len(rates))
+	for currency, rate := range rates {
+		exchangeRate, err := newExchangeRate(currency, rate)
+		if err != nil {
+			return err
+		}
+		exchangeRates = append(exchangeRates, exchangeRate)
+	}
+
+	for _, exchangeRate := range exchangeRates {
+		if err := uc.rateRepo.SetRate(exchangeRate); err != nil {
+			return fmt.Errorf("failed to save exchange rate for %s: %w", exchangeRate.Currency, err)
+		}
+	}
+	return nil
+}
+
+// newExchangeRate проверяет код валюты и курс.
+func newExchangeRate(currency, rate string) (*domain.ExchangeRate, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !domain.IsSupportedCurrency(currency) {
+		return nil, &ValidationErr{Message: fmt.Sprintf("unsupported currency %q", currency)}
+	}
+	if currency == domain.BaseCurrency {
+		return nil, &ValidationErr{Message: "the base currency rate is always 1"}
+	}
+
+	parsed, err := domain.ParseRate(rate)
+	switch {
+	case errors.Is(err, domain.ErrRatePrecision):
+		return nil, &ValidationErr{Message: fmt.Sprintf("%s: rate must have at most 6 decimal places", currency)}
+	case errors.Is(err, domain.ErrRateRange):
+		return nil, &ValidationErr{Message: fmt.Sprintf("%s: rate must not exceed %s", currency, domain.MaxRate)}
+	case err != nil:
+		return nil, &ValidationErr{Message: fmt.Sprintf("%s: rate must be a positive decimal number", currency)}
+	}
+
+	return &domain.ExchangeRate{
+		Currency:  currency,
+		Rate:      parsed,
+		UpdatedAt: time.Now().UTC(),
+	}, nil
+}
@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"time"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+	"vk/internal/infrastructure/imaging"
+)
+
+// maxImageProcessingAttempts — сколько раз обработчик пытается построить варианты
+// изображения, прежде чем отметить его как необработанное.
+const maxImageProcessingAttempts = 3
+
+// imageProcessingTimeout — время, после которого незавершенная обработка считается
+// прерванной (например, сервис был перезапущен) и изображение обрабатывается снова.
+const imageProcessingTimeout = 5 * time.Minute
+
+// ImageProcessingUseCase строит уменьшенные копии загруженных изображений.
+// Вызывается фоновым обработчиком; очередь хранится в БД и переживает перезапуск.
+type ImageProcessingUseCase struct {
+	imageRepo   repository.AdImageRepository
+	blobStorage repository.BlobStorage
+}
+
+func NewImageProcessingUseCase(imageRepo repository.AdImageRepository, blobStorage repository.BlobStorage) *ImageProcessingUseCase {
+	return &ImageProcessingUseCase{
+		imageRepo:   imageRepo,
+		blobStorage: blobStorage,
+	}
+}
+
+// ProcessNextImage обрабатывает одно изображение из очереди. Возвращает false,
+// если очередь пуста.
+func (uc *ImageProcessingUseCase) ProcessNextImage() (bool, error) {
+	now := time.Now().UTC()
+	staleBefore := now.Add(-imageProcessingTimeout)
+	image, err := uc.imageRepo.ClaimNextImage(now, staleBefore, maxImageProcessingAttempts)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim image: %w", err)
+	}
+	if image == nil {
+		// Изображение, обработка которого прервалась на последней попытке, больше
+		// не забирается из очереди; без этого оно осталось бы в processing навсегда
+		failed, err := uc.imageRepo.FailStaleImages(staleBefore, maxImageProcessingAttempts)
+		if err != nil {
+			return false, fmt.Errorf("failed to fail stale images: %w", err)
+		}
+		if failed > 0 {
+			log.Printf("marked %d stale images as failed", failed)
+		}
+		return false, nil
+	}
+
+	if err := uc.processImage(image); err != nil {
+		// Исчерпав попытки, изображение остается без вариантов: клиенты получат оригинал
+		status := domain.ImageStatusPending
+		if image.Attempts >= maxImageProcessingAttempts {
+			status = domain.ImageStatusFailed
+		}
+		if statusErr := uc.imageRepo.SetImageStatus(image.ID, status); statusErr != nil {
+			log.Printf("failed to update status of image %s: %v", image.ID, statusErr)
+		}
+		return true, fmt.Errorf("failed to process image %s (attempt %d): %w", image.ID, image.Attempts, err)
+	}
+	return true, nil
+}
+
+// processImage строит и сохраняет все варианты изображения в формате JPEG.
+// Ориентация из EXIF применяется до уменьшения.
+func (uc *ImageProcessingUseCase) processImage(image *domain.AdImage) error {
+	data, err := uc.blobStorage.Get(image.StorageKey)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return fmt.Errorf("file %s not found", image.StorageKey)
+	}
+	src, err := imaging.DecodeOriented(data)
+	if err != nil {
+		return err
+	}
+
+	variants := make([]domain.AdImageVariant, 0, len(domain.ImageVariantSpecs))
+	for _, spec := range domain.ImageVariantSpecs {
+		resized := imaging.ResizeToFit(src, spec.MaxSide)
+		variant, err := uc.storeVariant(image, spec.Name, resized)
+		if err != nil {
+			uc.deleteVariants(variants)
+			return err
+		}
+		variants = append(variants, *variant)
+	}
+
+	// Если изображение удалили во время обработки, сохранить варианты не получится
+	if err := uc.imageRepo.SaveImageVariants(image.ID, variants); err != nil {
+		uc.deleteVariants(variants)
+		return err
+	}
+	return nil
+}
+
+// storeVariant кодирует вариант в JPEG и сохраняет файл.
+func (uc *ImageProcessingUseCase) storeVariant(original *domain.AdImage, name string, resized image.Image) (*domain.AdImageVariant, error) {
+	jpegData, err := imaging.EncodeJPEG(resized)
+	if err != nil {
+		return nil, err
+	}
+
+	variant := &domain.AdImageVariant{
+		ImageID:     original.ID,
+		Name:        name,
+		StorageKey:  fmt.Sprintf("ads/%s/%s_%s.jpg", original.AdID, original.ID, name),
+		ContentType: imaging.ContentTypeJPEG,
+		Width:       resized.Bounds().Dx(),
+		Height:      resized.Bounds().Dy(),
+	}
+	if err := uc.blobStorage.Put(variant.StorageKey, jpegData, imaging.ContentTypeJPEG); err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+// deleteVariants удаляет файлы вариантов, которые не удалось сохранить.
+func (uc *ImageProcessingUseCase) deleteVariants(variants []domain.AdImageVariant) {
+	for _, variant := range variants {
+		uc.deleteBlob(variant.StorageKey)
+	}
+}
+
+// deleteBlob удаляет файл, ошибка только записывается в журнал.
+func (uc *ImageProcessingUseCase) deleteBlob(key string) {
+	if err := uc.blobStorage.Delete(key); err != nil {
+		log.Printf("failed to delete blob %s: %v", key, err)
+	}
+}
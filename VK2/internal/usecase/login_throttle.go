@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+	"vk/internal/infrastructure/util"
+)
+
+// loginThrottleBatchSize — сколько устаревших счетчиков фоновая задача удаляет за один вызов.
+const loginThrottleBatchSize = 1000
+
+var (
+	// loginPolicy ограничивает подбор пароля к одной учетной записи.
+	loginPolicy = domain.LoginThrottlePolicy{
+		FreeAttempts: 5,
+		BaseLockout:  30 * time.Second,
+		MaxLockout:   15 * time.Minute,
+		ResetAfter:   time.Hour,
+	}
+	// ipPolicy ограничивает перебор логинов с одного адреса. Допускает больше
+	// попыток: за одним адресом может быть много пользователей.
+	ipPolicy = domain.LoginThrottlePolicy{
+		FreeAttempts: 20,
+		BaseLockout:  30 * time.Second,
+		MaxLockout:   15 * time.Minute,
+		ResetAfter:   time.Hour,
+	}
+	// twoFactorPolicy ограничивает подбор кода второго фактора к одной учетной
+	// записи. Счетчик ведется по пользователю, а не по входу: иначе каждый новый
+	// вход с верным паролем давал бы новые попытки. Сбрасывается только через сутки.
+	twoFactorPolicy = domain.LoginThrottlePolicy{
+		FreeAttempts: 5,
+		BaseLockout:  time.Minute,
+		MaxLockout:   time.Hour,
+		ResetAfter:   24 * time.Hour,
+	}
+	// resetEmailPolicy ограничивает письма для сброса пароля на один адрес,
+	// чтобы сбросом нельзя было завалить чужой почтовый ящик.
+	resetEmailPolicy = domain.LoginThrottlePolicy{
+		FreeAttempts: 3,
+		BaseLockout:  5 * time.Minute,
+		MaxLockout:   time.Hour,
+		ResetAfter:   time.Hour,
+	}
+	// resetIPPolicy ограничивает запросы на сброс пароля с одного адреса.
+	resetIPPolicy = domain.LoginThrottlePolicy{
+		FreeAttempts: 10,
+		BaseLockout:  time.Minute,
+		MaxLockout:   time.Hour,
+		ResetAfter:   time.Hour,
+	}
+)
+
+// LoginThrottleUseCase защищает вход от подбора пароля: считает неудачные
+// попытки по логину и по IP-адресу и после нескольких неудач подряд блокирует
+// вход на время, которое удваивается с каждой следующей неудачей. Те же
+// счетчики ограничивают частоту запросов на сброс пароля.
+type LoginThrottleUseCase struct {
+	throttleRepo repository.LoginThrottleRepository
+}
+
+func NewLoginThrottleUseCase(throttleRepo repository.LoginThrottleRepository) *LoginThrottleUseCase {
+	return &LoginThrottleUseCase{throttleRepo: throttleRepo}
+}
+
+// loginThrottleKey — ключ счетчика и правила блокировки для него.
+type loginThrottleKey struct {
+	key    string
+	policy domain.LoginThrottlePolicy
+}
+
+// BeginAttempt заранее учитывает попытку входа как неудачную, чтобы параллельные
+// запросы не обходили ограничение. Если вход заблокирован, возвращает RateLimitErr;
+// сообщение не зависит от того, по логину или по адресу наступила блокировка.
+func (uc *LoginThrottleUseCase) BeginAttempt(login, ip string) error {
+	return uc.claim(loginThrottleKeys(login, ip), "слишком много попыток входа, повторите позже")
+}
+
+// BeginPasswordCheck заранее учитывает проверку пароля пользователя с логином
+// login вне входа (смена пароля или почты, подключение и отключение второго
+// фактора). Счетчики общие со входом: иначе пароль можно было бы подбирать через
+// эти запросы. После успешной проверки вызывается AttemptSucceeded.
+func (uc *LoginThrottleUseCase) BeginPasswordCheck(login, ip string) error {
+	return uc.claim(loginThrottleKeys(login, ip), "слишком много неверных паролей, повторите позже")
+}
+
+// BeginTwoFactorAttempt заранее учитывает попытку ввода кода второго фактора
+// пользователем userID как неудачную.
+func (uc *LoginThrottleUseCase) BeginTwoFactorAttempt(userID string) error {
+	keys := []loginThrottleKey{{key: throttleKey("2fa", userID), policy: twoFactorPolicy}}
+	return uc.claim(keys, "слишком много неверных кодов, повторите позже")
+}
+
+// TwoFactorAttemptSucceeded сбрасывает счетчик неверных кодов пользователя userID.
+func (uc *LoginThrottleUseCase) TwoFactorAttemptSucceeded(userID string) {
+	if err := uc.throttleRepo.ResetLoginThrottle(throttleKey("2fa", userID)); err != nil {
+		log.Printf("failed to reset two-factor throttle: %v", err)
+	}
+}
+
+// BeginPasswordReset учитывает запрос на сброс пароля по адресу почты и IP-адресу.
+// Каждый запрос отправляет письмо, поэтому учитывается как попытка независимо
+// от того, зарегистрирована ли почта.
+func (uc *LoginThrottleUseCase) BeginPasswordReset(email, ip string) error {
+	var keys []loginThrottleKey
+	if ip != "" {
+		keys = append(keys, loginThrottleKey{key: throttleKey("reset-ip", ip), policy: resetIPPolicy})
+	}
+	keys = append(keys, loginThrottleKey{key: throttleKey("reset-email", strings.ToLower(email)), policy: resetEmailPolicy})
+	return uc.claim(keys, "слишком много запросов на сброс пароля, повторите позже")
+}
+
+// claim учитывает попытку по каждому из ключей по порядку. Если по ключу
+// попытки заблокированы, возвращает RateLimitErr с сообщением message.
+func (uc *LoginThrottleUseCase) claim(keys []loginThrottleKey, message string) error {
+	now := time.Now().UTC()
+	for _, key := range keys {
+		throttle, claimed, err := uc.throttleRepo.ClaimLoginAttempt(key.key, key.policy, now)
+		if err != nil {
+			return fmt.Errorf("не удалось учесть попытку: %w", err)
+		}
+		if !claimed {
+			return &RateLimitErr{
+				Message:    message,
+				RetryAfter: throttle.LockedUntil.Sub(now),
+			}
+		}
+	}
+	return nil
+}
+
+// AttemptSucceeded вызывается после успешного входа: счетчик логина
+// сбрасывается, а попытка не засчитывается адресу как неудачная. Если подключен
+// второй фактор, вызывается только после проверки кода.
+func (uc *LoginThrottleUseCase) AttemptSucceeded(login, ip string) {
+	if err := uc.throttleRepo.ResetLoginThrottle(throttleKey("login", login)); err != nil {
+		log.Printf("failed to reset login throttle: %v", err)
+	}
+	if ip != "" {
+		if err := uc.throttleRepo.ReleaseLoginAttempt(throttleKey("ip", ip)); err != nil {
+			log.Printf("failed to release login attempt: %v", err)
+		}
+	}
+}
+
+// DeleteStaleThrottles удаляет очередную партию счетчиков, по которым давно не
+// было неудачных попыток. Возвращает true, если партия заполнена полностью.
+func (uc *LoginThrottleUseCase) DeleteStaleThrottles() (bool, error) {
+	before := time.Now().UTC().Add(-max(loginPolicy.ResetAfter, ipPolicy.ResetAfter, twoFactorPolicy.ResetAfter, resetEmailPolicy.ResetAfter, resetIPPolicy.ResetAfter))
+	count, err := uc.throttleRepo.DeleteStaleLoginThrottles(before, loginThrottleBatchSize)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete stale login throttles: %w", err)
+	}
+	return count == loginThrottleBatchSize, nil
+}
+
+// loginThrottleKeys возвращает ключи счетчиков для попытки входа. Адрес
+// проверяется первым: пока он заблокирован, перебор с него не увеличивает
+// счетчики чужих логинов и не блокирует их владельцев.
+func loginThrottleKeys(login, ip string) []loginThrottleKey {
+	var keys []loginThrottleKey
+	if ip != "" {
+		keys = append(keys, loginThrottleKey{key: throttleKey("ip", ip), policy: ipPolicy})
+	}
+	return append(keys, loginThrottleKey{key: throttleKey("login", login), policy: loginPolicy})
+}
+
+// throttleKey хеширует значение с префиксом вида, чтобы в БД не попадали
+// введенные логины (в поле логина иногда по ошибке вводят пароль).
+func throttleKey(kind, value string) string {
+	return util.HashOpaqueToken(kind + ":" + value)
+}
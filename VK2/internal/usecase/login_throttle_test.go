@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"vk/internal/domain"
+)
+
+// memoryThrottleRepository хранит счетчики в памяти по тем же правилам, что и PostgreSQL.
+type memoryThrottleRepository struct {
+	throttles map[string]*domain.LoginThrottle
+}
+
+func newMemoryThrottleRepository() *memoryThrottleRepository {
+	return &memoryThrottleRepository{throttles: make(map[string]*domain.LoginThrottle)}
+}
+
+func (r *memoryThrottleRepository) ClaimLoginAttempt(key string, policy domain.LoginThrottlePolicy, now time.Time) (*domain.LoginThrottle, bool, error) {
+	throttle, ok := r.throttles[key]
+	if !ok {
+		throttle = &domain.LoginThrottle{Key: key, LastFailureAt: now}
+		r.throttles[key] = throttle
+	}
+	if throttle.Locked(now) {
+		copied := *throttle
+		return &copied, false, nil
+	}
+	throttle.RecordFailure(policy, now)
+	copied := *throttle
+	return &copied, true, nil
+}
+
+func (r *memoryThrottleRepository) ReleaseLoginAttempt(key string) error {
+	if throttle, ok := r.throttles[key]; ok {
+		throttle.Failures = max(throttle.Failures-1, 0)
+		throttle.LockedUntil = nil
+	}
+	return nil
+}
+
+func (r *memoryThrottleRepository) ResetLoginThrottle(key string) error {
+	delete(r.throttles, key)
+	return nil
+}
+
+func (r *memoryThrottleRepository) DeleteStaleLoginThrottles(before time.Time, limit int) (int, error) {
+	return 0, nil
+}
+
+// wantRateLimit проверяет, что err — RateLimitErr с положительным RetryAfter.
+func wantRateLimit(t *testing.T, err error) {
+	t.Helper()
+	var rateLimitErr *RateLimitErr
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("error = %v, want *RateLimitErr", err)
+	}
+	if rateLimitErr.RetryAfter <= 0 {
+		t.Errorf("RetryAfter = %s, want positive", rateLimitErr.RetryAfter)
+	}
+}
+
+func TestBeginAttemptLocksLogin(t *testing.T) {
+	uc := NewLoginThrottleUseCase(newMemoryThrottleRepository())
+
+	for i := 0; i < loginPolicy.FreeAttempts; i++ {
+		if err := uc.BeginAttempt("alice", "10.0.0.1"); err != nil {
+			t.Fatalf("attempt %d: error = %v", i+1, err)
+		}
+	}
+	wantRateLimit(t, uc.BeginAttempt("alice", "10.0.0.1"))
+	// Блокировка относится к логину, а не к адресу
+	wantRateLimit(t, uc.BeginAttempt("alice", "10.0.0.2"))
+	if err := uc.BeginAttempt("bob", "10.0.0.1"); err != nil {
+		t.Errorf("other login: error = %v, want nil", err)
+	}
+}
+
+func TestBeginAttemptLocksIP(t *testing.T) {
+	uc := NewLoginThrottleUseCase(newMemoryThrottleRepository())
+
+	for i := 0; i < ipPolicy.FreeAttempts; i++ {
+		if err := uc.BeginAttempt(fmt.Sprintf("user%d", i), "10.0.0.1"); err != nil {
+			t.Fatalf("attempt %d: error = %v", i+1, err)
+		}
+	}
+	wantRateLimit(t, uc.BeginAttempt("someone", "10.0.0.1"))
+	if err := uc.BeginAttempt("someone", "10.0.0.2"); err != nil {
+		t.Errorf("other address: error = %v, want nil", err)
+	}
+}
+
+func TestAttemptSucceededResetsLogin(t *testing.T) {
+	uc := NewLoginThrottleUseCase(newMemoryThrottleRepository())
+
+	for i := 0; i < loginPolicy.FreeAttempts-1; i++ {
+		if err := uc.BeginAttempt("alice", "10.0.0.1"); err != nil {
+			t.Fatalf("attempt %d: error = %v", i+1, err)
+		}
+	}
+	if err := uc.BeginAttempt("alice", "10.0.0.1"); err != nil {
+		t.Fatalf("successful attempt: error = %v", err)
+	}
+	uc.AttemptSucceeded("alice", "10.0.0.1")
+
+	// Удачная попытка снимает и блокировку, которую поставила она сама
+	for i := 0; i < loginPolicy.FreeAttempts; i++ {
+		if err := uc.BeginAttempt("alice", "10.0.0.1"); err != nil {
+			t.Fatalf("attempt %d after success: error = %v", i+1, err)
+		}
+	}
+}
+
+func TestAttemptSucceededReleasesIPLock(t *testing.T) {
+	uc := NewLoginThrottleUseCase(newMemoryThrottleRepository())
+
+	for i := 0; i < ipPolicy.FreeAttempts-1; i++ {
+		if err := uc.BeginAttempt(fmt.Sprintf("user%d", i), "10.0.0.1"); err != nil {
+			t.Fatalf("attempt %d: error = %v", i+1, err)
+		}
+	}
+	// Эта попытка ставит блокировку адреса, но оказывается успешной
+	if err := uc.BeginAttempt("alice", "10.0.0.1"); err != nil {
+		t.Fatalf("successful attempt: error = %v", err)
+	}
+	uc.AttemptSucceeded("alice", "10.0.0.1")
+
+	if err := uc.BeginAttempt("bob", "10.0.0.1"); err != nil {
+		t.Errorf("attempt after success: error = %v, want nil", err)
+	}
+}
+
+func TestBeginPasswordCheckSharesLoginCounter(t *testing.T) {
+	uc := NewLoginThrottleUseCase(newMemoryThrottleRepository())
+
+	for i := 0; i < loginPolicy.FreeAttempts; i++ {
+		if err := uc.BeginPasswordCheck("alice", "10.0.0.1"); err != nil {
+			t.Fatalf("check %d: error = %v", i+1, err)
+		}
+	}
+	wantRateLimit(t, uc.BeginAttempt("alice", "10.0.0.2"))
+}
+
+func TestBeginTwoFactorAttempt(t *testing.T) {
+	uc := NewLoginThrottleUseCase(newMemoryThrottleRepository())
+
+	for i := 0; i < twoFactorPolicy.FreeAttempts; i++ {
+		if err := uc.BeginTwoFactorAttempt("user-1"); err != nil {
+			t.Fatalf("attempt %d: error = %v", i+1, err)
+		}
+	}
+	wantRateLimit(t, uc.BeginTwoFactorAttempt("user-1"))
+	if err := uc.BeginTwoFactorAttempt("user-2"); err != nil {
+		t.Errorf("other user: error = %v, want nil", err)
+	}
+
+	uc.TwoFactorAttemptSucceeded("user-1")
+	if err := uc.BeginTwoFactorAttempt("user-1"); err != nil {
+		t.Errorf("after success: error = %v, want nil", err)
+	}
+}
+
+func TestBeginPasswordReset(t *testing.T) {
+	uc := NewLoginThrottleUseCase(newMemoryThrottleRepository())
+
+	for i := 0; i < resetEmailPolicy.FreeAttempts; i++ {
+		if err := uc.BeginPasswordReset("Alice@Example.com", fmt.Sprintf("10.0.0.%d", i)); err != nil {
+			t.Fatalf("request %d: error = %v", i+1, err)
+		}
+	}
+	// Адрес почты сравнивается без учета регистра
+	wantRateLimit(t, uc.BeginPasswordReset("alice@example.com", "10.0.1.1"))
+	if err := uc.BeginPasswordReset("bob@example.com", "10.0.1.1"); err != nil {
+		t.Errorf("other email: error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,244 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+	"vk/internal/infrastructure/util"
+)
+
+var (
+	ErrWrongPassword             = errors.New("текущий пароль указан неверно")
+	ErrInvalidPasswordResetToken = errors.New("ссылка для сброса пароля недействительна или устарела")
+)
+
+// PasswordUseCase отвечает за смену и восстановление пароля.
+type PasswordUseCase struct {
+	userRepo        repository.UserRepository
+	sessionRepo     repository.SessionRepository
+	resetRepo       repository.PasswordResetRepository
+	mailer          repository.Mailer
+	loginThrottle   *LoginThrottleUseCase
+	passwordPolicy  *PasswordPolicy
+	passwordManager *util.PasswordManager
+	resetTTL        time.Duration // Срок действия ссылки для сброса пароля
+	resetURL        string        // Адрес страницы сброса пароля; токен добавляется параметром token
+}
+
+func NewPasswordUseCase(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, resetRepo repository.PasswordResetRepository, mailer repository.Mailer, loginThrottle *LoginThrottleUseCase, passwordPolicy *PasswordPolicy, passwordManager *util.PasswordManager, resetTTL time.Duration, resetURL string) *PasswordUseCase {
+	return &PasswordUseCase{
+		userRepo:        userRepo,
+		sessionRepo:     sessionRepo,
+		resetRepo:       resetRepo,
+		mailer:          mailer,
+		loginThrottle:   loginThrottle,
+		passwordPolicy:  passwordPolicy,
+		passwordManager: passwordManager,
+		resetTTL:        resetTTL,
+		resetURL:        resetURL,
+	}
+}
+
+// ChangePassword меняет пароль пользователя после проверки текущего. Остальные
+// сессии пользователя завершаются, текущая (sessionID) сохраняется. Неверный
+// текущий пароль учитывается так же, как неудачная попытка входа с адреса ip.
+func (uc *PasswordUseCase) ChangePassword(userID, sessionID, currentPassword, newPassword, ip string) error {
+	user, err := uc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("не удалось получить пользователя: %w", err)
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if err := uc.loginThrottle.BeginPasswordCheck(user.Login, ip); err != nil {
+		return err
+	}
+	if ok, _ := uc.passwordManager.Verify(currentPassword, user.PasswordHash); !ok {
+		return ErrWrongPassword
+	}
+	uc.loginThrottle.AttemptSucceeded(user.Login, ip)
+	if err := uc.passwordPolicy.Validate(newPassword, user.Login, user.Email); err != nil {
+		return err
+	}
+	if newPassword == currentPassword {
+		return &ValidationErr{Message: "новый пароль должен отличаться от текущего"}
+	}
+
+	if err := uc.setPassword(user, newPassword); err != nil {
+		return err
+	}
+	if err := uc.sessionRepo.RevokeOtherUserSessions(user.ID, sessionID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("не удалось завершить остальные сессии: %w", err)
+	}
+	return nil
+}
+
+// RequestPasswordReset ставит в очередь запрос на отправку ссылки для сброса
+// пароля. Пользователь ищется и письмо отправляется в фоне: иначе по ответу или
+// по времени ответа можно было бы узнать, какие адреса зарегистрированы.
+func (uc *PasswordUseCase) RequestPasswordReset(email, ip string) error {
+	email = strings.TrimSpace(email)
+	if !isValidEmail(email) {
+		return &ValidationErr{Message: "некорректный адрес почты"}
+	}
+	if err := uc.loginThrottle.BeginPasswordReset(email, ip); err != nil {
+		return err
+	}
+
+	if err := uc.resetRepo.EnqueuePasswordResetRequest(&domain.PasswordResetRequest{
+		ID:        uuid.New().String(),
+		Email:     email,
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		return fmt.Errorf("не удалось поставить запрос на сброс пароля в очередь: %w", err)
+	}
+	return nil
+}
+
+// ProcessPasswordResetRequest обрабатывает очередной запрос на сброс пароля:
+// отправляет ссылку для сброса, если почта принадлежит пользователю и подтверждена.
+// Возвращает false, если очередь пуста.
+func (uc *PasswordUseCase) ProcessPasswordResetRequest() (bool, error) {
+	request, err := uc.resetRepo.TakePasswordResetRequest()
+	if err != nil {
+		return false, fmt.Errorf("failed to take password reset request: %w", err)
+	}
+	if request == nil {
+		return false, nil
+	}
+
+	user, err := uc.userRepo.GetUserByEmail(request.Email)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user by email: %w", err)
+	}
+	if user == nil || !user.EmailVerified() {
+		return true, nil
+	}
+	if err := uc.sendResetLink(user); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// sendResetLink выдает пользователю токен сброса пароля и отправляет ссылку письмом.
+func (uc *PasswordUseCase) sendResetLink(user *domain.User) error {
+	token, err := util.GenerateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+	now := time.Now().UTC()
+	if err := uc.resetRepo.CreatePasswordResetToken(&domain.PasswordResetToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		TokenHash: util.HashOpaqueToken(token),
+		ExpiresAt: now.Add(uc.resetTTL),
+		CreatedAt: now,
+	}); err != nil {
+		return fmt.Errorf("failed to save password reset token: %w", err)
+	}
+
+	if err := uc.mailer.Send(domain.EmailMessage{
+		To:      user.Email,
+		Subject: "Сброс пароля",
+		Body:    uc.resetMessage(user, token),
+	}); err != nil {
+		log.Printf("failed to send password reset email to user %s: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword устанавливает новый пароль по токену из письма. Токен становится
+// недействительным, все сессии пользователя завершаются.
+func (uc *PasswordUseCase) ResetPassword(token, newPassword string) error {
+	stored, err := uc.resetRepo.GetPasswordResetTokenByHash(util.HashOpaqueToken(token))
+	if err != nil {
+		return fmt.Errorf("не удалось получить токен сброса пароля: %w", err)
+	}
+	now := time.Now().UTC()
+	if stored == nil || stored.UsedAt != nil || now.After(stored.ExpiresAt) {
+		return ErrInvalidPasswordResetToken
+	}
+
+	user, err := uc.userRepo.GetUserByID(stored.UserID)
+	if err != nil {
+		return fmt.Errorf("не удалось получить пользователя: %w", err)
+	}
+	if user == nil {
+		return ErrInvalidPasswordResetToken
+	}
+
+	// Пароль проверяется до использования токена, чтобы неудачная попытка его не тратила
+	if err := uc.passwordPolicy.Validate(newPassword, user.Login, user.Email); err != nil {
+		return err
+	}
+	hashedPassword, err := uc.passwordManager.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("не удалось хешировать пароль: %w", err)
+	}
+	// Токен тратится в одной транзакции со сменой пароля
+	used, err := uc.resetRepo.ResetPasswordWithToken(stored.ID, user.ID, hashedPassword, now)
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить пароль: %w", err)
+	}
+	if !used {
+		// Токен успели использовать параллельным запросом
+		return ErrInvalidPasswordResetToken
+	}
+
+	uc.notifyPasswordChanged(user)
+	if err := uc.sessionRepo.RevokeUserSessions(user.ID, now); err != nil {
+		return fmt.Errorf("не удалось завершить сессии: %w", err)
+	}
+	return nil
+}
+
+// setPassword сохраняет новый пароль, аннулирует выданные ссылки для сброса
+// и сообщает пользователю о смене пароля.
+func (uc *PasswordUseCase) setPassword(user *domain.User, password string) error {
+	hashedPassword, err := uc.passwordManager.Hash(password)
+	if err != nil {
+		return fmt.Errorf("не удалось хешировать пароль: %w", err)
+	}
+	if err := uc.resetRepo.UpdatePasswordAndInvalidateTokens(user.ID, hashedPassword, time.Now().UTC()); err != nil {
+		return fmt.Errorf("не удалось сохранить пароль: %w", err)
+	}
+	uc.notifyPasswordChanged(user)
+	return nil
+}
+
+// notifyPasswordChanged сообщает пользователю о смене пароля, если его почта подтверждена.
+func (uc *PasswordUseCase) notifyPasswordChanged(user *domain.User) {
+	if !user.EmailVerified() {
+		return
+	}
+	if err := uc.mailer.Send(domain.EmailMessage{
+		To:      user.Email,
+		Subject: "Пароль изменен",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nПароль вашей учетной записи был изменен. "+
+			"Если это сделали не вы, восстановите доступ через сброс пароля.\n", user.Login),
+	}); err != nil {
+		log.Printf("failed to send password change notice to user %s: %v", user.ID, err)
+	}
+}
+
+// resetMessage формирует текст письма со ссылкой для сброса пароля.
+func (uc *PasswordUseCase) resetMessage(user *domain.User, token string) string {
+	var body strings.Builder
+	fmt.Fprintf(&body, "Здравствуйте, %s!\n\n", user.Login)
+	body.WriteString("Мы получили запрос на сброс пароля вашей учетной записи.\n")
+	if uc.resetURL != "" {
+		fmt.Fprintf(&body, "Чтобы задать новый пароль, перейдите по ссылке:\n%s\n", withTokenParam(uc.resetURL, token))
+	} else {
+		fmt.Fprintf(&body, "Код для сброса пароля:\n%s\n", token)
+	}
+	fmt.Fprintf(&body, "\nСсылка действует %d мин. и может быть использована один раз. "+
+		"Если вы не запрашивали сброс пароля, просто проигнорируйте это письмо.\n", int(uc.resetTTL.Minutes()))
+	return body.String()
+}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/infrastructure/util"
+)
+
+// PasswordPolicy проверяет новые пароли: длину и состав, стойкость к подбору
+// и наличие в базе паролей из утечек.
+type PasswordPolicy struct {
+	breachedRepo repository.BreachedPasswordRepository // nil — проверка по утечкам отключена
+	minStrength  int                                   // Наименьшая допустимая оценка стойкости, от 0 до 4
+}
+
+func NewPasswordPolicy(breachedRepo repository.BreachedPasswordRepository, minStrength int) *PasswordPolicy {
+	return &PasswordPolicy{
+		breachedRepo: breachedRepo,
+		minStrength:  minStrength,
+	}
+}
+
+// Validate проверяет новый пароль. userInputs — логин и почта пользователя:
+// пароль, составленный из них, подбирают в первую очередь.
+func (p *PasswordPolicy) Validate(password string, userInputs ...string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+
+	if strength := util.EstimatePasswordStrength(password, userInputs...); strength.Score < p.minStrength {
+		message := "пароль слишком легко подобрать"
+		if strength.Warning != "" {
+			message += ": " + strength.Warning
+		}
+		return &ValidationErr{Message: message}
+	}
+
+	breached, err := p.isBreached(password)
+	if err != nil {
+		return err
+	}
+	if breached {
+		return &ValidationErr{Message: "пароль встречается в утечках данных, придумайте другой"}
+	}
+	return nil
+}
+
+// isBreached ищет пароль в базе утечек. Базе передается только префикс SHA-1
+// хеша, а совпадение суффикса проверяется здесь.
+func (p *PasswordPolicy) isBreached(password string) (bool, error) {
+	if p.breachedRepo == nil {
+		return false, nil
+	}
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	suffixes, err := p.breachedRepo.GetBreachedHashSuffixes(hash[:5])
+	if err != nil {
+		return false, fmt.Errorf("не удалось проверить пароль по базе утечек: %w", err)
+	}
+	return suffixes[hash[5:]] > 0, nil
+}
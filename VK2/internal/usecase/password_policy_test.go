@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeBreachedPasswordRepository отдает суффиксы хешей из списка паролей и
+// запоминает запрошенные префиксы.
+type fakeBreachedPasswordRepository struct {
+	passwords []string
+	err       error
+	prefixes  []string
+}
+
+func (r *fakeBreachedPasswordRepository) GetBreachedHashSuffixes(prefix string) (map[string]int, error) {
+	r.prefixes = append(r.prefixes, prefix)
+	if r.err != nil {
+		return nil, r.err
+	}
+	suffixes := make(map[string]int)
+	for _, password := range r.passwords {
+		sum := sha1.Sum([]byte(password))
+		if hash := strings.ToUpper(hex.EncodeToString(sum[:])); hash[:5] == prefix {
+			suffixes[hash[5:]] = 7
+		}
+	}
+	return suffixes, nil
+}
+
+const (
+	strongPassword   = "Xk9#vLq2!mWz"
+	breachedPassword = "Tr0ub4dour&3Horse!"
+)
+
+func TestPasswordPolicyRejectsBreachedPassword(t *testing.T) {
+	repo := &fakeBreachedPasswordRepository{passwords: []string{breachedPassword}}
+	policy := NewPasswordPolicy(repo, 2)
+
+	err := policy.Validate(breachedPassword, "alice", "alice@example.com")
+	var validationErr *ValidationErr
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Validate(breached) error = %v, want *ValidationErr", err)
+	}
+	if !strings.Contains(validationErr.Message, "утечках") {
+		t.Errorf("Validate(breached) message = %q, want breach explanation", validationErr.Message)
+	}
+
+	// Хранилищу передается только префикс хеша
+	if len(repo.prefixes) != 1 || len(repo.prefixes[0]) != 5 {
+		t.Errorf("requested prefixes = %v, want one 5-character prefix", repo.prefixes)
+	}
+}
+
+func TestPasswordPolicyAcceptsUnlistedPassword(t *testing.T) {
+	repo := &fakeBreachedPasswordRepository{passwords: []string{breachedPassword}}
+	if err := NewPasswordPolicy(repo, 2).Validate(strongPassword); err != nil {
+		t.Errorf("Validate(unlisted) error = %v", err)
+	}
+}
+
+func TestPasswordPolicyWithoutBreachedRepository(t *testing.T) {
+	if err := NewPasswordPolicy(nil, 2).Validate(breachedPassword); err != nil {
+		t.Errorf("Validate() without breached repository error = %v", err)
+	}
+}
+
+func TestPasswordPolicyBreachedRepositoryError(t *testing.T) {
+	repoErr := errors.New("disk failure")
+	repo := &fakeBreachedPasswordRepository{err: repoErr}
+
+	err := NewPasswordPolicy(repo, 2).Validate(strongPassword)
+	var validationErr *ValidationErr
+	if !errors.Is(err, repoErr) || errors.As(err, &validationErr) {
+		t.Errorf("Validate() error = %v, want wrapped repository error", err)
+	}
+}
+
+func TestPasswordPolicyChecksStrengthBeforeBreaches(t *testing.T) {
+	repo := &fakeBreachedPasswordRepository{}
+	policy := NewPasswordPolicy(repo, 2)
+
+	for _, password := range []string{"short1!", "Password1!"} {
+		var validationErr *ValidationErr
+		if err := policy.Validate(password, "alice"); !errors.As(err, &validationErr) {
+			t.Errorf("Validate(%q) error = %v, want *ValidationErr", password, err)
+		}
+	}
+	if len(repo.prefixes) != 0 {
+		t.Errorf("requested prefixes = %v, want none for rejected passwords", repo.prefixes)
+	}
+}
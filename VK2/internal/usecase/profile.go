@@ -0,0 +1,338 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+	"vk/internal/infrastructure/imaging"
+)
+
+// Ограничения полей профиля в символах.
+const (
+	maxDisplayNameLength = 50
+	maxCityLength        = 100
+	maxBioLength         = 1000
+)
+
+// avatarMaxSide — наибольшая сторона аватара в пикселях. Аватар уменьшается
+// при загрузке, поэтому оригинал не хранится.
+const avatarMaxSide = 512
+
+// PublicProfile — открытые сведения о продавце.
+type PublicProfile struct {
+	UserID      string
+	Login       string
+	DisplayName string
+	AvatarURL   string
+	Bio         string
+	City        string // Пустой, если пользователь скрыл город
+	Phone       string // Пустой, если пользователь скрыл телефон
+	MemberSince time.Time
+	AdCount     int      // Число опубликованных объявлений
+	Rating      *float64 // Средняя оценка; nil, пока у продавца нет отзывов
+}
+
+// ProfileUseCase отвечает за профили пользователей.
+type ProfileUseCase struct {
+	userRepo    repository.UserRepository
+	adRepo      repository.AdRepository
+	profileRepo repository.ProfileRepository
+	blobStorage repository.BlobStorage
+	imageURLTTL time.Duration // Срок действия ссылок на аватары
+}
+
+func NewProfileUseCase(userRepo repository.UserRepository, adRepo repository.AdRepository, profileRepo repository.ProfileRepository, blobStorage repository.BlobStorage, imageURLTTL time.Duration) *ProfileUseCase {
+	return &ProfileUseCase{
+		userRepo:    userRepo,
+		adRepo:      adRepo,
+		profileRepo: profileRepo,
+		blobStorage: blobStorage,
+		imageURLTTL: imageURLTTL,
+	}
+}
+
+// GetProfile возвращает пользователя и его профиль со всеми полями, включая скрытые.
+// Предназначен для самого пользователя.
+func (uc *ProfileUseCase) GetProfile(userID string) (*domain.User, *domain.UserProfile, error) {
+	user, err := uc.getUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	profile, err := uc.getProfile(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, profile, nil
+}
+
+// UpdateProfileParameters содержит изменяемые поля профиля. Nil-поля не изменяются,
+// пустая строка очищает поле.
+type UpdateProfileParameters struct {
+	DisplayName *string
+	City        *string
+	Phone       *string
+	Bio         *string
+	CityPublic  *bool
+	PhonePublic *bool
+}
+
+// UpdateProfile частично обновляет профиль пользователя. Изменяются только
+// переданные поля, поэтому параллельная загрузка аватара не теряется.
+func (uc *ProfileUseCase) UpdateProfile(userID string, params UpdateProfileParameters) (*domain.User, *domain.UserProfile, error) {
+	user, err := uc.getUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	update := repository.ProfileUpdate{
+		CityPublic:  params.CityPublic,
+		PhonePublic: params.PhonePublic,
+	}
+	if params.DisplayName != nil {
+		displayName := strings.TrimSpace(*params.DisplayName)
+		if err := validateProfileText(displayName, maxDisplayNameLength, "display_name", false); err != nil {
+			return nil, nil, err
+		}
+		update.DisplayName = &displayName
+	}
+	if params.City != nil {
+		city := strings.TrimSpace(*params.City)
+		if err := validateProfileText(city, maxCityLength, "city", false); err != nil {
+			return nil, nil, err
+		}
+		update.City = &city
+	}
+	if params.Phone != nil {
+		phone, ok := normalizePhone(*params.Phone)
+		if !ok {
+			return nil, nil, &ValidationErr{Message: "телефон должен быть в международном формате, например +79991234567"}
+		}
+		update.Phone = &phone
+	}
+	if params.Bio != nil {
+		bio := strings.TrimSpace(*params.Bio)
+		if err := validateProfileText(bio, maxBioLength, "bio", true); err != nil {
+			return nil, nil, err
+		}
+		update.Bio = &bio
+	}
+
+	profile, err := uc.profileRepo.UpdateProfile(user.ID, update, time.Now().UTC())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to save profile: %w", err)
+	}
+	uc.signAvatarURL(profile)
+	return user, profile, nil
+}
+
+// UploadAvatar заменяет аватар пользователя. Изображение поворачивается согласно
+// EXIF Orientation, уменьшается и сохраняется в JPEG; при перекодировании
+// метаданные не переносятся.
+func (uc *ProfileUseCase) UploadAvatar(userID string, data []byte) (*domain.User, *domain.UserProfile, error) {
+	user, err := uc.getUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := checkImage(data); err != nil {
+		return nil, nil, err
+	}
+	img, err := imaging.DecodeOriented(data)
+	if err != nil {
+		return nil, nil, &ValidationErr{Message: "файл изображения поврежден"}
+	}
+	encoded, err := imaging.EncodeJPEG(imaging.ResizeToFit(img, avatarMaxSide))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Новый ключ для каждого аватара: старые подписанные ссылки не покажут новое изображение
+	key := fmt.Sprintf("avatars/%s/%s.jpg", user.ID, uuid.New().String())
+	if err := uc.blobStorage.Put(key, encoded, imaging.ContentTypeJPEG); err != nil {
+		return nil, nil, fmt.Errorf("failed to save avatar: %w", err)
+	}
+
+	profile, err := uc.replaceAvatarKey(user.ID, key)
+	if err != nil {
+		uc.deleteBlob(key)
+		return nil, nil, err
+	}
+	return user, profile, nil
+}
+
+// DeleteAvatar удаляет аватар пользователя.
+func (uc *ProfileUseCase) DeleteAvatar(userID string) (*domain.User, *domain.UserProfile, error) {
+	user, profile, err := uc.GetProfile(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if profile.AvatarKey == "" {
+		return user, profile, nil
+	}
+
+	profile, err = uc.replaceAvatarKey(user.ID, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, profile, nil
+}
+
+// GetPublicProfile возвращает открытый профиль продавца. Скрытые пользователем
+// поля не заполняются.
+func (uc *ProfileUseCase) GetPublicProfile(userID string) (*PublicProfile, error) {
+	user, profile, err := uc.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	adCount, err := uc.adRepo.CountAds(repository.AdFilter{
+		UserID:   user.ID,
+		Statuses: []string{domain.AdStatusPublished},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count ads: %w", err)
+	}
+
+	// Отзывов о продавцах пока нет, поэтому рейтинг не вычисляется
+	public := &PublicProfile{
+		UserID:      user.ID,
+		Login:       user.Login,
+		DisplayName: profile.DisplayName,
+		AvatarURL:   profile.AvatarURL,
+		Bio:         profile.Bio,
+		MemberSince: user.CreatedAt,
+		AdCount:     adCount,
+	}
+	if profile.CityPublic {
+		public.City = profile.City
+	}
+	if profile.PhonePublic {
+		public.Phone = profile.Phone
+	}
+	return public, nil
+}
+
+// getUser находит пользователя по ID.
+func (uc *ProfileUseCase) getUser(userID string) (*domain.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	user, err := uc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// getProfile загружает профиль пользователя; незаполненный профиль возвращается пустым.
+func (uc *ProfileUseCase) getProfile(userID string) (*domain.UserProfile, error) {
+	profile, err := uc.profileRepo.GetProfile(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+	if profile == nil {
+		profile = domain.NewUserProfile(userID)
+	}
+	uc.signAvatarURL(profile)
+	return profile, nil
+}
+
+// replaceAvatarKey сохраняет новый ключ аватара и удаляет файл прежнего.
+// Прежний ключ берется из хранилища в момент замены: при параллельных
+// загрузках каждый замененный файл удаляется, а не остается без записи.
+func (uc *ProfileUseCase) replaceAvatarKey(userID, key string) (*domain.UserProfile, error) {
+	profile, oldKey, err := uc.profileRepo.ReplaceAvatarKey(userID, key, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to save profile: %w", err)
+	}
+	if oldKey != "" && oldKey != key {
+		uc.deleteBlob(oldKey)
+	}
+	uc.signAvatarURL(profile)
+	return profile, nil
+}
+
+// signAvatarURL заполняет подписанную ссылку на аватар.
+func (uc *ProfileUseCase) signAvatarURL(profile *domain.UserProfile) {
+	profile.AvatarURL = ""
+	if profile.AvatarKey == "" {
+		return
+	}
+	url, err := uc.blobStorage.SignedURL(profile.AvatarKey, uc.imageURLTTL)
+	if err != nil {
+		log.Printf("failed to sign avatar url for user %s: %v", profile.UserID, err)
+		return
+	}
+	profile.AvatarURL = url
+}
+
+// deleteBlob удаляет файл, которому больше не соответствует ни одна запись.
+func (uc *ProfileUseCase) deleteBlob(key string) {
+	if err := uc.blobStorage.Delete(key); err != nil {
+		log.Printf("failed to delete blob %s: %v", key, err)
+	}
+}
+
+// validateProfileText проверяет длину текстового поля профиля и отсутствие
+// управляющих символов. Переводы строк допускаются только в многострочных полях.
+func validateProfileText(value string, maxLength int, field string, multiline bool) error {
+	if !utf8.ValidString(value) {
+		return &ValidationErr{Message: fmt.Sprintf("поле %s содержит недопустимые символы", field)}
+	}
+	if utf8.RuneCountInString(value) > maxLength {
+		return &ValidationErr{Message: fmt.Sprintf("поле %s должно быть не длиннее %d символов", field, maxLength)}
+	}
+	for _, r := range value {
+		if unicode.IsControl(r) && !(multiline && (r == '\n' || r == '\r' || r == '\t')) {
+			return &ValidationErr{Message: fmt.Sprintf("поле %s содержит недопустимые символы", field)}
+		}
+	}
+	return nil
+}
+
+// normalizePhone приводит номер телефона к формату E.164: убирает пробелы, дефисы,
+// скобки и точки, российский номер с ведущей 8 переводит в +7. Пустая строка допустима
+// и означает удаление телефона.
+func normalizePhone(phone string) (string, bool) {
+	var digits strings.Builder
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return "", true
+	}
+	for i, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '.':
+		default:
+			return "", false
+		}
+	}
+
+	number := digits.String()
+	if !strings.HasPrefix(phone, "+") {
+		// Без кода страны принимается только российский номер вида 8XXXXXXXXXX
+		if len(number) != 11 || number[0] != '8' {
+			return "", false
+		}
+		number = "7" + number[1:]
+	}
+	// E.164: до 15 цифр, код страны не начинается с нуля
+	if len(number) < 8 || len(number) > 15 || number[0] == '0' {
+		return "", false
+	}
+	return "+" + number, true
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+)
+
+var (
+	ErrUserNotFound = errors.New("пользователь не найден")
+	ErrUnknownRole  = errors.New("неизвестная роль")
+)
+
+// Actor описывает пользователя, выполняющего действие, и его разрешения из токена.
+type Actor struct {
+	UserID      string
+	Role        string
+	Permissions []string
+}
+
+// Can проверяет наличие у пользователя разрешения.
+func (a Actor) Can(permission string) bool {
+	for _, p := range a.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type RoleUseCase struct {
+	userRepo    repository.UserRepository
+	roleRepo    repository.RoleRepository
+	sessionRepo repository.SessionRepository
+}
+
+func NewRoleUseCase(userRepo repository.UserRepository, roleRepo repository.RoleRepository, sessionRepo repository.SessionRepository) *RoleUseCase {
+	return &RoleUseCase{
+		userRepo:    userRepo,
+		roleRepo:    roleRepo,
+		sessionRepo: sessionRepo,
+	}
+}
+
+// SetUserRole назначает пользователю роль. Если новая роль лишает пользователя
+// хотя бы одного разрешения, все его сессии завершаются сразу: иначе выданные
+// токены сохраняли бы прежние разрешения до истечения. Расширенные разрешения
+// попадают в токены при следующем входе или обновлении токена.
+func (uc *RoleUseCase) SetUserRole(userID, role string) (*domain.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	user, err := uc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить пользователя: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return uc.setRole(user, role)
+}
+
+// PromoteToAdmin назначает роль администратора пользователю с указанным логином.
+// Используется для создания первого администратора.
+func (uc *RoleUseCase) PromoteToAdmin(login string) (*domain.User, error) {
+	user, err := uc.userRepo.GetUserByLogin(login)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить пользователя: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return uc.setRole(user, domain.RoleAdmin)
+}
+
+func (uc *RoleUseCase) setRole(user *domain.User, role string) (*domain.User, error) {
+	exists, err := uc.roleRepo.RoleExists(role)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось проверить роль: %w", err)
+	}
+	if !exists {
+		return nil, ErrUnknownRole
+	}
+
+	revoke, err := uc.losesPermissions(user.Role, role)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.userRepo.UpdateUserRole(user.ID, role); err != nil {
+		return nil, fmt.Errorf("не удалось изменить роль: %w", err)
+	}
+	if revoke {
+		if err := uc.sessionRepo.RevokeUserSessions(user.ID, time.Now().UTC()); err != nil {
+			return nil, fmt.Errorf("не удалось завершить сессии пользователя: %w", err)
+		}
+	}
+
+	user.Role = role
+	return user, nil
+}
+
+// losesPermissions сообщает, есть ли у роли oldRole разрешения, которых нет у newRole.
+func (uc *RoleUseCase) losesPermissions(oldRole, newRole string) (bool, error) {
+	if oldRole == newRole {
+		return false, nil
+	}
+	oldPermissions, err := uc.roleRepo.GetRolePermissions(oldRole)
+	if err != nil {
+		return false, fmt.Errorf("не удалось получить разрешения роли: %w", err)
+	}
+	newPermissions, err := uc.roleRepo.GetRolePermissions(newRole)
+	if err != nil {
+		return false, fmt.Errorf("не удалось получить разрешения роли: %w", err)
+	}
+	kept := Actor{Permissions: newPermissions}
+	for _, permission := range oldPermissions {
+		if !kept.Can(permission) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"vk/internal/domain"
+)
+
+var (
+	ErrSessionRevoked = errors.New("сессия завершена или истекла")
+)
+
+// maxUserAgentLength ограничивает длину сохраняемого User-Agent.
+const maxUserAgentLength = 512
+
+// ClientInfo описывает устройство, с которого выполняется вход.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// ValidateSession проверяет, что сессия из access-токена не отозвана и не истекла,
+// и отмечает ее активность.
+func (uc *AuthUseCase) ValidateSession(userID, sessionID string) error {
+	session, err := uc.sessionRepo.GetSessionByID(sessionID)
+	if err != nil {
+		return fmt.Errorf("не удалось получить сессию: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if session == nil || session.UserID != userID || session.RevokedAt != nil || now.After(session.ExpiresAt) {
+		return ErrSessionRevoked
+	}
+
+	if err := uc.sessionRepo.TouchSession(sessionID, now); err != nil {
+		return fmt.Errorf("не удалось обновить сессию: %w", err)
+	}
+	return nil
+}
+
+// ListSessions возвращает активные сессии пользователя.
+func (uc *AuthUseCase) ListSessions(userID string) ([]domain.Session, error) {
+	sessions, err := uc.sessionRepo.ListActiveSessions(userID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить список сессий: %w", err)
+	}
+	return sessions, nil
+}
+
+// Logout завершает текущую сессию пользователя.
+func (uc *AuthUseCase) Logout(userID, sessionID string) error {
+	session, err := uc.sessionRepo.GetSessionByID(sessionID)
+	if err != nil {
+		return fmt.Errorf("не удалось получить сессию: %w", err)
+	}
+	if session == nil || session.UserID != userID {
+		return ErrSessionRevoked
+	}
+
+	if err := uc.sessionRepo.RevokeSession(sessionID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("не удалось завершить сессию: %w", err)
+	}
+	return nil
+}
+
+// LogoutAll завершает все сессии пользователя на всех устройствах.
+func (uc *AuthUseCase) LogoutAll(userID string) error {
+	if err := uc.sessionRepo.RevokeUserSessions(userID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("не удалось завершить сессии: %w", err)
+	}
+	return nil
+}
+
+// truncateUTF8 обрезает строку до maxBytes байт по границе символа.
+// Некорректные последовательности UTF-8 удаляются, иначе PostgreSQL не примет строку.
+func truncateUTF8(value string, maxBytes int) string {
+	value = strings.ToValidUTF8(value, "")
+	if len(value) <= maxBytes {
+		return value
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return value[:cut]
+}
+
+// createSession открывает новую сессию для пользователя.
+func (uc *AuthUseCase) createSession(userID string, client ClientInfo) (*domain.Session, error) {
+	userAgent := truncateUTF8(client.UserAgent, maxUserAgentLength)
+
+	now := time.Now().UTC()
+	session := &domain.Session{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(uc.refreshTokenExpiration),
+	}
+	if err := uc.sessionRepo.CreateSession(session); err != nil {
+		return nil, fmt.Errorf("не удалось создать сессию: %w", err)
+	}
+	return session, nil
+}
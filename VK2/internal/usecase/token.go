@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"vk/internal/domain"
+	"vk/internal/infrastructure/util"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("неверный или истекший refresh-токен")
+	ErrRefreshTokenReused  = errors.New("refresh-токен уже был использован, все сессии этого входа отозваны")
+)
+
+// AuthTokens содержит короткоживущий access-токен и refresh-токен для его обновления.
+type AuthTokens struct {
+	AccessToken     string
+	AccessExpiresIn time.Duration
+	RefreshToken    string
+}
+
+// RefreshTokens обменивает refresh-токен на новую пару токенов (ротация).
+// Повторное предъявление уже использованного токена считается признаком
+// утечки: все токены этого семейства отзываются.
+func (uc *AuthUseCase) RefreshTokens(refreshToken string) (*AuthTokens, error) {
+	stored, err := uc.refreshTokenRepo.GetRefreshTokenByHash(util.HashOpaqueToken(refreshToken))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить refresh-токен: %w", err)
+	}
+	if stored == nil || stored.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	now := time.Now().UTC()
+	if stored.RotatedAt != nil {
+		return nil, uc.revokeReusedFamily(stored.FamilyID, now)
+	}
+	if now.After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	rotated, err := uc.refreshTokenRepo.MarkRefreshTokenRotated(stored.ID, now)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось обновить refresh-токен: %w", err)
+	}
+	if !rotated {
+		// Токен успели использовать параллельным запросом
+		return nil, uc.revokeReusedFamily(stored.FamilyID, now)
+	}
+
+	// Семейство refresh-токенов соответствует сессии: продлеваем ее вместе с токеном
+	if err := uc.sessionRepo.ExtendSession(stored.FamilyID, now.Add(uc.refreshTokenExpiration)); err != nil {
+		return nil, fmt.Errorf("не удалось продлить сессию: %w", err)
+	}
+	if err := uc.sessionRepo.TouchSession(stored.FamilyID, now); err != nil {
+		return nil, fmt.Errorf("не удалось обновить сессию: %w", err)
+	}
+
+	// Роль перечитывается при каждом обновлении, чтобы ее изменение вступало в силу
+	user, err := uc.userRepo.GetUserByID(stored.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить пользователя: %w", err)
+	}
+	if user == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return uc.issueTokens(user, stored.FamilyID)
+}
+
+// issueTokens выпускает access-токен и новый refresh-токен для сессии.
+// ID сессии одновременно служит идентификатором семейства refresh-токенов.
+func (uc *AuthUseCase) issueTokens(user *domain.User, sessionID string) (*AuthTokens, error) {
+	permissions, err := uc.roleRepo.GetRolePermissions(user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить разрешения роли: %w", err)
+	}
+
+	accessToken, err := uc.tokenManager.GenerateToken(util.TokenClaims{
+		UserID:      user.ID,
+		SessionID:   sessionID,
+		Role:        user.Role,
+		Permissions: permissions,
+	}, uc.tokenExpiration)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сгенерировать токен: %w", err)
+	}
+
+	refreshToken, err := util.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сгенерировать refresh-токен: %w", err)
+	}
+
+	now := time.Now().UTC()
+	stored := &domain.RefreshToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		FamilyID:  sessionID,
+		TokenHash: util.HashOpaqueToken(refreshToken),
+		ExpiresAt: now.Add(uc.refreshTokenExpiration),
+		CreatedAt: now,
+	}
+	if err := uc.refreshTokenRepo.CreateRefreshToken(stored); err != nil {
+		return nil, fmt.Errorf("не удалось сохранить refresh-токен: %w", err)
+	}
+
+	return &AuthTokens{
+		AccessToken:     accessToken,
+		AccessExpiresIn: uc.tokenExpiration,
+		RefreshToken:    refreshToken,
+	}, nil
+}
+
+// revokeReusedFamily отзывает сессию и все ее refresh-токены при обнаружении повторного использования.
+func (uc *AuthUseCase) revokeReusedFamily(familyID string, now time.Time) error {
+	if err := uc.sessionRepo.RevokeSession(familyID, now); err != nil {
+		return fmt.Errorf("не удалось отозвать семейство refresh-токенов: %w", err)
+	}
+	return ErrRefreshTokenReused
+}
@@ -0,0 +1,385 @@
+package usecase
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"vk/internal/adapter/repository"
+	"vk/internal/domain"
+	"vk/internal/infrastructure/util"
+)
+
+const (
+	// recoveryCodeCount — сколько кодов восстановления выдается при подключении.
+	recoveryCodeCount = 10
+	// recoveryCodeAlphabet не содержит похожих символов (0/o, 1/l/i).
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	// totpSkew — допуск в интервалах на расхождение часов устройства и сервера.
+	totpSkew = 1
+	// loginChallengeTTL — время на ввод кода после проверки пароля.
+	loginChallengeTTL = 5 * time.Minute
+	// maxLoginChallengeAttempts — попыток ввода кода на один вход; дальше нужно
+	// снова ввести пароль.
+	maxLoginChallengeAttempts = 5
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("двухфакторная аутентификация уже подключена")
+	ErrTwoFactorNotEnabled     = errors.New("двухфакторная аутентификация не подключена")
+	ErrTwoFactorNotEnrolled    = errors.New("сначала начните подключение двухфакторной аутентификации")
+	ErrInvalidTwoFactorCode    = errors.New("неверный код подтверждения")
+	ErrInvalidLoginChallenge   = errors.New("вход не найден или устарел, войдите заново")
+)
+
+// TwoFactorEnrollment — данные для добавления секрета в приложение-аутентификатор.
+type TwoFactorEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// TwoFactorChallenge — незавершенный вход, который нужно подтвердить кодом.
+type TwoFactorChallenge struct {
+	Token     string
+	ExpiresIn time.Duration
+}
+
+// TwoFactorUseCase отвечает за двухфакторную аутентификацию по TOTP (RFC 6238).
+type TwoFactorUseCase struct {
+	userRepo        repository.UserRepository
+	twoFactorRepo   repository.TwoFactorRepository
+	challengeRepo   repository.LoginChallengeRepository
+	sessionRepo     repository.SessionRepository
+	loginThrottle   *LoginThrottleUseCase
+	passwordManager *util.PasswordManager
+	recoveryCodeKey []byte // Ключ HMAC, которым хешируются коды восстановления
+	issuer          string // Название сервиса в приложении-аутентификаторе
+}
+
+func NewTwoFactorUseCase(userRepo repository.UserRepository, twoFactorRepo repository.TwoFactorRepository, challengeRepo repository.LoginChallengeRepository, sessionRepo repository.SessionRepository, loginThrottle *LoginThrottleUseCase, passwordManager *util.PasswordManager, recoveryCodeKey []byte, issuer string) *TwoFactorUseCase {
+	return &TwoFactorUseCase{
+		userRepo:        userRepo,
+		twoFactorRepo:   twoFactorRepo,
+		challengeRepo:   challengeRepo,
+		sessionRepo:     sessionRepo,
+		loginThrottle:   loginThrottle,
+		passwordManager: passwordManager,
+		recoveryCodeKey: recoveryCodeKey,
+		issuer:          issuer,
+	}
+}
+
+// Enroll начинает подключение второго фактора: создает новый секрет и возвращает
+// ссылку для приложения-аутентификатора. Вход остается однофакторным, пока
+// подключение не подтверждено кодом (Confirm). Повторный вызов заменяет секрет.
+// Требует пароль: одного токена доступа недостаточно, чтобы подключить свой секрет.
+func (uc *TwoFactorUseCase) Enroll(userID, password, ip string) (*TwoFactorEnrollment, error) {
+	user, err := uc.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.checkPassword(user, password, ip); err != nil {
+		return nil, err
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	saved, err := uc.twoFactorRepo.SavePendingTOTPSecret(&domain.TOTPSecret{
+		UserID:    user.ID,
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сохранить секрет: %w", err)
+	}
+	if !saved {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	return &TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: util.TOTPProvisioningURI(uc.issuer, user.Login, secret),
+	}, nil
+}
+
+// Confirm подтверждает подключение кодом из приложения и возвращает коды
+// восстановления. Коды показываются один раз: в хранилище попадают только их хеши.
+// Остальные сессии пользователя завершаются, текущая (sessionID) сохраняется:
+// открытые до подключения сессии не должны обходить второй фактор. Как и Enroll,
+// требует пароль; неверные пароль и код учитываются теми же счетчиками, что и при входе.
+func (uc *TwoFactorUseCase) Confirm(userID, sessionID, password, code, ip string) ([]string, error) {
+	user, err := uc.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.checkPassword(user, password, ip); err != nil {
+		return nil, err
+	}
+
+	totp, err := uc.getTOTPSecret(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if totp == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if totp.Enabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	if err := uc.loginThrottle.BeginTwoFactorAttempt(user.ID); err != nil {
+		return nil, err
+	}
+	step, ok := util.MatchTOTP(totp.Secret, normalizeTOTPCode(code), time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	uc.loginThrottle.TwoFactorAttemptSucceeded(user.ID)
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, uc.hashRecoveryCode(code))
+	}
+
+	enabled, err := uc.twoFactorRepo.EnableTOTP(userID, step, time.Now().UTC(), hashes)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось подключить двухфакторную аутентификацию: %w", err)
+	}
+	if !enabled {
+		// Подключение подтвердили параллельным запросом
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if err := uc.sessionRepo.RevokeOtherUserSessions(userID, sessionID, time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("не удалось завершить остальные сессии: %w", err)
+	}
+	return codes, nil
+}
+
+// Disable отключает второй фактор. Требует пароль и действующий код из
+// приложения или код восстановления. Неверные пароль и код учитываются теми же
+// счетчиками, что и при входе с адреса ip.
+func (uc *TwoFactorUseCase) Disable(userID, password, code, ip string) error {
+	user, err := uc.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if err := uc.checkPassword(user, password, ip); err != nil {
+		return err
+	}
+
+	totp, err := uc.getTOTPSecret(user.ID)
+	if err != nil {
+		return err
+	}
+	if !totp.Enabled() {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := uc.loginThrottle.BeginTwoFactorAttempt(user.ID); err != nil {
+		return err
+	}
+	if err := uc.verifyCode(totp, code); err != nil {
+		return err
+	}
+	uc.loginThrottle.TwoFactorAttemptSucceeded(user.ID)
+
+	if err := uc.twoFactorRepo.DeleteTOTP(user.ID); err != nil {
+		return fmt.Errorf("не удалось отключить двухфакторную аутентификацию: %w", err)
+	}
+	return nil
+}
+
+// StartLogin вызывается после проверки пароля. Если второй фактор подключен,
+// создает незавершенный вход; иначе возвращает nil.
+func (uc *TwoFactorUseCase) StartLogin(userID string) (*TwoFactorChallenge, error) {
+	totp, err := uc.getTOTPSecret(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !totp.Enabled() {
+		return nil, nil
+	}
+
+	token, err := util.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сгенерировать токен входа: %w", err)
+	}
+	now := time.Now().UTC()
+	if err := uc.challengeRepo.CreateLoginChallenge(&domain.LoginChallenge{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		TokenHash: util.HashOpaqueToken(token),
+		ExpiresAt: now.Add(loginChallengeTTL),
+		CreatedAt: now,
+	}); err != nil {
+		return nil, fmt.Errorf("не удалось сохранить вход: %w", err)
+	}
+	return &TwoFactorChallenge{Token: token, ExpiresIn: loginChallengeTTL}, nil
+}
+
+// CompleteLogin проверяет код для незавершенного входа и возвращает ID
+// пользователя. После maxLoginChallengeAttempts неверных кодов вход нужно
+// начинать заново с пароля, а после нескольких неверных кодов подряд по всем
+// входам пользователя ввод кода временно блокируется (RateLimitErr).
+func (uc *TwoFactorUseCase) CompleteLogin(challengeToken, code string) (string, error) {
+	challenge, err := uc.challengeRepo.GetLoginChallengeByHash(util.HashOpaqueToken(challengeToken))
+	if err != nil {
+		return "", fmt.Errorf("не удалось получить вход: %w", err)
+	}
+	if challenge == nil || challenge.UsedAt != nil || time.Now().UTC().After(challenge.ExpiresAt) {
+		return "", ErrInvalidLoginChallenge
+	}
+	claimed, err := uc.challengeRepo.ClaimLoginChallengeAttempt(challenge.ID, maxLoginChallengeAttempts)
+	if err != nil {
+		return "", fmt.Errorf("не удалось обновить вход: %w", err)
+	}
+	if !claimed {
+		return "", ErrInvalidLoginChallenge
+	}
+
+	totp, err := uc.getTOTPSecret(challenge.UserID)
+	if err != nil {
+		return "", err
+	}
+	if !totp.Enabled() {
+		// Второй фактор отключили, пока вход ожидал кода
+		return "", ErrInvalidLoginChallenge
+	}
+	if err := uc.loginThrottle.BeginTwoFactorAttempt(challenge.UserID); err != nil {
+		return "", err
+	}
+	if err := uc.verifyCode(totp, code); err != nil {
+		return "", err
+	}
+	uc.loginThrottle.TwoFactorAttemptSucceeded(challenge.UserID)
+
+	used, err := uc.challengeRepo.MarkLoginChallengeUsed(challenge.ID, time.Now().UTC())
+	if err != nil {
+		return "", fmt.Errorf("не удалось завершить вход: %w", err)
+	}
+	if !used {
+		return "", ErrInvalidLoginChallenge
+	}
+	return challenge.UserID, nil
+}
+
+// verifyCode принимает код из приложения или код восстановления. Каждый код
+// действует один раз.
+func (uc *TwoFactorUseCase) verifyCode(totp *domain.TOTPSecret, code string) error {
+	// Коды из приложения состоят из 6 цифр, коды восстановления — из 10 символов
+	if totpCode := normalizeTOTPCode(code); len(totpCode) == 6 {
+		step, ok := util.MatchTOTP(totp.Secret, totpCode, time.Now(), totpSkew)
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		accepted, err := uc.twoFactorRepo.UseTOTPStep(totp.UserID, step)
+		if err != nil {
+			return fmt.Errorf("не удалось проверить код: %w", err)
+		}
+		if !accepted {
+			// Код уже использован: перехваченный код нельзя повторить
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := uc.twoFactorRepo.UseRecoveryCode(totp.UserID, uc.hashRecoveryCode(code), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("не удалось проверить код восстановления: %w", err)
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// hashRecoveryCode возвращает HMAC-SHA256 кода восстановления в hex-кодировке.
+// Кодов мало и они короткие, поэтому без ключа, который не хранится в БД, их
+// хеши из утекшей базы можно было бы подобрать перебором.
+func (uc *TwoFactorUseCase) hashRecoveryCode(code string) string {
+	mac := hmac.New(sha256.New, uc.recoveryCodeKey)
+	mac.Write([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// checkPassword проверяет пароль пользователя, учитывая неверный пароль с
+// адреса ip теми же счетчиками, что и при входе.
+func (uc *TwoFactorUseCase) checkPassword(user *domain.User, password, ip string) error {
+	if err := uc.loginThrottle.BeginPasswordCheck(user.Login, ip); err != nil {
+		return err
+	}
+	if ok, _ := uc.passwordManager.Verify(password, user.PasswordHash); !ok {
+		return ErrWrongPassword
+	}
+	uc.loginThrottle.AttemptSucceeded(user.Login, ip)
+	return nil
+}
+
+// getUser находит пользователя по ID.
+func (uc *TwoFactorUseCase) getUser(userID string) (*domain.User, error) {
+	user, err := uc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить пользователя: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// getTOTPSecret загружает секрет TOTP пользователя; nil, если второй фактор не подключался.
+func (uc *TwoFactorUseCase) getTOTPSecret(userID string) (*domain.TOTPSecret, error) {
+	totp, err := uc.twoFactorRepo.GetTOTPSecret(userID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить секрет: %w", err)
+	}
+	return totp, nil
+}
+
+// generateRecoveryCode создает код восстановления вида xxxxx-xxxxx.
+func generateRecoveryCode() (string, error) {
+	var code strings.Builder
+	alphabetSize := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			code.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", fmt.Errorf("не удалось сгенерировать код восстановления: %w", err)
+		}
+		code.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return code.String(), nil
+}
+
+// normalizeTOTPCode убирает пробелы, которые приложения вставляют в середину кода.
+func normalizeTOTPCode(code string) string {
+	return strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+}
+
+// normalizeRecoveryCode приводит код восстановления к виду, от которого считается хеш:
+// без дефисов и пробелов, в нижнем регистре.
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
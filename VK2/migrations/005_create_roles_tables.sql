@@ -0,0 +1,38 @@
+-- migrations/005_create_roles_tables.sql
+
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(32) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name VARCHAR(64) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role VARCHAR(32) NOT NULL,
+    permission VARCHAR(64) NOT NULL,
+    PRIMARY KEY (role, permission),
+    FOREIGN KEY (role) REFERENCES roles (name) ON DELETE CASCADE,
+    FOREIGN KEY (permission) REFERENCES permissions (name) ON DELETE CASCADE
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('user', 'Обычный пользователь'),
+    ('moderator', 'Модератор объявлений'),
+    ('admin', 'Администратор')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+    ('ads:moderate', 'Изменение и удаление любых объявлений'),
+    ('users:manage_roles', 'Назначение ролей пользователям')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('moderator', 'ads:moderate'),
+    ('admin', 'ads:moderate'),
+    ('admin', 'users:manage_roles')
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL DEFAULT 'user' REFERENCES roles (name);
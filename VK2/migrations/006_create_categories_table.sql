@@ -0,0 +1,25 @@
+-- migrations/006_create_categories_table.sql
+
+CREATE TABLE IF NOT EXISTS categories (
+    id UUID PRIMARY KEY,
+    parent_id UUID,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (parent_id) REFERENCES categories (id) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
+-- Имена уникальны среди соседних категорий (корневые категории сравниваются между собой)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_parent_name ON categories (COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'), LOWER(name));
+
+-- Существующие объявления остаются без категории, новые создаются с обязательной категорией
+ALTER TABLE ads ADD COLUMN IF NOT EXISTS category_id UUID REFERENCES categories (id) ON DELETE RESTRICT;
+CREATE INDEX IF NOT EXISTS idx_ads_category_id ON ads (category_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('categories:manage', 'Управление деревом категорий')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'categories:manage')
+ON CONFLICT DO NOTHING;
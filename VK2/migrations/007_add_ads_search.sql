@@ -0,0 +1,9 @@
+-- migrations/007_add_ads_search.sql
+
+-- Поисковый вектор по заголовку (вес A) и описанию (вес B) с русской морфологией
+ALTER TABLE ads ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('russian', COALESCE(description, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_ads_search_vector ON ads USING GIN (search_vector);
@@ -0,0 +1,4 @@
+-- migrations/008_add_ads_currency.sql
+
+-- Валюта цены объявления (ISO 4217). Существующие цены указаны в рублях.
+ALTER TABLE ads ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'RUB';
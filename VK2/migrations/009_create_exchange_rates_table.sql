@@ -0,0 +1,19 @@
+-- migrations/009_create_exchange_rates_table.sql
+
+-- Курсы валют к базовой валюте (RUB): сколько рублей стоит единица валюты
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    currency CHAR(3) PRIMARY KEY,
+    rate NUMERIC(12, 6) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO exchange_rates (currency, rate) VALUES ('RUB', 1)
+ON CONFLICT (currency) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+    ('rates:manage', 'Управление курсами валют')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'rates:manage')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,6 @@
+-- migrations/010_add_ads_image_key.sql
+
+-- Изображения загружаются в хранилище файлов; в объявлении хранится только ключ файла.
+-- Произвольные ссылки image_url больше не принимаются; уже сохраненные ссылки
+-- переносятся в ad_images миграцией 011, после чего колонка удаляется.
+ALTER TABLE ads ADD COLUMN IF NOT EXISTS image_key VARCHAR(512);
@@ -0,0 +1,54 @@
+-- migrations/011_create_ad_images_table.sql
+
+CREATE TABLE IF NOT EXISTS ad_images (
+    id UUID PRIMARY KEY,
+    ad_id UUID NOT NULL,
+    storage_key VARCHAR(512) NOT NULL,
+    content_type VARCHAR(64) NOT NULL,
+    position INTEGER NOT NULL,
+    is_cover BOOLEAN NOT NULL DEFAULT FALSE,
+    external_url VARCHAR(255), -- Внешняя ссылка у изображений, перенесенных из ads.image_url
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (ad_id) REFERENCES ads (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_ad_images_ad_id_position ON ad_images (ad_id, position);
+-- У объявления не больше одной обложки
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ad_images_cover ON ad_images (ad_id) WHERE is_cover;
+
+-- Единственное изображение объявления становится его обложкой
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'ads' AND column_name = 'image_key') THEN
+        INSERT INTO ad_images (id, ad_id, storage_key, content_type, position, is_cover, created_at)
+        SELECT gen_random_uuid(), id, image_key,
+            CASE
+                WHEN image_key LIKE '%.png' THEN 'image/png'
+                WHEN image_key LIKE '%.webp' THEN 'image/webp'
+                ELSE 'image/jpeg'
+            END,
+            0, TRUE, created_at
+        FROM ads
+        WHERE image_key IS NOT NULL;
+
+        ALTER TABLE ads DROP COLUMN image_key;
+    END IF;
+END $$;
+
+-- Ссылки image_url из первой версии объявлений сохраняются как внешние изображения.
+-- Если у объявления уже есть загруженная обложка, ссылка становится вторым изображением.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'ads' AND column_name = 'image_url') THEN
+        INSERT INTO ad_images (id, ad_id, storage_key, content_type, position, is_cover, external_url, created_at)
+        SELECT gen_random_uuid(), ads.id, '', 'image/jpeg',
+            CASE WHEN cover.ad_id IS NULL THEN 0 ELSE 1 END,
+            cover.ad_id IS NULL,
+            ads.image_url, ads.created_at
+        FROM ads
+        LEFT JOIN ad_images cover ON cover.ad_id = ads.id AND cover.is_cover
+        WHERE ads.image_url IS NOT NULL AND ads.image_url <> '';
+
+        ALTER TABLE ads DROP COLUMN image_url;
+    END IF;
+END $$;
@@ -0,0 +1,25 @@
+-- migrations/012_create_ad_image_variants_table.sql
+
+-- Очередь обработки изображений: фоновый обработчик забирает изображения в статусе
+-- pending, а также зависшие в processing (например, после перезапуска сервиса)
+ALTER TABLE ad_images ADD COLUMN IF NOT EXISTS processing_status VARCHAR(16) NOT NULL DEFAULT 'pending';
+ALTER TABLE ad_images ADD COLUMN IF NOT EXISTS processing_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE ad_images ADD COLUMN IF NOT EXISTS processing_started_at TIMESTAMP WITH TIME ZONE;
+
+-- Внешние изображения не обрабатываются: вариантов у них нет
+UPDATE ad_images SET processing_status = 'ready'
+WHERE external_url IS NOT NULL AND processing_status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_ad_images_processing ON ad_images (created_at)
+    WHERE processing_status IN ('pending', 'processing');
+
+CREATE TABLE IF NOT EXISTS ad_image_variants (
+    image_id UUID NOT NULL,
+    variant VARCHAR(16) NOT NULL,
+    storage_key VARCHAR(512) NOT NULL,
+    content_type VARCHAR(64) NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    PRIMARY KEY (image_id, variant),
+    FOREIGN KEY (image_id) REFERENCES ad_images (id) ON DELETE CASCADE
+);
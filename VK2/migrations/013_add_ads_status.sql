@@ -0,0 +1,9 @@
+-- migrations/013_add_ads_status.sql
+
+-- Статус объявления. Существующие объявления уже показываются в ленте, поэтому считаются опубликованными.
+ALTER TABLE ads ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'published', 'reserved', 'sold', 'archived'));
+
+-- Лента выбирает только опубликованные объявления
+CREATE INDEX IF NOT EXISTS idx_ads_published_created_at ON ads (created_at, id) WHERE status = 'published';
+CREATE INDEX IF NOT EXISTS idx_ads_user_id_status ON ads (user_id, status);
@@ -0,0 +1,13 @@
+-- migrations/014_add_ads_expiration.sql
+
+-- Срок публикации объявления. Существующим объявлениям дается 30 дней, чтобы
+-- владельцы успели продлить актуальные.
+ALTER TABLE ads ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+UPDATE ads SET expires_at = CURRENT_TIMESTAMP + INTERVAL '30 days' WHERE expires_at IS NULL;
+ALTER TABLE ads ALTER COLUMN expires_at SET NOT NULL;
+
+-- Когда владельцу отправлено напоминание о скором истечении срока; сбрасывается при продлении
+ALTER TABLE ads ADD COLUMN IF NOT EXISTS expiry_reminded_at TIMESTAMP WITH TIME ZONE;
+
+-- Фоновая задача ищет истекающие объявления среди активных
+CREATE INDEX IF NOT EXISTS idx_ads_active_expires_at ON ads (expires_at) WHERE status IN ('published', 'reserved');
@@ -0,0 +1,11 @@
+-- migrations/015_add_ads_publish_at.sql
+
+-- Отложенная публикация: объявление в статусе scheduled публикуется в момент publish_at
+ALTER TABLE ads ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE ads DROP CONSTRAINT IF EXISTS ads_status_check;
+ALTER TABLE ads ADD CONSTRAINT ads_status_check
+    CHECK (status IN ('draft', 'scheduled', 'published', 'reserved', 'sold', 'archived'));
+
+-- Планировщик ищет объявления, время публикации которых наступило
+CREATE INDEX IF NOT EXISTS idx_ads_scheduled_publish_at ON ads (publish_at) WHERE status = 'scheduled';
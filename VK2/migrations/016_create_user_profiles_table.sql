@@ -0,0 +1,15 @@
+-- migrations/016_create_user_profiles_table.sql
+
+-- Профиль пользователя. Строка появляется при первом изменении профиля.
+CREATE TABLE IF NOT EXISTS user_profiles (
+    user_id UUID PRIMARY KEY,
+    display_name VARCHAR(50) NOT NULL DEFAULT '',
+    avatar_key VARCHAR(512) NOT NULL DEFAULT '',
+    city VARCHAR(100) NOT NULL DEFAULT '',
+    phone VARCHAR(16) NOT NULL DEFAULT '',
+    bio TEXT NOT NULL DEFAULT '',
+    city_public BOOLEAN NOT NULL DEFAULT TRUE,
+    phone_public BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
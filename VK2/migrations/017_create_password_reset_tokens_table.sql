@@ -0,0 +1,29 @@
+-- migrations/017_create_password_reset_tokens_table.sql
+
+-- Почта для восстановления доступа. У пользователей, зарегистрированных
+-- раньше, она не указана.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email VARCHAR(254);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (lower(email));
+
+-- Токены сброса пароля. Хранится только хеш значения, отправленного в письме.
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    used_at TIMESTAMP WITH TIME ZONE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+
+-- Очередь запросов на сброс пароля. Запрос обрабатывается фоновой задачей,
+-- поэтому время ответа не зависит от того, зарегистрирована ли почта.
+CREATE TABLE IF NOT EXISTS password_reset_requests (
+    id UUID PRIMARY KEY,
+    email VARCHAR(254) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_requests_created_at ON password_reset_requests (created_at);
@@ -0,0 +1,46 @@
+-- migrations/018_add_users_email_verification.sql
+
+-- Время подтверждения почты. Сбрасывается при смене адреса. Пользователи,
+-- зарегистрированные до появления подтверждения, считаются подтвердившими почту
+-- с момента регистрации, чтобы не потерять возможность размещать объявления.
+-- Отметка ставится только при добавлении столбца: повторный запуск миграции
+-- не подтверждает почту новым пользователям.
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'users' AND column_name = 'email_verified_at'
+    ) THEN
+        ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP WITH TIME ZONE;
+        UPDATE users SET email_verified_at = created_at;
+    END IF;
+END $$;
+
+-- Токены подтверждения почты. Токен относится к адресу, на который был отправлен:
+-- после смены почты старые письма не подтверждают новый адрес.
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    email VARCHAR(254) NOT NULL,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    used_at TIMESTAMP WITH TIME ZONE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_verification_tokens_user_id ON email_verification_tokens (user_id, created_at DESC);
+
+-- Исходящие письма. Письма отправляет фоновая задача, поэтому медленный или
+-- недоступный почтовый сервер не задерживает ответы API.
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id UUID PRIMARY KEY,
+    recipient VARCHAR(254) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL, -- Раньше этого момента письмо не отправляется
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_outbox_next_attempt_at ON email_outbox (next_attempt_at);
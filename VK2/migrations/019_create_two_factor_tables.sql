@@ -0,0 +1,34 @@
+-- migrations/019_create_two_factor_tables.sql
+
+-- Секрет TOTP пользователя. Пока enabled_at пуст, подключение не подтверждено
+-- и вход по-прежнему выполняется только по паролю.
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id UUID PRIMARY KEY,
+    secret VARCHAR(64) NOT NULL,
+    enabled_at TIMESTAMP WITH TIME ZONE,
+    last_used_step BIGINT NOT NULL DEFAULT 0, -- Последний принятый интервал: код нельзя использовать повторно
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- Одноразовые коды восстановления на случай потери устройства. Хранятся хеши.
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    UNIQUE (user_id, code_hash)
+);
+
+-- Незавершенные входы: пароль проверен, ожидается код второго фактора.
+CREATE TABLE IF NOT EXISTS login_challenges (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    used_at TIMESTAMP WITH TIME ZONE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
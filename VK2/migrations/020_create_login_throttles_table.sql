@@ -0,0 +1,12 @@
+-- migrations/020_create_login_throttles_table.sql
+
+-- Счетчики неудачных попыток входа для защиты от подбора пароля. Ключ — хеш
+-- логина или IP-адреса с префиксом, поэтому введенные логины не хранятся открыто.
+CREATE TABLE IF NOT EXISTS login_throttles (
+    key VARCHAR(64) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE -- До этого момента попытки входа отклоняются
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_throttles_last_failure_at ON login_throttles (last_failure_at);
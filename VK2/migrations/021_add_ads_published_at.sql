@@ -0,0 +1,14 @@
+-- migrations/021_add_ads_published_at.sql
+
+-- Момент последней публикации объявления: лента по умолчанию сортируется по нему,
+-- поэтому запланированное, опубликованное из черновика или возвращенное из архива
+-- объявление появляется в начале ленты, а не на месте даты создания.
+-- У объявлений, которые еще не публиковались, колонка пуста.
+ALTER TABLE ads ADD COLUMN IF NOT EXISTS published_at TIMESTAMP WITH TIME ZONE;
+
+-- Существующие объявления, побывавшие в ленте, считаются опубликованными в момент создания
+UPDATE ads SET published_at = created_at
+WHERE published_at IS NULL AND status IN ('published', 'reserved', 'sold', 'archived');
+
+-- Лента сортирует по моменту публикации, а до публикации — по моменту создания
+CREATE INDEX IF NOT EXISTS idx_ads_published_published_at ON ads ((COALESCE(published_at, created_at)), id) WHERE status = 'published';